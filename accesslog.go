@@ -0,0 +1,103 @@
+package nanojack
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// AccessLogFormat is the layout of the lines produced by an AccessLog.
+type AccessLogFormat int
+
+const (
+	// CommonLogFormat is the Apache Common Log Format:
+	//
+	//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.0" 200 2326
+	CommonLogFormat AccessLogFormat = iota
+
+	// CombinedLogFormat is the nginx combined format, which is the Common
+	// Log Format followed by the quoted referer and user agent.
+	CombinedLogFormat
+)
+
+// accessTimeFormat is the time layout of a web access log.
+const accessTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+var (
+	accessMethods  = []string{"GET", "GET", "GET", "GET", "POST", "POST", "PUT", "DELETE", "HEAD"}
+	accessProtos   = []string{"HTTP/1.0", "HTTP/1.1", "HTTP/1.1", "HTTP/2.0"}
+	accessUsers    = []string{"-", "-", "-", "-", "frank", "alice", "bob"}
+	accessStatuses = []int{200, 200, 200, 200, 200, 200, 201, 204, 301, 304, 400, 401, 403, 404, 404, 500, 502, 503}
+	accessExts     = []string{"", "", ".html", ".css", ".js", ".png", ".json"}
+	accessAgents   = []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Safari/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0 Safari/605.1.15",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:81.0) Gecko/20100101 Firefox/81.0",
+		"curl/7.68.0",
+		"Go-http-client/1.1",
+	}
+	accessReferers = []string{"-", "-", "https://www.example.com/", "https://www.google.com/"}
+)
+
+// AccessLog is a Generator of web server access log lines, as written by
+// Apache and nginx. Every field is drawn from a random source seeded with
+// Seed, except the timestamp, which is the current time. To control the rate
+// at which lines are written, use it with a Load.
+type AccessLog struct {
+	// Format is the layout of the generated lines.
+	Format AccessLogFormat `json:"format" yaml:"format"`
+
+	// Seed seeds the random source that the lines are drawn from.
+	Seed int64 `json:"seed" yaml:"seed"`
+
+	// Clock is the source of the time of each request. It defaults to the
+	// system clock.
+	Clock Clock `json:"-" yaml:"-"`
+
+	rng *rand.Rand
+	buf []byte
+}
+
+// Line implements Generator.
+func (a *AccessLog) Line() []byte {
+	if a.rng == nil {
+		a.rng = rand.New(rand.NewSource(a.Seed))
+	}
+
+	b := appendIPv4(a.buf[:0], a.rng)
+	b = append(b, " - "...)
+	b = append(b, pick(a.rng, accessUsers)...)
+	b = append(b, " ["...)
+	b = clockNow(a.Clock).AppendFormat(b, accessTimeFormat)
+	b = append(b, "] \""...)
+	b = append(b, pick(a.rng, accessMethods)...)
+	b = append(b, ' ')
+	for i, n := 0, 1+a.rng.Intn(3); i < n; i++ {
+		b = append(b, '/')
+		b = append(b, pick(a.rng, words)...)
+	}
+	b = append(b, pick(a.rng, accessExts)...)
+	b = append(b, ' ')
+	b = append(b, pick(a.rng, accessProtos)...)
+	b = append(b, "\" "...)
+	status := accessStatuses[a.rng.Intn(len(accessStatuses))]
+	b = strconv.AppendInt(b, int64(status), 10)
+	b = append(b, ' ')
+	size := 0
+	if status != 204 && status != 304 {
+		size = a.rng.Intn(50000)
+	}
+	if size == 0 && a.Format == CommonLogFormat {
+		b = append(b, '-')
+	} else {
+		b = strconv.AppendInt(b, int64(size), 10)
+	}
+	if a.Format == CombinedLogFormat {
+		b = append(b, " \""...)
+		b = append(b, pick(a.rng, accessReferers)...)
+		b = append(b, "\" \""...)
+		b = append(b, pick(a.rng, accessAgents)...)
+		b = append(b, '"')
+	}
+	a.buf = b
+	return b
+}
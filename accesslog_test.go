@@ -0,0 +1,59 @@
+package nanojack
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogFormats(t *testing.T) {
+	clock := NewFakeClock(time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60)))
+	clf := `^\d+\.\d+\.\d+\.\d+ - [a-z-]+ \[10/Oct/2000:13:55:36 -0700\] "[A-Z]+ (/[a-z]+)+(\.[a-z]+)? HTTP/[0-9.]+" \d{3} (\d+|-)`
+	tests := []struct {
+		format AccessLogFormat
+		re     *regexp.Regexp
+	}{
+		{CommonLogFormat, regexp.MustCompile(clf + `$`)},
+		{CombinedLogFormat, regexp.MustCompile(clf + ` "[^"]+" "[^"]+"$`)},
+	}
+	for _, tt := range tests {
+		a := &AccessLog{Format: tt.format, Seed: 3, Clock: clock}
+		for i := 0; i < 100; i++ {
+			line := a.Line()
+			require.Regexp(t, tt.re, string(line))
+		}
+	}
+}
+
+func TestAccessLogDeterministic(t *testing.T) {
+	clock := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC))
+	lines := func(seed int64) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, Generate(&buf, &AccessLog{Format: CombinedLogFormat, Seed: seed, Clock: clock}, 20))
+		return buf.Bytes()
+	}
+	require.Equal(t, lines(1), lines(1))
+	require.NotEqual(t, lines(1), lines(2))
+}
+
+func TestLoadRate(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(start)
+	l := &Logger{Filename: logFile(dir), MaxLines: 100, Clock: clock}
+	defer l.Close()
+
+	load := &Load{Generator: &AccessLog{Seed: 1, Clock: clock}, Rate: 10, Clock: clock}
+	require.NoError(t, load.Run(l, 21))
+	existsWithLines(logFile(dir), 21, t)
+
+	// the first line is written immediately, and the rest a tenth of a
+	// second apart
+	require.Equal(t, 2*time.Second, clock.Now().Sub(start))
+}
@@ -0,0 +1,156 @@
+package nanojack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultArchiveBackoff is the delay before the first retry of a failed
+// archive when ArchiveBackoff is not set.
+const defaultArchiveBackoff = time.Second
+
+// Archiver archives backups after rotation, for example by copying them to
+// remote storage, so that destinations such as GCS, Azure Blob storage or
+// SFTP can be plugged in without nanojack depending on their SDKs.
+type Archiver interface {
+	// Archive archives the backup at path. It is called in the background,
+	// so a sequential backup may already have been renamed by a later
+	// rotation if archiving falls behind.
+	Archive(path string) error
+}
+
+// fileArchiver is implemented by the Archivers of this package, which can
+// read the backups of a Logger on any FileSystem. Other Archivers are given
+// paths on the real file system.
+type fileArchiver interface {
+	archiveFile(fsys FileSystem, path string) error
+}
+
+// ArchiverFunc adapts a function to the Archiver interface.
+type ArchiverFunc func(path string) error
+
+// Archive calls f(path).
+func (f ArchiverFunc) Archive(path string) error {
+	return f(path)
+}
+
+// LocalArchiver is an Archiver that copies each backup into Dir, keeping its
+// base name and modification time.
+type LocalArchiver struct {
+	// Dir is the directory the backups are copied into. It is created if
+	// it does not exist.
+	Dir string `json:"dir" yaml:"dir"`
+}
+
+// Archive implements Archiver.
+func (a LocalArchiver) Archive(path string) error {
+	return a.archiveFile(osFS{}, path)
+}
+
+// archiveFile copies the backup at path on fsys into Dir, which is always on
+// the real file system.
+func (a LocalArchiver) archiveFile(fsys FileSystem, path string) error {
+	if err := os.MkdirAll(a.Dir, 0744); err != nil {
+		return err
+	}
+	return copyFile(fsys, path, filepath.Join(a.Dir, filepath.Base(path)))
+}
+
+// NopArchiver is an Archiver that does nothing, for exercising the archive
+// path of a Logger without storing anything.
+type NopArchiver struct{}
+
+// Archive implements Archiver.
+func (NopArchiver) Archive(path string) error {
+	return nil
+}
+
+func (NopArchiver) archiveFile(FileSystem, string) error {
+	return nil
+}
+
+// archive runs the Logger's Archivers, and the S3 upload if there is one, on
+// the backup at path in the background. Each Archiver is retried according
+// to ArchiveRetries and ArchiveBackoff, and its outcome is reported in an
+// EventArchive.
+func (l *Logger) archive(path, filename string) {
+	archivers := l.Archivers
+	if l.S3 != nil {
+		archivers = append(archivers[:len(archivers):len(archivers)], l.S3)
+	}
+	if len(archivers) == 0 || path == "" {
+		return
+	}
+	// the file is identified now, so that it is only deleted later if it is
+	// still the same file
+	info, err := l.fs().Stat(path)
+	if err != nil {
+		l.emit(Event{Type: EventArchive, Filename: filename, Path: path, Err: err})
+		return
+	}
+	deleteLocal := l.S3 != nil && l.S3.DeleteLocal
+	retries, backoff := l.ArchiveRetries, l.ArchiveBackoff
+	if backoff <= 0 {
+		backoff = defaultArchiveBackoff
+	}
+
+	go func() {
+		ok := true
+		for _, a := range archivers {
+			err := l.archiveOne(a, path, retries, backoff)
+			if err != nil {
+				ok = false
+				l.debug("error", "archive failed", "backup", path, "error", err)
+			}
+			l.emit(Event{Type: EventArchive, Filename: filename, Path: path, Err: err})
+		}
+		if ok && deleteLocal {
+			if err := l.removeArchived(path, info); err != nil {
+				l.emit(Event{Type: EventArchive, Filename: filename, Path: path, Err: err})
+			}
+		}
+	}()
+}
+
+// archiveOne archives the backup at path with a, retrying up to retries
+// times with a delay that starts at backoff and doubles after each attempt.
+func (l *Logger) archiveOne(a Archiver, path string, retries int, backoff time.Duration) error {
+	for attempt := 0; ; attempt++ {
+		err := l.archiveWith(a, path)
+		if err == nil || attempt >= retries {
+			return err
+		}
+		l.debug("debug", "retrying archive", "backup", path, "attempt", attempt+1, "error", err)
+		l.sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// archiveWith archives the backup at path with a, which must be one of this
+// package's Archivers unless the Logger is on the real file system.
+func (l *Logger) archiveWith(a Archiver, path string) error {
+	fsys := l.fs()
+	if fa, ok := a.(fileArchiver); ok {
+		return fa.archiveFile(fsys, path)
+	}
+	if !realFS(fsys) {
+		return needsRealFS(fmt.Sprintf("Archiver %T", a))
+	}
+	return a.Archive(path)
+}
+
+// removeArchived removes the file at path if it is still the archived file,
+// described by info.
+func (l *Logger) removeArchived(path string, info os.FileInfo) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current, err := l.fs().Stat(path)
+	if err != nil || !sameFile(info, current) {
+		// already removed or renamed away
+		return nil
+	}
+	l.expect(opRemove, path)
+	return l.fs().Remove(path)
+}
@@ -0,0 +1,123 @@
+package nanojack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalArchiver(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "archive")
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:  logFile(dir),
+		MaxLines:  1,
+		Archivers: []Archiver{LocalArchiver{Dir: archive}, NopArchiver{}},
+		Events:    events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	_, err = l.Write([]byte("two\n"))
+	require.NoError(t, err)
+
+	archived := 0
+	for archived < 2 {
+		e := nextEvent(t, events)
+		if e.Type == EventArchive {
+			require.NoError(t, e.Err)
+			require.Equal(t, backupFile(dir), e.Path)
+			archived++
+		}
+	}
+	b, err := ioutil.ReadFile(filepath.Join(archive, filepath.Base(backupFile(dir))))
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(b))
+	existsWithLines(backupFile(dir), 1, t)
+}
+
+func TestArchiveRetry(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(start)
+	var calls int32
+	flaky := ArchiverFunc(func(path string) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:       logFile(dir),
+		MaxLines:       1,
+		Clock:          clock,
+		Archivers:      []Archiver{flaky},
+		ArchiveRetries: 2,
+		ArchiveBackoff: time.Second,
+		Events:         events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	_, err = l.Write([]byte("two\n"))
+	require.NoError(t, err)
+
+	e := nextEvent(t, events)
+	for e.Type != EventArchive {
+		e = nextEvent(t, events)
+	}
+	require.NoError(t, e.Err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	// the retries waited one and then two seconds
+	require.Equal(t, 3*time.Second, clock.Now().Sub(start))
+}
+
+func TestArchiveRetriesExhausted(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var calls int32
+	failing := ArchiverFunc(func(path string) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("unavailable")
+	})
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:       logFile(dir),
+		MaxLines:       1,
+		Clock:          NewFakeClock(time.Now()),
+		Archivers:      []Archiver{failing},
+		ArchiveRetries: 1,
+		Events:         events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	_, err = l.Write([]byte("two\n"))
+	require.NoError(t, err)
+
+	e := nextEvent(t, events)
+	for e.Type != EventArchive {
+		e = nextEvent(t, events)
+	}
+	require.EqualError(t, e.Err, "unavailable")
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
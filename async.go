@@ -0,0 +1,111 @@
+package nanojack
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned by Write when the AsyncQueue is full and
+// AsyncOverflow is OverflowError.
+var ErrQueueFull = errors.New("nanojack: async queue is full")
+
+// OverflowPolicy selects what an asynchronous Logger does with a write when
+// its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the writer until there is room in the queue. This
+	// is the default.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDrop discards the write, reporting it in an EventDropped, and
+	// returns as if it had succeeded.
+	OverflowDrop
+
+	// OverflowError discards the write and returns ErrQueueFull.
+	OverflowError
+)
+
+// asyncWriter performs a Logger's writes on a background goroutine.
+type asyncWriter struct {
+	// mu is held for reading while sending to queue, and for writing while
+	// closing it.
+	mu     sync.RWMutex
+	queue  chan []byte
+	done   chan struct{}
+	closed bool
+}
+
+// enqueue copies p onto the Logger's queue, starting the background writer
+// if necessary. Once Close has stopped the background writer, p is written
+// synchronously instead, since nothing would stop another.
+func (l *Logger) enqueue(p []byte) (int, error) {
+	l.mu.Lock()
+	if l.asyncDone {
+		l.mu.Unlock()
+		return l.write(p)
+	}
+	a := l.async
+	if a == nil {
+		a = &asyncWriter{
+			queue: make(chan []byte, l.AsyncQueue),
+			done:  make(chan struct{}),
+		}
+		l.async = a
+		go a.run(l)
+	}
+	filename := l.filename()
+	l.mu.Unlock()
+
+	buf := append([]byte(nil), p...)
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		// Close raced with this write; write it synchronously instead.
+		return l.write(p)
+	}
+
+	if l.AsyncOverflow == OverflowBlock {
+		a.queue <- buf
+		return len(p), nil
+	}
+	select {
+	case a.queue <- buf:
+		return len(p), nil
+	default:
+	}
+	if l.AsyncOverflow == OverflowError {
+		return 0, ErrQueueFull
+	}
+	l.emit(Event{Type: EventDropped, Filename: filename})
+	return len(p), nil
+}
+
+func (a *asyncWriter) run(l *Logger) {
+	defer close(a.done)
+	for p := range a.queue {
+		if _, err := l.write(p); err != nil {
+			l.emit(Event{Type: EventWriteError, Filename: l.lockedFilename(), Err: err})
+		}
+	}
+}
+
+// stopAsync waits for queued writes to complete and stops the background
+// writer, if there is one.
+func (l *Logger) stopAsync() {
+	l.mu.Lock()
+	a := l.async
+	l.async = nil
+	l.asyncDone = true
+	l.mu.Unlock()
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+	<-a.done
+}
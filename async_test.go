@@ -0,0 +1,142 @@
+package nanojack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsync(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   1000,
+		AsyncQueue: 10,
+	}
+	defer l.Close()
+
+	var expected strings.Builder
+	b := make([]byte, 0, 16)
+	for i := 0; i < 100; i++ {
+		// reuse the buffer, as callers are allowed to
+		b = append(b[:0], fmt.Sprintf("%d\n", i)...)
+		n, err := l.Write(b)
+		require.NoError(t, err)
+		require.Equal(t, len(b), n)
+		expected.Write(b)
+	}
+
+	require.NoError(t, l.Close())
+	content, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, expected.String(), string(content))
+}
+
+func TestAsyncAfterClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		AsyncQueue: 10,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	// the write is done before it returns, without a new background writer
+	_, err = l.Write([]byte("foo!\n"))
+	require.NoError(t, err)
+	existsWithLines(filename, 2, t)
+	l.mu.Lock()
+	require.Nil(t, l.async)
+	l.mu.Unlock()
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	t.Run("Drop", testAsyncOverflow(t, OverflowDrop))
+	t.Run("Error", testAsyncOverflow(t, OverflowError))
+}
+
+func testAsyncOverflow(t *testing.T, policy OverflowPolicy) func(t *testing.T) {
+	return func(t *testing.T) {
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		filename := logFile(dir)
+		events := make(chan Event, 10)
+		l := &Logger{
+			Filename:      filename,
+			AsyncQueue:    1,
+			AsyncOverflow: policy,
+			Events:        events,
+		}
+		defer l.Close()
+
+		// pausing blocks the background writer, so the queue fills up
+		l.Pause()
+		b := []byte("boo!\n")
+		var overflowed int
+		for i := 0; i < 3; i++ {
+			n, err := l.Write(b)
+			if policy == OverflowError && err != nil {
+				require.Equal(t, ErrQueueFull, err)
+				require.Equal(t, 0, n)
+				overflowed++
+				continue
+			}
+			require.NoError(t, err)
+			require.Equal(t, len(b), n)
+		}
+		l.Resume()
+		require.NoError(t, l.Close())
+
+		if policy == OverflowDrop {
+			overflowed = len(events)
+			for len(events) > 0 {
+				require.Equal(t, EventDropped, (<-events).Type)
+			}
+		}
+
+		// one write was taken by the paused writer and one fills the queue,
+		// so at least one overflowed.
+		require.True(t, overflowed >= 1)
+		existsWithLines(filename, int64(3-overflowed), t)
+	}
+}
+
+func TestAsyncWriteError(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:   logFile(dir),
+		AsyncQueue: 10,
+		Events:     events,
+	}
+	defer l.Close()
+	l.Kill()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	e := <-events
+	require.Equal(t, EventWriteError, e.Type)
+	require.Equal(t, ErrKilled, e.Err)
+}
@@ -0,0 +1,175 @@
+package nanojack
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// BackupInfo describes one backup in a Logger's backup chain, as detected by
+// the same parsing of names that cleanup uses.
+type BackupInfo struct {
+	// Path is the path of the backup.
+	Path string `json:"path"`
+
+	// Timestamp is the time parsed from the name of a timestamped backup. It
+	// is zero for sequential backups.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Sequence is the number of a sequential backup, 1 being the newest. It
+	// is zero for timestamped backups.
+	Sequence int `json:"sequence"`
+
+	// Size is the size of the backup in bytes.
+	Size int64 `json:"size"`
+
+	// Lines is the number of lines in the backup. When Gzip is set, the
+	// backup is decompressed to count them.
+	Lines int64 `json:"lines"`
+
+	// Reason says why the rotation that made the backup happened. It is
+	// empty for a backup that was not made by this Logger.
+	Reason RotationReason `json:"reason"`
+
+	// FirstLine and LastLine are the numbers of the first and last lines in
+	// the backup, counting every line written by the Logger from 1 across
+	// all its files, including any found in the log file when it was first
+	// opened. Together they show whether any lines went missing between
+	// backups. They are zero for an empty backup, and for one that was not
+	// made by this Logger.
+	FirstLine int64 `json:"firstline"`
+	LastLine  int64 `json:"lastline"`
+}
+
+// Backups returns the backups of the log file, newest first, so that
+// mismatches between the configured naming and the files on disk can be
+// spotted.
+func (l *Logger) Backups() ([]BackupInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]BackupInfo, 0, len(files))
+	base := filepath.Base(l.filename())
+	for _, f := range files {
+		b := BackupInfo{Path: f.path, Size: f.Size()}
+		if l.Sequential {
+			b.Sequence, _ = l.sequentialNamer().ParseBackup(base, filepath.Base(f.path))
+		} else {
+			b.Timestamp = f.timestamp
+		}
+		backups = append(backups, b)
+	}
+
+	kept := backups[:0]
+	for _, b := range backups {
+		meta := l.metaFor(b.Path)
+		b.Reason, b.FirstLine, b.LastLine = meta.reason, meta.firstLine, meta.lastLine
+		b.Lines, err = l.backupLines(b.Path)
+		if os.IsNotExist(err) {
+			// removed by a cleanup in the background since it was listed
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		kept = append(kept, b)
+	}
+	return kept, nil
+}
+
+// WriteBackups writes a table of the Logger's Backups to w, one backup per
+// line.
+func (l *Logger) WriteBackups(w io.Writer) error {
+	backups, err := l.Backups()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tTIMESTAMP\tSEQUENCE\tSIZE\tLINES\tREASON")
+	for _, b := range backups {
+		ts, seq := "-", "-"
+		if !b.Timestamp.IsZero() {
+			ts = b.Timestamp.Format(time.RFC3339Nano)
+		}
+		if b.Sequence > 0 {
+			seq = fmt.Sprint(b.Sequence)
+		}
+		reason := string(b.Reason)
+		if reason == "" {
+			reason = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n", b.Path, ts, seq, b.Size, b.Lines, reason)
+	}
+	return tw.Flush()
+}
+
+// backupLines counts the lines in the backup at path, decompressing it if it
+// was written with Gzip or compressed by Compress.
+func (l *Logger) backupLines(path string) (int64, error) {
+	if !l.Gzip && !strings.HasSuffix(path, compressSuffix) {
+		return l.countLines(path)
+	}
+
+	f, err := l.fs().OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, err
+	}
+	return countNewlines(zr)
+}
+
+// backupMeta is what the Logger knows about a backup it made beyond what is
+// on disk.
+type backupMeta struct {
+	reason    RotationReason
+	firstLine int64
+	lastLine  int64
+}
+
+// noteBackup remembers meta for the backup at path.
+func (l *Logger) noteBackup(path string, meta backupMeta) {
+	if l.backupMeta == nil {
+		l.backupMeta = make(map[string]backupMeta)
+	}
+	l.backupMeta[filepath.Clean(path)] = meta
+}
+
+// moveBackup carries what is known about the backup at from over to its new
+// name.
+func (l *Logger) moveBackup(from, to string) {
+	meta, ok := l.backupMeta[filepath.Clean(from)]
+	if !ok {
+		return
+	}
+	delete(l.backupMeta, filepath.Clean(from))
+	l.backupMeta[filepath.Clean(to)] = meta
+}
+
+// forgetBackup forgets the backup at path, which is being deleted.
+func (l *Logger) forgetBackup(path string) {
+	delete(l.backupMeta, filepath.Clean(path))
+}
+
+// metaFor returns what is known about the backup at path, which is nothing
+// if it wasn't made by this Logger. A compressed backup has the metadata of
+// the backup it was made from.
+func (l *Logger) metaFor(path string) backupMeta {
+	path = filepath.Clean(path)
+	if meta, ok := l.backupMeta[path]; ok {
+		return meta
+	}
+	return l.backupMeta[strings.TrimSuffix(path, compressSuffix)]
+}
@@ -0,0 +1,106 @@
+package nanojack
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackups(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxLines: 2,
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	first := backupFile(dir)
+
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	second := backupFile(dir)
+
+	backups, err := l.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Equal(t, BackupInfo{Path: second, Timestamp: fakeTime().UTC(), Size: 5, Lines: 1, Reason: ReasonManual, FirstLine: 3, LastLine: 3}, backups[0])
+	require.Equal(t, first, backups[1].Path)
+	require.Equal(t, int64(2), backups[1].Lines)
+	require.Equal(t, ReasonMaxLines, backups[1].Reason)
+	require.Equal(t, int64(1), backups[1].FirstLine)
+	require.Equal(t, int64(2), backups[1].LastLine)
+
+	var buf bytes.Buffer
+	require.NoError(t, l.WriteBackups(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasPrefix(lines[1], second))
+}
+
+func TestBackupsSequential(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		Sequential: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+	require.NoError(t, l.Rotate())
+
+	backups, err := l.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Equal(t, BackupInfo{Path: sequentialName(filename, 1), Sequence: 1, Reason: ReasonManual}, backups[0])
+	require.Equal(t, BackupInfo{Path: sequentialName(filename, 2), Sequence: 2, Size: 5, Lines: 1, Reason: ReasonManual, FirstLine: 1, LastLine: 1}, backups[1])
+}
+
+func TestBackupsDuringCleanup(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clock := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC))
+	l := &Logger{Filename: logFile(dir), MaxLines: 1, MaxBackups: 1, Clock: clock}
+	defer l.Close()
+
+	// old backups are removed in the background while Backups lists them
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			clock.Advance(time.Second)
+			if _, err := l.Write([]byte("boo!\n")); err != nil {
+				return
+			}
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		backups, err := l.Backups()
+		require.NoError(t, err)
+		for _, b := range backups {
+			require.Equal(t, int64(1), b.Lines)
+		}
+		require.NoError(t, l.WriteBackups(&bytes.Buffer{}))
+	}
+}
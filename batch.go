@@ -0,0 +1,61 @@
+package nanojack
+
+// WriteLines writes each of lines to the log file as Write would, but takes
+// the Logger's lock once for the whole batch, and collects the data of the
+// lines into a single write to the file, split only where the file is
+// rotated or synced. This keeps the Logger from being the bottleneck when
+// writing at very high rates. Unlike Write, WriteLines always writes
+// synchronously, whatever AsyncQueue and WriteTimeout are set to.
+//
+// It returns the number of lines written. Since the data is written out
+// after the lines are processed, a failure to write it is reported after
+// the lines it holds have been counted.
+func (l *Logger) WriteLines(lines [][]byte) (int, error) {
+	if l.RateLimit != nil {
+		for _, p := range lines {
+			l.RateLimit.wait(len(p), l.now(), l.sleep)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// with a shared lock, the file must be up to date on disk whenever the
+	// lock is released, which happens after every line
+	if l.Lock == LockShared {
+		for i, p := range lines {
+			if _, err := l.writeLocked(p); err != nil {
+				return i, err
+			}
+		}
+		return len(lines), nil
+	}
+
+	l.batching = true
+	for i, p := range lines {
+		if _, err := l.writeLocked(p); err != nil {
+			l.endBatch()
+			return i, err
+		}
+	}
+	return len(lines), l.endBatch()
+}
+
+// endBatch stops collecting a batch and writes out the data collected.
+func (l *Logger) endBatch() error {
+	err := l.flushBatch()
+	l.batching = false
+	return err
+}
+
+// flushBatch writes out the data collected for a batch so far.
+func (l *Logger) flushBatch() error {
+	if len(l.batch) == 0 {
+		return nil
+	}
+	p, batching := l.batch, l.batching
+	l.batch, l.batching = l.batch[:0], false
+	_, err := l.writeOut(p)
+	l.batching = batching
+	return err
+}
@@ -0,0 +1,67 @@
+package nanojack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLines(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 3,
+	}
+	defer l.Close()
+
+	var lines [][]byte
+	for i := 0; i < 5; i++ {
+		lines = append(lines, []byte(fmt.Sprintf("line %d\n", i)))
+	}
+	n, err := l.WriteLines(lines)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	// the batch was split where the file was rotated
+	content, err := ioutil.ReadFile(backupFile(dir))
+	require.NoError(t, err)
+	require.Equal(t, "line 0\nline 1\nline 2\n", string(content))
+	content, err = ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "line 3\nline 4\n", string(content))
+
+	// single writes carry on from the batch
+	newFakeTime(time.Second)
+	_, err = l.Write([]byte("line 5\n"))
+	require.NoError(t, err)
+	existsWithLines(filename, 3, t)
+	require.False(t, l.batching)
+	require.Empty(t, l.batch)
+}
+
+func TestWriteLinesError(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		FailWhenPaused: true,
+	}
+	defer l.Close()
+
+	l.Pause()
+	n, err := l.WriteLines([][]byte{[]byte("boo!\n")})
+	require.Equal(t, ErrPaused, err)
+	require.Equal(t, 0, n)
+	require.False(t, l.batching)
+}
@@ -0,0 +1,91 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func BenchmarkWrite(b *testing.B) {
+	dir := makeTempDir(b)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxLines: b.N + 1,
+	}
+	defer l.Close()
+
+	line := []byte("benchmark line\n")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := l.Write(line); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteLines(b *testing.B) {
+	dir := makeTempDir(b)
+	defer os.RemoveAll(dir)
+
+	lines := make([][]byte, 100)
+	for i := range lines {
+		lines[i] = []byte("benchmark line\n")
+	}
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxLines: b.N*len(lines) + 1,
+	}
+	defer l.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := l.WriteLines(lines); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRotate(b *testing.B) {
+	currentTime = fakeTime
+	dir := makeTempDir(b)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+	}
+	defer l.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newFakeTime(time.Second)
+		if err := l.Rotate(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRotateSequential(b *testing.B) {
+	dir := makeTempDir(b)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 20,
+		Sequential: true,
+	}
+	defer l.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := l.Rotate(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package nanojack
+
+import (
+	"bufio"
+	"time"
+)
+
+// fileWriter writes through to a Logger's active log file.
+type fileWriter struct {
+	l *Logger
+}
+
+func (w fileWriter) Write(p []byte) (int, error) {
+	return w.l.writeFile(p)
+}
+
+// Flush writes any buffered data to the active log file.
+func (l *Logger) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.flush()
+}
+
+// flush writes any buffered data to the active log file.
+func (l *Logger) flush() error {
+	if l.file == nil {
+		return nil
+	}
+	if err := l.flushBatch(); err != nil {
+		return err
+	}
+	if l.buf != nil {
+		if err := l.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	if l.gz != nil {
+		return l.gz.Flush()
+	}
+	return nil
+}
+
+// writeBuffered writes p to the buffer, creating it and starting the timed
+// flusher if necessary.
+func (l *Logger) writeBuffered(p []byte) (int, error) {
+	if l.buf == nil {
+		l.buf = bufio.NewWriterSize(fileWriter{l}, l.BufferSize)
+	}
+	if l.FlushInterval > 0 && l.flusher == nil {
+		l.flusher = newFlusher(l, l.FlushInterval)
+	}
+	return l.buf.Write(p)
+}
+
+// flusher periodically flushes a Logger's buffer.
+type flusher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newFlusher(l *Logger, interval time.Duration) *flusher {
+	f := &flusher{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	// the ticker is started before returning, so that a Clock advanced
+	// straight after the write that started it is seen
+	ticker := l.ticker(interval)
+	go func() {
+		defer close(f.done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := l.Flush(); err != nil {
+					l.emit(Event{Type: EventWriteError, Filename: l.lockedFilename(), Err: err})
+				}
+			case <-f.stop:
+				return
+			}
+		}
+	}()
+	return f
+}
+
+// stopFlusher stops the timed flusher, if there is one.
+func (l *Logger) stopFlusher() {
+	l.mu.Lock()
+	f := l.flusher
+	l.flusher = nil
+	l.mu.Unlock()
+	if f == nil {
+		return
+	}
+	close(f.stop)
+	<-f.done
+}
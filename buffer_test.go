@@ -0,0 +1,134 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		MaxLines:         3,
+		BufferSize:       4096,
+		DetectTruncation: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 2; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+	// buffered lines are not mistaken for truncation
+	existsWithLines(filename, 0, t)
+
+	require.NoError(t, l.Flush())
+	existsWithLines(filename, 2, t)
+
+	_, err := l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(filename, 2, t)
+
+	// rotation writes out the buffer first
+	newFakeTime(time.Second)
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(backupFile(dir), 3, t)
+	existsWithLines(filename, 0, t)
+
+	require.NoError(t, l.Close())
+	existsWithLines(filename, 1, t)
+}
+
+func TestBufferKill(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		BufferSize: 4096,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, l.Flush())
+	_, err = l.Write(b)
+	require.NoError(t, err)
+
+	l.Kill()
+	require.NoError(t, l.Recover())
+	require.NoError(t, l.Close())
+	existsWithLines(filename, 1, t)
+}
+
+func TestFlushInterval(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		BufferSize:    4096,
+		FlushInterval: 5 * time.Millisecond,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		lines, err := linesInFile(osFS{}, filename)
+		require.NoError(t, err)
+		if lines == 1 {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "buffer was not flushed")
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFlushIntervalClock(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	clock := NewFakeClock(fakeTime())
+	l := &Logger{
+		Filename:      filename,
+		BufferSize:    4096,
+		FlushInterval: time.Minute,
+		Clock:         clock,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	existsWithLines(filename, 0, t)
+
+	// the interval passes on the Clock, not the system clock
+	clock.Advance(time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for {
+		lines, err := linesInFile(osFS{}, filename)
+		require.NoError(t, err)
+		if lines == 1 {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "buffer was not flushed")
+		time.Sleep(time.Millisecond)
+	}
+}
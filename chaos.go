@@ -0,0 +1,120 @@
+package nanojack
+
+import (
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Chaos randomly interferes with a Logger's normal operation in order to
+// stress the software reading its output. Every decision is drawn from a
+// random source seeded with Seed, so a given seed always produces the same
+// sequence of interference for the same sequence of writes.
+//
+// Each probability is evaluated independently before every write, and must
+// be in the range [0, 1]. A zero probability disables that kind of
+// interference.
+type Chaos struct {
+	// Seed seeds the random source that drives all chaos decisions.
+	Seed int64 `json:"seed" yaml:"seed"`
+
+	// RotateProbability is the chance that the log file is rotated early.
+	RotateProbability float64 `json:"rotateprobability" yaml:"rotateprobability"`
+
+	// DeleteProbability is the chance that a random backup file is deleted.
+	DeleteProbability float64 `json:"deleteprobability" yaml:"deleteprobability"`
+
+	// ChmodProbability is the chance that the permissions of the active log
+	// file are flipped. A flip removes all permissions from the file, and the
+	// next flip restores them. The Logger keeps writing through its open
+	// handle, but other processes cannot open the file while it is flipped.
+	ChmodProbability float64 `json:"chmodprobability" yaml:"chmodprobability"`
+
+	// DelayProbability is the chance that the write is delayed by a random
+	// duration in the range [0, MaxDelay).
+	DelayProbability float64 `json:"delayprobability" yaml:"delayprobability"`
+
+	// MaxDelay is the upper bound on a chaos delay.
+	MaxDelay time.Duration `json:"maxdelay" yaml:"maxdelay"`
+
+	rng     *rand.Rand
+	flipped os.FileMode
+	chmoded bool
+}
+
+// strike rolls the dice for every kind of interference and applies the ones
+// that come up. It must be called with the Logger's lock held.
+func (c *Chaos) strike(l *Logger) error {
+	if c.rng == nil {
+		c.rng = rand.New(rand.NewSource(c.Seed))
+	}
+
+	// Every roll is made up front, so that the sequence of random numbers
+	// consumed per write does not depend on the state of the file system.
+	rotate := c.roll(c.RotateProbability)
+	del := c.roll(c.DeleteProbability)
+	chmod := c.roll(c.ChmodProbability)
+	delay := c.roll(c.DelayProbability)
+	pick := c.rng.Int63()
+	var d time.Duration
+	if c.MaxDelay > 0 {
+		d = time.Duration(c.rng.Int63n(int64(c.MaxDelay)))
+	}
+
+	if rotate {
+		l.debug("debug", "rotating", "reason", "chaos")
+		if err := l.rotate(ReasonChaos); err != nil {
+			return err
+		}
+	}
+	if del {
+		if err := c.deleteBackup(l, pick); err != nil {
+			return err
+		}
+	}
+	if chmod {
+		if err := c.flip(l); err != nil {
+			return err
+		}
+	}
+	if delay && d > 0 {
+		l.sleep(d)
+	}
+	return nil
+}
+
+// roll returns true with probability p.
+func (c *Chaos) roll(p float64) bool {
+	return c.rng.Float64() < p
+}
+
+// deleteBackup removes the backup at index pick modulo the number of backups.
+func (c *Chaos) deleteBackup(l *Logger, pick int64) error {
+	files, err := l.oldLogFiles()
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	path := files[pick%int64(len(files))].path
+	l.expect(opRemove, path)
+	return l.fs().Remove(path)
+}
+
+// flip removes all permissions from the active log file, or restores them if
+// they were removed by the previous flip.
+func (c *Chaos) flip(l *Logger) error {
+	if !realFS(l.fs()) {
+		return needsRealFS("Chaos flipping")
+	}
+	name := l.filename()
+	if c.chmoded {
+		c.chmoded = false
+		return os.Chmod(name, c.flipped)
+	}
+	info, err := l.fs().Stat(name)
+	if err != nil {
+		return err
+	}
+	c.flipped = info.Mode().Perm()
+	c.chmoded = true
+	return os.Chmod(name, 0)
+}
@@ -0,0 +1,128 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosRotate(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Chaos:    &Chaos{RotateProbability: 1},
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 3; i++ {
+		newFakeTime(time.Second)
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+
+	// Every write rotates, including the first one which backs up the
+	// freshly created empty file.
+	fileCount(dir, 4, t)
+	existsWithLines(logFile(dir), 1, t)
+}
+
+func TestChaosDelete(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxLines: 1,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 3; i++ {
+		newFakeTime(time.Second)
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+	fileCount(dir, 3, t)
+
+	l.MaxLines = 10
+	l.Chaos = &Chaos{DeleteProbability: 1}
+	_, err := l.Write(b)
+	require.NoError(t, err)
+	fileCount(dir, 2, t)
+
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	fileCount(dir, 1, t)
+
+	// nothing left to delete
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	fileCount(dir, 1, t)
+}
+
+func TestChaosChmod(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Chaos:    &Chaos{ChmodProbability: 1},
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0), info.Mode().Perm())
+
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	info, err = os.Stat(filename)
+	require.NoError(t, err)
+	require.NotEqual(t, os.FileMode(0), info.Mode().Perm())
+	existsWithLines(filename, 2, t)
+}
+
+func TestChaosDeterministic(t *testing.T) {
+	run := func() []time.Duration {
+		var slept []time.Duration
+		sleep = func(d time.Duration) { slept = append(slept, d) }
+		defer func() { sleep = time.Sleep }()
+
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{
+			Filename: logFile(dir),
+			MaxLines: 1000,
+			Chaos: &Chaos{
+				Seed:             42,
+				DelayProbability: 0.5,
+				MaxDelay:         time.Second,
+			},
+		}
+		defer l.Close()
+
+		for i := 0; i < 100; i++ {
+			_, err := l.Write([]byte("boo!\n"))
+			require.NoError(t, err)
+		}
+		return slept
+	}
+
+	first := run()
+	require.NotEmpty(t, first)
+	require.True(t, len(first) < 100)
+	require.Equal(t, first, run())
+}
@@ -14,3 +14,9 @@ func chown(name string, info os.FileInfo) error {
 	stat := info.Sys().(*syscall.Stat_t)
 	return os_Chown(name, int(stat.Uid), int(stat.Gid))
 }
+
+// setOwner changes the owner and group of the named file. An ID of -1 is left
+// unchanged.
+func setOwner(name string, uid, gid int) error {
+	return os_Chown(name, uid, gid)
+}
@@ -7,3 +7,7 @@ import (
 func chown(_ string, _ os.FileInfo) error {
 	return nil
 }
+
+func setOwner(_ string, _, _ int) error {
+	return ErrOwnerUnsupported
+}
@@ -0,0 +1,76 @@
+package nanojack
+
+// NoBackups is the value of MaxBackups that keeps no backups at all. Each
+// backup is made as usual, so that rotation looks the same to a reader, and
+// then deleted by the cleanup that follows.
+const NoBackups = -1
+
+// CleanupResult reports the old log files deleted by a rotation.
+type CleanupResult struct {
+	// Removed lists the paths of the backups that were deleted.
+	Removed []string
+
+	// Errors holds the error for each backup that could not be deleted.
+	Errors []error
+}
+
+// RotateSync rotates the log file like Rotate, but deletes old log files
+// before returning rather than in the background, and reports which were
+// deleted. The error is that of the rotation itself; failures to delete
+// backups are reported in the result.
+func (l *Logger) RotateSync() (CleanupResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.acquire(); err != nil {
+		return CleanupResult{}, err
+	}
+	defer l.release()
+
+	var result CleanupResult
+	l.cleanupResult = &result
+	defer func() { l.cleanupResult = nil }()
+	err := l.rotate(ReasonManual)
+	return result, err
+}
+
+// remove deletes the old log file at path, recording the outcome if a
+// RotateSync is in progress.
+func (l *Logger) remove(path string) {
+	err := l.fs().Remove(path)
+	if err != nil {
+		l.debug("error", "can't remove old log file", "path", path, "error", err)
+		l.cleanupErr = err
+	}
+	if l.cleanupResult == nil {
+		return
+	}
+	if err != nil {
+		l.cleanupResult.Errors = append(l.cleanupResult.Errors, err)
+		return
+	}
+	l.cleanupResult.Removed = append(l.cleanupResult.Removed, path)
+}
+
+// approveDelete asks OnBeforeDelete, if it is set, whether cleanup may
+// delete the backup at path.
+func (l *Logger) approveDelete(path string) bool {
+	if l.OnBeforeDelete == nil || l.DryRun || l.OnBeforeDelete(path) {
+		return true
+	}
+	l.debug("debug", "keeping old log file", "path", path, "reason", "vetoed")
+	return false
+}
+
+// approved returns the files that OnBeforeDelete allows cleanup to delete.
+func (l *Logger) approved(files []logInfo) []logInfo {
+	if l.OnBeforeDelete == nil {
+		return files
+	}
+	var ok []logInfo
+	for _, f := range files {
+		if l.approveDelete(f.path) {
+			ok = append(ok, f)
+		}
+	}
+	return ok
+}
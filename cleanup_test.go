@@ -0,0 +1,219 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateSync(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	newFakeTime(time.Second)
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Empty(t, result.Removed)
+	require.Empty(t, result.Errors)
+	first := backupFile(dir)
+
+	newFakeTime(time.Second)
+	result, err = l.RotateSync()
+	require.NoError(t, err)
+	require.Equal(t, []string{first}, result.Removed)
+	require.Empty(t, result.Errors)
+
+	// the deletion has already happened
+	notExist(first, t)
+	fileCount(dir, 2, t)
+}
+
+func TestRotateSyncSequentialError(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxBackups: 2,
+		Sequential: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	// a non-empty directory in the place of the oldest backup can't be removed
+	oldest := sequentialName(filename, 2)
+	require.NoError(t, os.Mkdir(oldest, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(oldest, "x"), nil, 0644))
+
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Empty(t, result.Removed)
+	require.Len(t, result.Errors, 1)
+}
+
+func TestMaxBackupAge(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		MaxBackups:   3,
+		MaxBackupAge: time.Hour,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	newFakeTime(time.Second)
+	_, err = l.RotateSync()
+	require.NoError(t, err)
+	old := backupFile(dir)
+
+	newFakeTime(time.Minute)
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Empty(t, result.Removed)
+
+	// the first backup is now too old, although within MaxBackups
+	newFakeTime(time.Hour)
+	result, err = l.RotateSync()
+	require.NoError(t, err)
+	require.Equal(t, []string{old}, result.Removed)
+	fileCount(dir, 3, t)
+}
+
+func TestMaxBackupAgeSequential(t *testing.T) {
+	// the age of sequential backups comes from their real modification times
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		Sequential:   true,
+		MaxBackupAge: time.Hour,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+	require.NoError(t, l.Rotate())
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(sequentialName(filename, 2), old, old))
+
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Equal(t, []string{sequentialName(filename, 3)}, result.Removed)
+	fileCount(dir, 3, t)
+}
+
+func TestNoBackups(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: NoBackups,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	newFakeTime(time.Second)
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Equal(t, []string{backupFile(dir)}, result.Removed)
+
+	// only the new log file is left
+	notExist(backupFile(dir), t)
+	existsWithLines(logFile(dir), 0, t)
+	fileCount(dir, 1, t)
+}
+
+func TestNoBackupsSequential(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		Sequential: true,
+		MaxBackups: NoBackups,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Equal(t, []string{sequentialName(filename, 1)}, result.Removed)
+	fileCount(dir, 1, t)
+}
+
+func TestOnBeforeDelete(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var offered []string
+	veto := true
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+		OnBeforeDelete: func(path string) bool {
+			offered = append(offered, path)
+			return !veto
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	newFakeTime(time.Second)
+	_, err = l.RotateSync()
+	require.NoError(t, err)
+	first := backupFile(dir)
+
+	newFakeTime(time.Second)
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Empty(t, result.Removed)
+	require.Equal(t, []string{first}, offered)
+	exists(first, t)
+
+	// the vetoed backup is offered again at the next cleanup
+	veto = false
+	offered = nil
+	newFakeTime(time.Second)
+	result, err = l.RotateSync()
+	require.NoError(t, err)
+	require.Contains(t, offered, first)
+	require.Contains(t, result.Removed, first)
+	notExist(first, t)
+}
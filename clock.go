@@ -0,0 +1,150 @@
+package nanojack
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a source of the current time. Loggers that share a Clock agree
+// exactly on when things happen, which makes scenarios involving several
+// Loggers deterministic.
+type Clock interface {
+	Now() time.Time
+}
+
+// Timer is a call scheduled with a Clock, which may be cancelled.
+type Timer interface {
+	// Stop prevents the call from being made. It returns false if the call
+	// has already been made or stopped.
+	Stop() bool
+}
+
+// sleeper is implemented by Clocks that control how the Logger waits.
+type sleeper interface {
+	Sleep(d time.Duration)
+}
+
+// timerClock is implemented by Clocks that control when timers fire.
+type timerClock interface {
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// now returns the current time according to the Logger's Clock.
+func (l *Logger) now() time.Time {
+	return clockNow(l.Clock)
+}
+
+// sleep waits for d according to the Logger's Clock.
+func (l *Logger) sleep(d time.Duration) {
+	clockSleep(l.Clock, d)
+}
+
+// afterFunc arranges for f to be called once d has passed according to the
+// Logger's Clock.
+func (l *Logger) afterFunc(d time.Duration, f func()) Timer {
+	return clockAfterFunc(l.Clock, d, f)
+}
+
+// after returns a channel that receives the time once d has passed according
+// to the Logger's Clock, and the Timer that stops it.
+func (l *Logger) after(d time.Duration) (<-chan time.Time, Timer) {
+	return clockAfter(l.Clock, d)
+}
+
+// ticker returns a ticker that ticks every d according to the Logger's Clock.
+func (l *Logger) ticker(d time.Duration) *ticker {
+	return newTicker(l.Clock, d)
+}
+
+// clockNow returns the current time according to c, or the system clock if c
+// is nil.
+func clockNow(c Clock) time.Time {
+	if c != nil {
+		return c.Now()
+	}
+	return currentTime()
+}
+
+// clockSleep waits for d according to c, or the system clock if c is nil.
+func clockSleep(c Clock, d time.Duration) {
+	if s, ok := c.(sleeper); ok {
+		s.Sleep(d)
+		return
+	}
+	sleep(d)
+}
+
+// clockAfterFunc arranges for f to be called once d has passed according to
+// c, or the system clock if c does not control timers.
+func clockAfterFunc(c Clock, d time.Duration, f func()) Timer {
+	if tc, ok := c.(timerClock); ok {
+		return tc.AfterFunc(d, f)
+	}
+	return time.AfterFunc(d, f)
+}
+
+// clockAfter returns a channel that receives the time once d has passed
+// according to c, and the Timer that stops it.
+func clockAfter(c Clock, d time.Duration) (<-chan time.Time, Timer) {
+	tc, ok := c.(timerClock)
+	if !ok {
+		t := time.NewTimer(d)
+		return t.C, t
+	}
+	ch := make(chan time.Time, 1)
+	t := tc.AfterFunc(d, func() { ch <- c.Now() })
+	return ch, t
+}
+
+// timerNow returns the time at which a timer on c fires: c's own time if it
+// controls timers, and otherwise the system time, as for a time.Timer.
+func timerNow(c Clock) time.Time {
+	if _, ok := c.(timerClock); ok {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// ticker sends the time on C every period according to a Clock. Like a
+// time.Ticker, it drops ticks for a slow receiver.
+type ticker struct {
+	C <-chan time.Time
+
+	c      chan time.Time
+	clock  Clock
+	period time.Duration
+
+	mu      sync.Mutex
+	timer   Timer
+	stopped bool
+}
+
+// newTicker returns a ticker that ticks every d according to c.
+func newTicker(c Clock, d time.Duration) *ticker {
+	ch := make(chan time.Time, 1)
+	t := &ticker{C: ch, c: ch, clock: c, period: d}
+	t.mu.Lock()
+	t.timer = clockAfterFunc(c, d, t.tick)
+	t.mu.Unlock()
+	return t
+}
+
+func (t *ticker) tick() {
+	select {
+	case t.c <- timerNow(t.clock):
+	default:
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.stopped {
+		t.timer = clockAfterFunc(t.clock, t.period, t.tick)
+	}
+}
+
+// Stop turns off the ticker. Like time.Ticker.Stop, it does not close C.
+func (t *ticker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	t.timer.Stop()
+}
@@ -0,0 +1,136 @@
+package nanojack
+
+import (
+	"compress/gzip"
+	"os"
+	"sync"
+)
+
+// compressSuffix is appended to the name of a backup when it is compressed.
+const compressSuffix = ".gz"
+
+// defaultCompressQueue is the number of backups that may wait to be
+// compressed when CompressQueue is not set.
+const defaultCompressQueue = 16
+
+// compressor compresses backups on a pool of background goroutines.
+type compressor struct {
+	queue chan compressJob
+	wg    sync.WaitGroup
+}
+
+// compressJob is a backup waiting to be compressed, with the name of the log
+// file it was rotated from.
+type compressJob struct {
+	path     string
+	filename string
+}
+
+// compressing reports whether backups are to be compressed.
+func (l *Logger) compressing() bool {
+	return l.Compress && !l.Sequential && l.Namer == nil
+}
+
+// compress queues the backup at path to be compressed, starting the worker
+// pool if necessary. If the queue is full, it blocks until there is room.
+func (l *Logger) compress(path string) {
+	c := l.compressor
+	if c == nil {
+		workers, size := l.CompressWorkers, l.CompressQueue
+		if workers <= 0 {
+			workers = 1
+		}
+		if size <= 0 {
+			size = defaultCompressQueue
+		}
+		c = &compressor{queue: make(chan compressJob, size)}
+		c.wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go c.run(l)
+		}
+		l.compressor = c
+	}
+
+	tmp := path + compressSuffix + ".tmp"
+	l.expect(opCreate, tmp, path+compressSuffix)
+	l.expect(opRename, tmp)
+	l.expect(opRemove, path)
+	c.queue <- compressJob{path: path, filename: l.filename()}
+}
+
+func (c *compressor) run(l *Logger) {
+	defer c.wg.Done()
+	for job := range c.queue {
+		gz, err := compressFile(l.fs(), job.path, l.CopyBufferSize)
+		if err != nil {
+			l.debug("error", "can't compress backup", "backup", job.path, "error", err)
+			l.emit(Event{Type: EventCompress, Filename: job.filename, Path: job.path, Err: err})
+			continue
+		}
+		l.debug("debug", "compressed backup", "backup", gz)
+		l.emit(Event{Type: EventCompress, Filename: job.filename, Path: gz})
+		l.archive(gz, job.filename)
+	}
+}
+
+// compressFile gzips the file at path into a file named with compressSuffix,
+// keeping its permissions, ownership and modification time, and removes the
+// original. The compressed file is written under a temporary name first, so
+// that it is never mistaken for a complete backup. It returns the name of
+// the compressed file.
+func compressFile(fsys FileSystem, path string, size int) (string, error) {
+	src, err := fsys.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	gz := path + compressSuffix
+	tmp := gz + ".tmp"
+	dst, err := fsys.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return "", err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = copyBuffer(zw, src, size)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && realFS(fsys) {
+		// this is a no-op on windows
+		err = chown(tmp, info)
+	}
+	if err == nil {
+		err = fsys.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = fsys.Rename(tmp, gz)
+	}
+	if err != nil {
+		fsys.Remove(tmp)
+		return "", err
+	}
+	src.Close()
+	return gz, fsys.Remove(path)
+}
+
+// stopCompress waits for queued backups to be compressed and stops the
+// worker pool, if there is one.
+func (l *Logger) stopCompress() {
+	l.mu.Lock()
+	c := l.compressor
+	l.compressor = nil
+	l.mu.Unlock()
+	if c == nil {
+		return
+	}
+	close(c.queue)
+	c.wg.Wait()
+}
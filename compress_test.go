@@ -0,0 +1,121 @@
+package nanojack
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	// the clock is read by the workers as the test advances it
+	clock := NewFakeClock(fakeTime())
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:        logFile(dir),
+		Compress:        true,
+		CompressWorkers: 2,
+		Events:          events,
+		Clock:           clock,
+	}
+	defer l.Close()
+
+	var backups []string
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+		clock.Advance(time.Second)
+		require.NoError(t, l.Rotate())
+		backups = append(backups, timestampedName(logFile(dir), clock.Now()))
+	}
+	// Close waits for the compressions to finish
+	require.NoError(t, l.Close())
+
+	compressed := 0
+	for len(events) > 0 {
+		e := <-events
+		if e.Type == EventCompress {
+			require.NoError(t, e.Err)
+			compressed++
+		}
+	}
+	require.Equal(t, 3, compressed)
+
+	for _, b := range backups {
+		notExist(b, t)
+		f, err := os.Open(b + compressSuffix)
+		require.NoError(t, err)
+		zr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(zr)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		require.Equal(t, "boo!\n", string(content))
+	}
+	fileCount(dir, 4, t)
+}
+
+func TestCompressCleanup(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		Compress:   true,
+		MaxBackups: 1,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	first := backupFile(dir)
+	l.stopCompress()
+	exists(first+compressSuffix, t)
+
+	// a compressed backup counts towards MaxBackups
+	newFakeTime(time.Second)
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Equal(t, []string{first + compressSuffix}, result.Removed)
+}
+
+func TestCompressEventFilename(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+		Events:   events,
+		Clock:    NewFakeClock(fakeTime()),
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+	// the compression runs while the Logger switches to another file, and
+	// still names the file the backup was rotated from
+	require.NoError(t, l.SetFilename(filepath.Join(dir, "other.log"), false))
+	l.stopCompress()
+
+	for len(events) > 0 {
+		if e := <-events; e.Type == EventCompress {
+			require.NoError(t, e.Err)
+			require.Equal(t, logFile(dir), e.Filename)
+			return
+		}
+	}
+	t.Fatal("no EventCompress")
+}
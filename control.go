@@ -0,0 +1,131 @@
+package nanojack
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// controlServer serves a Logger's control socket.
+type controlServer struct {
+	ln net.Listener
+}
+
+// ListenControl serves a Unix domain socket at path through which local
+// tooling can drive the Logger. Each connection sends commands, one per
+// line, and receives one line in reply to each: "ok", the result of the
+// command, or "error: " followed by the error. The commands are:
+//
+//	rotate          rotate the log file
+//	reopen          reopen the log file, as Reopen does
+//	stats           reply with the Logger's Stats as JSON
+//	pause           pause the Logger, as Pause does
+//	resume          resume the Logger, as Resume does
+//	set-rate N      set the RateLimit to N per second, 0 to disable it
+//
+// set-rate requires RateLimit to be set, if only to a zero RateLimit. A
+// stale socket at path is replaced. Calling ListenControl again replaces the
+// previous socket, and Close removes it.
+func (l *Logger) ListenControl(path string) error {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	s := &controlServer{ln: ln}
+
+	l.mu.Lock()
+	l.stopControl()
+	l.control = s
+	l.mu.Unlock()
+
+	go l.serveControl(s)
+	return nil
+}
+
+func (l *Logger) serveControl(s *controlServer) {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go l.handleControl(conn)
+	}
+}
+
+// handleControl answers the commands sent on conn until it is closed.
+func (l *Logger) handleControl(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		reply, err := l.controlCommand(strings.Fields(scanner.Text()))
+		if err != nil {
+			reply = "error: " + err.Error()
+		}
+		if _, err := fmt.Fprintln(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// controlCommand carries out the command given by args, and returns the
+// reply to send.
+func (l *Logger) controlCommand(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("no command")
+	}
+	switch cmd := args[0]; {
+	case cmd == "rotate" && len(args) == 1:
+		if err := l.requestRotate(ReasonExternal); err != nil {
+			return "", err
+		}
+	case cmd == "reopen" && len(args) == 1:
+		if err := l.Reopen(); err != nil {
+			return "", err
+		}
+	case cmd == "stats" && len(args) == 1:
+		stats, err := l.Stats()
+		if err != nil {
+			return "", err
+		}
+		b, err := json.Marshal(stats)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case cmd == "pause" && len(args) == 1:
+		l.Pause()
+	case cmd == "resume" && len(args) == 1:
+		l.Resume()
+	case cmd == "set-rate" && len(args) == 2:
+		rate, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || rate < 0 {
+			return "", fmt.Errorf("invalid rate %q", args[1])
+		}
+		if l.RateLimit == nil {
+			return "", errors.New("no RateLimit is set")
+		}
+		l.RateLimit.SetLimit(rate)
+	default:
+		return "", fmt.Errorf("invalid command %q", strings.Join(args, " "))
+	}
+	return "ok", nil
+}
+
+// stopControl closes the control socket, if any.
+func (l *Logger) stopControl() {
+	if l.control == nil {
+		return
+	}
+	l.control.ln.Close()
+	l.control = nil
+}
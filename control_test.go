@@ -0,0 +1,67 @@
+// +build !windows
+
+package nanojack
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenControl(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		RateLimit:      &RateLimit{},
+		FailWhenPaused: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	sock := filepath.Join(dir, "control.sock")
+	require.NoError(t, l.ListenControl(sock))
+	conn, err := net.Dial("unix", sock)
+	require.NoError(t, err)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	send := func(cmd string) string {
+		_, err := fmt.Fprintln(conn, cmd)
+		require.NoError(t, err)
+		reply, err := r.ReadString('\n')
+		require.NoError(t, err)
+		return reply
+	}
+
+	newFakeTime(time.Second)
+	require.Equal(t, "ok\n", send("rotate"))
+	existsWithLines(backupFile(dir), 1, t)
+	require.Contains(t, send("stats"), `"rotations":1`)
+
+	require.Equal(t, "ok\n", send("pause"))
+	_, err = l.Write([]byte("boo!\n"))
+	require.Equal(t, ErrPaused, err)
+	require.Equal(t, "ok\n", send("resume"))
+	_, err = l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	require.Equal(t, "ok\n", send("reopen"))
+
+	require.Equal(t, "ok\n", send("set-rate 100"))
+	require.Equal(t, float64(100), l.RateLimit.Limit)
+	require.Equal(t, "error: invalid rate \"fast\"\n", send("set-rate fast"))
+	require.Equal(t, "error: invalid command \"jump\"\n", send("jump"))
+
+	require.NoError(t, l.Close())
+	notExist(sock, t)
+}
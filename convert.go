@@ -0,0 +1,87 @@
+package nanojack
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConvertBackups renames the existing backups from the Logger's current
+// naming scheme to the sequential scheme if sequential is set, or to the
+// timestamped one if not, and switches the Logger to that scheme, as a
+// logrotate configuration change would. Timestamped names are made from the
+// modification times of sequential backups, and sequential backups are given
+// the times from their timestamped names as their modification times, so
+// that MaxBackupAge treats them alike either way. The order of the backups
+// is kept. Backups compressed by Compress are left as they are. If any of
+// the new names is already taken, nothing is renamed.
+func (l *Logger) ConvertBackups(sequential bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if sequential == l.Sequential {
+		return nil
+	}
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+	var from []logInfo
+	for _, f := range files {
+		if !strings.HasSuffix(f.path, compressSuffix) {
+			from = append(from, f)
+		}
+	}
+
+	to := make([]string, len(from))
+	taken := make(map[string]bool, len(from))
+	for i, f := range from {
+		name := l.convertedName(f, i, sequential)
+		for !sequential && taken[name] {
+			// two backups modified at the same instant
+			f.timestamp = f.timestamp.Add(-time.Nanosecond)
+			name = l.convertedName(f, i, sequential)
+		}
+		if fileExists(l.fs(), name) {
+			return fmt.Errorf("can't convert backup %s: %s already exists", f.path, name)
+		}
+		to[i] = name
+		taken[name] = true
+	}
+
+	for i, f := range from {
+		if !sequential {
+			if err := l.fs().MkdirAll(filepath.Dir(to[i]), 0744); err != nil {
+				return fmt.Errorf("can't make directories for backup: %s", err)
+			}
+		}
+		l.expect(opRename, f.path)
+		l.expect(opCreate, to[i])
+		if err := l.fs().Rename(f.path, to[i]); err != nil {
+			return err
+		}
+		if sequential {
+			if err := l.fs().Chtimes(to[i], f.timestamp, f.timestamp); err != nil {
+				return err
+			}
+		}
+		l.movePin(f.path, to[i])
+		l.moveBackup(f.path, to[i])
+		l.debug("debug", "converted backup", "path", f.path, "backup", to[i])
+	}
+	l.Sequential = sequential
+	return nil
+}
+
+// convertedName returns the name that the backup f, the ith newest, takes in
+// the sequential scheme if sequential is set, or in the timestamped one.
+func (l *Logger) convertedName(f logInfo, i int, sequential bool) string {
+	if sequential {
+		return l.sequentialBackup(i + 1)
+	}
+	if l.Namer != nil {
+		return l.Namer.BackupName(l.filename(), f.timestamp)
+	}
+	return timestampedName(l.filename(), f.timestamp)
+}
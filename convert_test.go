@@ -0,0 +1,74 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertBackups(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		Sequential: true,
+	}
+	defer l.Close()
+
+	for _, line := range []string{"one\n", "two\n"} {
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+		require.NoError(t, l.Rotate())
+	}
+	older := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	require.NoError(t, os.Chtimes(sequentialName(filename, 2), older, older))
+	require.NoError(t, os.Chtimes(sequentialName(filename, 1), newer, newer))
+
+	require.NoError(t, l.ConvertBackups(false))
+	require.False(t, l.Sequential)
+	b, err := ioutil.ReadFile(timestampedName(filename, older))
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(b))
+	b, err = ioutil.ReadFile(timestampedName(filename, newer))
+	require.NoError(t, err)
+	require.Equal(t, "two\n", string(b))
+	fileCount(dir, 3, t)
+
+	// and back again, with the times from the names as modification times
+	require.NoError(t, l.ConvertBackups(true))
+	require.True(t, l.Sequential)
+	b, err = ioutil.ReadFile(sequentialName(filename, 2))
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(b))
+	info, err := os.Stat(sequentialName(filename, 1))
+	require.NoError(t, err)
+	require.True(t, info.ModTime().Equal(newer))
+	fileCount(dir, 3, t)
+}
+
+func TestConvertBackupsTaken(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+	backup := backupFile(dir)
+	require.NoError(t, ioutil.WriteFile(sequentialName(filename, 1), nil, 0644))
+
+	require.Error(t, l.ConvertBackups(true))
+	require.False(t, l.Sequential)
+	exists(backup, t)
+}
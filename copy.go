@@ -0,0 +1,27 @@
+package nanojack
+
+import (
+	"io"
+	"os"
+)
+
+// copyBuffer copies the rest of src to dst through user space, using a
+// buffer of size bytes, or the io.Copy default if size is not positive.
+func copyBuffer(dst io.Writer, src io.Reader, size int) (int64, error) {
+	if size <= 0 {
+		return io.Copy(dst, src)
+	}
+	// hide any ReaderFrom or WriterTo, which would ignore the buffer
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, make([]byte, size))
+}
+
+// copyFiles copies the rest of src to dst as copyData does, through a buffer
+// unless both are on the real file system.
+func copyFiles(dst, src File, size int) (int64, error) {
+	d, dok := dst.(*os.File)
+	s, sok := src.(*os.File)
+	if dok && sok {
+		return copyData(d, s, size)
+	}
+	return copyBuffer(dst, src, size)
+}
@@ -0,0 +1,42 @@
+package nanojack
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyData copies the rest of src to dst, in chunks of size bytes if size is
+// positive. It uses copy_file_range so that the data does not pass through
+// user space, and falls back to copying through a buffer where the kernel or
+// file system does not support it.
+func copyData(dst, src *os.File, size int) (int64, error) {
+	chunk := size
+	if chunk <= 0 {
+		chunk = 1 << 30
+	}
+	var written int64
+	for {
+		n, err := unix.CopyFileRange(int(src.Fd()), nil, int(dst.Fd()), nil, chunk, 0)
+		if err != nil {
+			if written == 0 && fallbackCopy(err) {
+				return copyBuffer(dst, src, size)
+			}
+			return written, err
+		}
+		if n == 0 {
+			return written, nil
+		}
+		written += int64(n)
+	}
+}
+
+// fallbackCopy reports whether err from copy_file_range means that it can't
+// be used for the files, rather than that copying failed.
+func fallbackCopy(err error) bool {
+	switch err {
+	case unix.ENOSYS, unix.EXDEV, unix.EINVAL, unix.EOPNOTSUPP, unix.EPERM:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+// +build !linux
+
+package nanojack
+
+import (
+	"os"
+)
+
+// copyData copies the rest of src to dst, in chunks of size bytes if size is
+// positive.
+func copyData(dst, src *os.File, size int) (int64, error) {
+	return copyBuffer(dst, src, size)
+}
@@ -0,0 +1,75 @@
+package nanojack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyData(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	content := bytes.Repeat([]byte("boo!\n"), 100000)
+	from := filepath.Join(dir, "from")
+	require.NoError(t, ioutil.WriteFile(from, content, 0644))
+
+	src, err := os.Open(from)
+	require.NoError(t, err)
+	defer src.Close()
+	// only the rest of the source is copied
+	_, err = src.Seek(5, 0)
+	require.NoError(t, err)
+
+	to := filepath.Join(dir, "to")
+	dst, err := os.Create(to)
+	require.NoError(t, err)
+	n, err := copyData(dst, src, 0)
+	require.NoError(t, err)
+	require.NoError(t, dst.Close())
+	require.Equal(t, int64(len(content)-5), n)
+
+	copied, err := ioutil.ReadFile(to)
+	require.NoError(t, err)
+	require.Equal(t, content[5:], copied)
+}
+
+func TestCopyDataBufferSize(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	content := bytes.Repeat([]byte("boo!\n"), 1000)
+	from := filepath.Join(dir, "from")
+	require.NoError(t, ioutil.WriteFile(from, content, 0644))
+
+	for _, size := range []int{1, 7, 4096} {
+		src, err := os.Open(from)
+		require.NoError(t, err)
+		to := filepath.Join(dir, "to")
+		dst, err := os.Create(to)
+		require.NoError(t, err)
+
+		n, err := copyData(dst, src, size)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(content)), n)
+		require.NoError(t, dst.Close())
+		require.NoError(t, src.Close())
+
+		copied, err := ioutil.ReadFile(to)
+		require.NoError(t, err)
+		require.Equal(t, content, copied)
+
+		// the same through user space
+		var buf bytes.Buffer
+		src, err = os.Open(from)
+		require.NoError(t, err)
+		_, err = copyBuffer(&buf, src, size)
+		require.NoError(t, err)
+		require.NoError(t, src.Close())
+		require.Equal(t, content, buf.Bytes())
+	}
+}
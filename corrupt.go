@@ -0,0 +1,75 @@
+package nanojack
+
+import (
+	"math/rand"
+)
+
+// invalidSequences are byte sequences that are not valid UTF-8: a lone
+// continuation byte, bytes that never appear in UTF-8, a truncated sequence,
+// an overlong encoding, and an encoded surrogate.
+var invalidSequences = [][]byte{
+	{0x80},
+	{0xff},
+	{0xfe},
+	{0xc3, 0x28},
+	{0xe2, 0x82},
+	{0xc0, 0xaf},
+	{0xed, 0xa0, 0x80},
+	{0xf8, 0x88, 0x80, 0x80, 0x80},
+}
+
+// controlBytes are the control characters that Corrupter inserts. Newlines
+// are never inserted, so that corrupted lines are still counted as one line.
+var controlBytes = []byte{0x00, 0x07, 0x08, '\t', 0x0b, 0x0c, '\r', 0x1b, 0x7f}
+
+// Corrupter is a Transformer that inserts invalid UTF-8 sequences and
+// control bytes into a fraction of lines, to verify that readers and
+// backends sanitize bad input rather than crash. Every decision is drawn
+// from a random source seeded with Seed, so a given seed always corrupts the
+// same lines in the same way.
+type Corrupter struct {
+	// Fraction is the chance, in the range [0, 1], that a line is corrupted.
+	Fraction float64 `json:"fraction" yaml:"fraction"`
+
+	// MaxInsertions is the maximum number of invalid sequences and control
+	// bytes inserted into a corrupted line. The number is chosen at random
+	// between 1 and MaxInsertions. It defaults to 3.
+	MaxInsertions int `json:"maxinsertions" yaml:"maxinsertions"`
+
+	// Seed seeds the random source that drives all corruption decisions.
+	Seed int64 `json:"seed" yaml:"seed"`
+
+	rng *rand.Rand
+}
+
+// Transform implements Transformer.
+func (c *Corrupter) Transform(p []byte) []byte {
+	if c.rng == nil {
+		c.rng = rand.New(rand.NewSource(c.Seed))
+	}
+	if c.rng.Float64() >= c.Fraction {
+		return p
+	}
+
+	max := c.MaxInsertions
+	if max <= 0 {
+		max = 3
+	}
+	body, end := p, []byte(nil)
+	if len(p) > 0 && p[len(p)-1] == '\n' {
+		body, end = p[:len(p)-1], p[len(p)-1:]
+	}
+
+	out := append([]byte(nil), body...)
+	for i, n := 0, 1+c.rng.Intn(max); i < n; i++ {
+		var insert []byte
+		if c.rng.Intn(2) == 0 {
+			insert = invalidSequences[c.rng.Intn(len(invalidSequences))]
+		} else {
+			insert = []byte{controlBytes[c.rng.Intn(len(controlBytes))]}
+		}
+		at := c.rng.Intn(len(out) + 1)
+		out = append(out[:at], append(insert[:len(insert):len(insert)], out[at:]...)...)
+	}
+	return append(out, end...)
+}
@@ -0,0 +1,60 @@
+package nanojack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrupter(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxLines:     1000,
+		Transformers: []Transformer{&Corrupter{Fraction: 0.5, Seed: 9}},
+	}
+	defer l.Close()
+
+	line := []byte("a perfectly ordinary line\n")
+	for i := 0; i < 200; i++ {
+		n, err := l.Write(line)
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+	}
+	existsWithLines(filename, 200, t)
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	corrupted := 0
+	for _, got := range bytes.Split(bytes.TrimSuffix(b, []byte("\n")), []byte("\n")) {
+		if !bytes.Equal(got, line[:len(line)-1]) {
+			corrupted++
+		}
+	}
+	require.True(t, corrupted > 50 && corrupted < 150, corrupted)
+	require.False(t, utf8.Valid(b))
+}
+
+func TestCorrupterDeterministic(t *testing.T) {
+	corrupt := func(seed int64) [][]byte {
+		c := &Corrupter{Fraction: 1, MaxInsertions: 5, Seed: seed}
+		var out [][]byte
+		for i := 0; i < 10; i++ {
+			out = append(out, c.Transform([]byte("line\n")))
+		}
+		return out
+	}
+	require.Equal(t, corrupt(1), corrupt(1))
+	require.NotEqual(t, corrupt(1), corrupt(2))
+	for _, line := range corrupt(1) {
+		require.Equal(t, 1, bytes.Count(line, []byte("\n")))
+		require.True(t, bytes.HasSuffix(line, []byte("\n")))
+	}
+}
@@ -0,0 +1,43 @@
+package nanojack
+
+import (
+	"errors"
+)
+
+// ErrKilled is returned by Write after Kill has been called, until the Logger
+// is recovered.
+var ErrKilled = errors.New("nanojack: logger has been killed")
+
+// Kill simulates a crash of the process writing the log. The open file handle
+// is abandoned without being flushed, synced, or closed, the contents of the
+// write buffer are discarded, and any subsequent Write fails with ErrKilled
+// until Recover is called. Kill is intended for testing the crash-recovery
+// paths of log readers.
+func (l *Logger) Kill() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Deliberately leak the handle. The runtime will eventually close it when
+	// it is garbage collected, just as the kernel would for a dead process.
+	if l.buf != nil {
+		l.buf.Reset(fileWriter{l})
+	}
+	l.gz = nil
+	l.held = false
+	l.setFile(nil, 0, 0)
+	l.killed = true
+}
+
+// Recover reopens the log file after a call to Kill, as a restarted process
+// would. The line count of the existing file is recomputed from disk, and the
+// file is rotated if it is already full.
+func (l *Logger) Recover() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.killed {
+		return nil
+	}
+	l.killed = false
+	return l.openExistingOrNew()
+}
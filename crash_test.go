@@ -0,0 +1,45 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKillRecover(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+
+	b := []byte("a\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+	_, err = l.Write(b)
+	require.NoError(t, err)
+
+	l.Kill()
+
+	n, err := l.Write(b)
+	require.Equal(t, ErrKilled, err)
+	require.Equal(t, 0, n)
+	existsWithLines(filename, 2, t)
+
+	require.NoError(t, l.Recover())
+	require.Equal(t, int64(2), l.lines)
+
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(filename, 3, t)
+	fileCount(dir, 1, t)
+
+	// recovering a logger that was not killed does nothing
+	require.NoError(t, l.Recover())
+	existsWithLines(filename, 3, t)
+}
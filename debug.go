@@ -0,0 +1,9 @@
+package nanojack
+
+// debug reports an internal decision to the Debug function, if there is one.
+func (l *Logger) debug(level, msg string, keyvals ...interface{}) {
+	if l.Debug == nil {
+		return
+	}
+	l.Debug(level, msg, keyvals...)
+}
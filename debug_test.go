@@ -0,0 +1,56 @@
+package nanojack
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebug(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var msgs []string
+	var reasons []interface{}
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxLines:   1,
+		MaxBackups: 2,
+		Debug: func(level, msg string, keyvals ...interface{}) {
+			if level != "debug" {
+				// old log files are removed in the background, and may
+				// fail once the test has cleaned up
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			msgs = append(msgs, msg)
+			if msg == "rotating" {
+				reasons = append(reasons, keyvals[1])
+			}
+		},
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		newFakeTime(time.Second)
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []interface{}{"max lines", "max lines", "requested"}, reasons)
+	require.Equal(t, []string{
+		"rotating", "rotated",
+		"rotating", "rotated",
+		"rotating", "rotated", "removing old log file",
+	}, msgs)
+}
@@ -0,0 +1,55 @@
+package nanojack
+
+import (
+	"fmt"
+	"os"
+)
+
+// isDevice reports whether path is a character device or a pipe, such as
+// /dev/stdout, rather than a regular file that can be rotated. There are no
+// devices on a FileSystem other than the real one.
+func (l *Logger) isDevice(path string) bool {
+	if !realFS(l.fs()) {
+		return false
+	}
+	switch path {
+	case "/dev/stdout", "/dev/stderr":
+		return true
+	}
+	info, err := l.fs().Stat(path)
+	return err == nil && info.Mode()&(os.ModeCharDevice|os.ModeNamedPipe) != 0
+}
+
+// openDevice opens the device at the Logger's filename as the active log
+// file. Lines are counted as for a regular file, but rotations only reset
+// the count, since a device can't be renamed or truncated.
+func (l *Logger) openDevice() error {
+	f, err := l.fs().OpenFile(l.filename(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("can't open log device: %s", err)
+	}
+	l.setFile(f, 0, 0)
+	l.device = true
+	return nil
+}
+
+// rotateDevice stands in for a rotation when the log file is a device. The
+// device is closed and reopened, so that buffered lines are written out and
+// a Gzip stream is finished, but no backup is made and nothing is cleaned up.
+func (l *Logger) rotateDevice(reason RotationReason) error {
+	if err := l.close(); err != nil {
+		l.rotateErr = err
+		return err
+	}
+	l.rotations++
+	if err := l.openDevice(); err != nil {
+		l.rotateErr = err
+		l.emit(Event{Type: EventRotate, Filename: l.filename(), Err: err, Reason: string(reason)})
+		return err
+	}
+	l.rotateErr = nil
+	l.debug("debug", "rotated", "backup", "", "reason", "device")
+	l.emit(Event{Type: EventRotate, Filename: l.filename(), Reason: string(reason)})
+	l.observeRotate("")
+	return l.repeatLast()
+}
@@ -0,0 +1,41 @@
+// +build !windows
+
+package nanojack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevice(t *testing.T) {
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:   "/dev/null",
+		MaxLines:   2,
+		MaxBackups: 1,
+		Events:     events,
+	}
+	defer l.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	require.Equal(t, int64(1), l.lines)
+
+	for i := 0; i < 2; i++ {
+		e := nextEvent(t, events)
+		require.Equal(t, EventRotate, e.Type)
+		require.Empty(t, e.Path)
+		require.NoError(t, e.Err)
+	}
+
+	require.NoError(t, l.Rotate())
+	s, err := l.Stats()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), s.Rotations)
+	require.Equal(t, int64(0), s.Lines)
+	require.Empty(t, s.Backups)
+	require.True(t, l.Health().OK())
+}
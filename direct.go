@@ -0,0 +1,63 @@
+package nanojack
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// directBlockSize is the alignment used for direct I/O. It is a multiple of
+// the logical block size of every common storage device.
+const directBlockSize = 4096
+
+// ErrDirectIOUnsupported is returned when DirectIO is set on a platform that
+// does not support it.
+var ErrDirectIOUnsupported = errors.New("nanojack: direct I/O is not supported on this platform")
+
+// loadTail reads the last partial block of the active file into memory, so
+// that direct writes can rewrite it.
+func (l *Logger) loadTail() error {
+	n := l.size % directBlockSize
+	l.tail = l.tail[:0]
+	if n == 0 {
+		return nil
+	}
+
+	f, err := l.fs().OpenFile(l.filename(), os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tail := make([]byte, n)
+	if _, err := f.ReadAt(tail, l.size-n); err != nil && err != io.EOF {
+		return err
+	}
+	l.tail = append(l.tail, tail...)
+	return nil
+}
+
+// writeDirect appends p to the active file using aligned block writes. The
+// last partial block of the file is written padded to a whole block, and the
+// file is then truncated back to its true size.
+func (l *Logger) writeDirect(p []byte) (int, error) {
+	start := l.size - int64(len(l.tail))
+	length := len(l.tail) + len(p)
+	blocks := (length + directBlockSize - 1) / directBlockSize
+
+	buf := alignedBuffer(blocks * directBlockSize)
+	copy(buf, l.tail)
+	copy(buf[len(l.tail):], p)
+
+	if _, err := l.file.WriteAt(buf, start); err != nil {
+		return 0, err
+	}
+	if length%directBlockSize != 0 {
+		if err := l.file.Truncate(start + int64(length)); err != nil {
+			return 0, err
+		}
+	}
+
+	rem := length % directBlockSize
+	l.tail = append(l.tail[:0], buf[length-rem:length]...)
+	return len(p), nil
+}
@@ -0,0 +1,20 @@
+package nanojack
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// directFlag is the open flag that enables direct I/O.
+const directFlag = syscall.O_DIRECT
+
+// alignedBuffer returns a buffer of n bytes whose start is aligned for direct
+// I/O.
+func alignedBuffer(n int) []byte {
+	buf := make([]byte, n+directBlockSize)
+	off := int(uintptr(unsafe.Pointer(&buf[0])) & (directBlockSize - 1))
+	if off != 0 {
+		off = directBlockSize - off
+	}
+	return buf[off : off+n]
+}
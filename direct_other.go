@@ -0,0 +1,12 @@
+// +build !linux
+
+package nanojack
+
+// directFlag is zero where direct I/O is not supported.
+const directFlag = 0
+
+// alignedBuffer is only used for direct I/O, which is not supported on this
+// platform.
+func alignedBuffer(n int) []byte {
+	return make([]byte, n)
+}
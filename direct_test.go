@@ -0,0 +1,77 @@
+// +build linux
+
+package nanojack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectIO(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	// an existing file whose last block is partial
+	existing := strings.Repeat("x", 5000) + "\n"
+	require.NoError(t, ioutil.WriteFile(filename, []byte(existing), 0644))
+
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 100000,
+		DirectIO: true,
+	}
+	defer l.Close()
+
+	expected := existing
+	for i := 0; i < 200; i++ {
+		line := fmt.Sprintf("%d %s\n", i, strings.Repeat("y", i*7))
+		n, err := l.Write([]byte(line))
+		if i == 0 && err != nil && strings.Contains(err.Error(), "invalid argument") {
+			t.Skip("file system does not support direct I/O")
+		}
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+		expected += line
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(content))
+
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	_, err = l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	content, err = ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "boo!\n", string(content))
+	existsWithLines(backupFile(dir), 201, t)
+}
+
+func TestSyncWrites(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	require.NoError(t, ioutil.WriteFile(filename, []byte("old\n"), 0644))
+	l := &Logger{
+		Filename:   filename,
+		SyncWrites: true,
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	existsWithLines(filename, 4, t)
+}
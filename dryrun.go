@@ -0,0 +1,50 @@
+package nanojack
+
+// dryRotate reports the rotation that would happen without carrying it out,
+// and resets the line count as if it had.
+func (l *Logger) dryRotate(reason RotationReason) error {
+	l.rotations++
+
+	var name string
+	switch {
+	case l.rotateTo != "":
+		name = l.rotateTo
+	case l.mechanism() == MechanismTruncate:
+	case l.Sequential:
+		name = l.sequentialBackup(1)
+		if l.MaxBackups > 0 {
+			maxBackupName := l.sequentialBackup(l.MaxBackups)
+			if fileExists(l.fs(), maxBackupName) && !l.isPinned(maxBackupName) {
+				l.emit(Event{Type: EventRemove, Filename: l.filename(), Path: maxBackupName, DryRun: true})
+			}
+		}
+	default:
+		name = l.timestampedBackupName()
+	}
+	l.emit(Event{Type: EventRotate, Filename: l.filename(), Path: name, DryRun: true, Reason: string(reason)})
+	l.lines = 0
+
+	if l.Sequential {
+		return l.cleanupSequential()
+	}
+	return l.cleanup()
+}
+
+// Expired returns the paths of the backups that cleanup would delete if it
+// ran now under the current retention settings, without deleting them.
+// Pinned backups are left out. OnBeforeDelete is not consulted, so a backup
+// it would keep is still listed.
+func (l *Logger) Expired() ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := l.expired()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	return paths, nil
+}
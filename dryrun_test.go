@@ -0,0 +1,159 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	// an existing backup that the retention settings would remove
+	old := backupFile(dir)
+	require.NoError(t, ioutil.WriteFile(old, []byte("old\n"), 0644))
+	newFakeTime(time.Second)
+
+	filename := logFile(dir)
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   2,
+		MaxBackups: 1,
+		DryRun:     true,
+		Events:     events,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 3; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+
+	// nothing was rotated or removed
+	existsWithLines(filename, 3, t)
+	exists(old, t)
+	fileCount(dir, 2, t)
+
+	e := <-events
+	require.Equal(t, EventRotate, e.Type)
+	require.Equal(t, backupFile(dir), e.Path)
+	require.True(t, e.DryRun)
+
+	// the hypothetical backup is not on disk, so only the old one is a
+	// candidate for removal, and it is within the limit.
+	require.Empty(t, events)
+
+	// the line count was reset by the dry rotation
+	require.Equal(t, int64(1), l.lines)
+}
+
+func TestDryRunSequential(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	require.NoError(t, ioutil.WriteFile(filename+".1", []byte("1\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filename+".2", []byte("2\n"), 0644))
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   1,
+		MaxBackups: 2,
+		Sequential: true,
+		DryRun:     true,
+		Events:     events,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 2; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+	existsWithLines(filename, 2, t)
+	fileCount(dir, 3, t)
+
+	e := <-events
+	require.Equal(t, EventRemove, e.Type)
+	require.Equal(t, filename+".2", e.Path)
+	require.True(t, e.DryRun)
+
+	e = <-events
+	require.Equal(t, EventRotate, e.Type)
+	require.Equal(t, filename+".1", e.Path)
+	require.True(t, e.DryRun)
+}
+
+func TestRotateEvents(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	old := backupFile(dir)
+	require.NoError(t, ioutil.WriteFile(old, []byte("old\n"), 0644))
+	newFakeTime(time.Second)
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+		Events:     events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+
+	e := <-events
+	require.Equal(t, EventRotate, e.Type)
+	require.Equal(t, backupFile(dir), e.Path)
+	require.False(t, e.DryRun)
+
+	e = <-events
+	require.Equal(t, EventRemove, e.Type)
+	require.Equal(t, old, e.Path)
+	require.False(t, e.DryRun)
+}
+
+func TestExpired(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var backups []string
+	for i := 0; i < 3; i++ {
+		newFakeTime(time.Minute)
+		name := backupFile(dir)
+		require.NoError(t, ioutil.WriteFile(name, []byte("old\n"), 0644))
+		backups = append(backups, name)
+	}
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 2,
+	}
+	defer l.Close()
+
+	expired, err := l.Expired()
+	require.NoError(t, err)
+	require.Equal(t, []string{backups[0]}, expired)
+
+	// the age limit applies to the backups within MaxBackups
+	l.MaxBackupAge = 30 * time.Second
+	expired, err = l.Expired()
+	require.NoError(t, err)
+	require.ElementsMatch(t, backups[:2], expired)
+
+	// nothing was deleted
+	fileCount(dir, 3, t)
+}
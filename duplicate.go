@@ -0,0 +1,89 @@
+package nanojack
+
+import (
+	"math/rand"
+)
+
+// Duplicates re-writes exact copies of lines that were already written, for
+// testing deduplication and the verification of at-least-once delivery.
+// Duplicates are written as lines of their own, and count towards MaxLines
+// like any other line. Every decision is drawn from a random source seeded
+// with Seed, so a given seed always duplicates the same lines.
+type Duplicates struct {
+	// Rate is the chance, in the range [0, 1], that a line is followed by a
+	// duplicate of one of the lines before it.
+	Rate float64 `json:"rate" yaml:"rate"`
+
+	// Window is the number of recent lines a duplicate is chosen from. It
+	// defaults to 1, which always duplicates the line just written.
+	Window int `json:"window" yaml:"window"`
+
+	// AfterRotation repeats the last line of each rotated file as the first
+	// line of the new file, as a producer that retries a write across a
+	// rotation would.
+	AfterRotation bool `json:"afterrotation" yaml:"afterrotation"`
+
+	// Seed seeds the random source that drives all duplication decisions.
+	Seed int64 `json:"seed" yaml:"seed"`
+
+	rng    *rand.Rand
+	recent [][]byte
+}
+
+// remember records a copy of line as the most recent line written.
+func (d *Duplicates) remember(line []byte) {
+	window := d.Window
+	if window <= 0 {
+		window = 1
+	}
+	var buf []byte
+	if len(d.recent) >= window {
+		// reuse the storage of the line that falls out of the window
+		buf = d.recent[0][:0]
+		d.recent = append(d.recent[:0], d.recent[1:]...)
+	}
+	d.recent = append(d.recent, append(buf, line...))
+}
+
+// duplicate remembers line, which was just written, and then rolls the dice
+// to decide whether to write a duplicate of a recent line.
+func (l *Logger) duplicate(line []byte) error {
+	d := l.Duplicates
+	if d.rng == nil {
+		d.rng = rand.New(rand.NewSource(d.Seed))
+	}
+	d.remember(line)
+	if d.rng.Float64() >= d.Rate {
+		return nil
+	}
+	dup := d.recent[d.rng.Intn(len(d.recent))]
+
+	if l.lines+1 > l.max() {
+		l.debug("debug", "rotating", "reason", "max lines", "lines", l.lines)
+		if err := l.rotate(ReasonMaxLines); err != nil {
+			return err
+		}
+	}
+	return l.writeDuplicate(dup)
+}
+
+// repeatLast writes the last line of the rotated file to the new file if
+// Duplicates.AfterRotation is set.
+func (l *Logger) repeatLast() error {
+	d := l.Duplicates
+	if d == nil || !d.AfterRotation || len(d.recent) == 0 || l.file == nil {
+		return nil
+	}
+	return l.writeDuplicate(d.recent[len(d.recent)-1])
+}
+
+// writeDuplicate writes dup to the active log file as a line of its own.
+func (l *Logger) writeDuplicate(dup []byte) error {
+	l.debug("debug", "writing duplicate line")
+	n, err := l.writeLine(dup)
+	l.lines++
+	if n > 0 {
+		l.observeWrite(dup[:n])
+	}
+	return err
+}
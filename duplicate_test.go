@@ -0,0 +1,84 @@
+package nanojack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicates(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   1000,
+		Duplicates: &Duplicates{Rate: 0.3, Window: 5, Seed: 4},
+	}
+	defer l.Close()
+
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		n, err := l.Write([]byte(line))
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	require.True(t, len(lines) > 110 && len(lines) < 150, len(lines))
+
+	stats, err := l.Stats()
+	require.NoError(t, err)
+	require.Equal(t, int64(len(lines)), stats.Lines)
+
+	// every original line appears in order, and every duplicate is a copy of
+	// one of the five lines before it
+	next := 0
+	for _, line := range lines {
+		var i int
+		_, err := fmt.Sscanf(line, "line %d", &i)
+		require.NoError(t, err)
+		if i == next {
+			next++
+			continue
+		}
+		require.True(t, i < next && i >= next-5, "%d after %d", i, next-1)
+	}
+	require.Equal(t, 100, next)
+}
+
+func TestDuplicatesAfterRotation(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   3,
+		Sequential: true,
+		Duplicates: &Duplicates{AfterRotation: true},
+	}
+	defer l.Close()
+
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, l.Rotate())
+
+	contents := func(path string) string {
+		b, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return string(b)
+	}
+	require.Equal(t, "a\nb\nc\n", contents(filename+".2"))
+	require.Equal(t, "c\nd\n", contents(filename+".1"))
+	require.Equal(t, "d\n", contents(filename))
+}
@@ -0,0 +1,162 @@
+package nanojack
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding in which a Logger writes lines. Lines
+// are always given to Write as UTF-8, and are converted as they are written.
+type Encoding int
+
+const (
+	// EncodingUTF8 writes lines unchanged. This is the default.
+	EncodingUTF8 Encoding = iota
+
+	// EncodingUTF16LE writes lines as little-endian UTF-16.
+	EncodingUTF16LE
+
+	// EncodingUTF16BE writes lines as big-endian UTF-16.
+	EncodingUTF16BE
+
+	// EncodingLatin1 writes lines as ISO 8859-1. Characters that it can't
+	// represent are written as '?'.
+	EncodingLatin1
+)
+
+var encodingNames = map[Encoding]string{
+	EncodingUTF8:    "utf-8",
+	EncodingUTF16LE: "utf-16le",
+	EncodingUTF16BE: "utf-16be",
+	EncodingLatin1:  "latin-1",
+}
+
+// String returns the configuration name of the encoding.
+func (e Encoding) String() string {
+	if name, ok := encodingNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e Encoding) MarshalText() ([]byte, error) {
+	if _, ok := encodingNames[e]; !ok {
+		return nil, fmt.Errorf("invalid encoding %d", int(e))
+	}
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *Encoding) UnmarshalText(text []byte) error {
+	for enc, name := range encodingNames {
+		if name == string(text) {
+			*e = enc
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid encoding %q", text)
+}
+
+// byteOrder returns the byte order of a UTF-16 encoding, or nil if e is not
+// UTF-16.
+func (e Encoding) byteOrder() binary.ByteOrder {
+	switch e {
+	case EncodingUTF16LE:
+		return binary.LittleEndian
+	case EncodingUTF16BE:
+		return binary.BigEndian
+	}
+	return nil
+}
+
+// bom returns the byte order mark of the encoding, which is empty if it has
+// none.
+func (e Encoding) bom() []byte {
+	switch e {
+	case EncodingUTF8:
+		return []byte{0xef, 0xbb, 0xbf}
+	case EncodingUTF16LE:
+		return []byte{0xff, 0xfe}
+	case EncodingUTF16BE:
+		return []byte{0xfe, 0xff}
+	}
+	return nil
+}
+
+// encode appends the UTF-8 text p to dst in the encoding e.
+func (e Encoding) encode(dst, p []byte) []byte {
+	order := e.byteOrder()
+	var unit [2]byte
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		p = p[size:]
+		switch {
+		case e == EncodingLatin1 && r < 0x100:
+			dst = append(dst, byte(r))
+		case e == EncodingLatin1:
+			dst = append(dst, '?')
+		case r >= 0x10000:
+			r1, r2 := utf16.EncodeRune(r)
+			order.PutUint16(unit[:], uint16(r1))
+			dst = append(dst, unit[:]...)
+			order.PutUint16(unit[:], uint16(r2))
+			dst = append(dst, unit[:]...)
+		default:
+			order.PutUint16(unit[:], uint16(r))
+			dst = append(dst, unit[:]...)
+		}
+	}
+	return dst
+}
+
+// encode converts p to the Logger's Encoding. The returned slice is only
+// valid until the next call.
+func (l *Logger) encode(p []byte) []byte {
+	if l.Encoding == EncodingUTF8 {
+		return p
+	}
+	l.encoded = l.Encoding.encode(l.encoded[:0], p)
+	return l.encoded
+}
+
+// countLines counts the lines in the file at path, as written in the
+// Logger's Encoding.
+func (l *Logger) countLines(path string) (int64, error) {
+	order := l.Encoding.byteOrder()
+	if order == nil {
+		return linesInFile(l.fs(), path)
+	}
+
+	f, err := l.fs().OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 32*1024)
+	var lines int64
+	var unit [2]byte
+	prev := uint16('\n')
+	for {
+		if _, err := io.ReadFull(r, unit[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return lines, nil
+		} else if err != nil {
+			return 0, err
+		}
+		c := order.Uint16(unit[:])
+		if c == 0xfeff {
+			// byte order mark
+			continue
+		}
+		if c != '\n' && prev == '\n' {
+			lines++
+		}
+		prev = c
+	}
+}
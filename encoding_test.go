@@ -0,0 +1,86 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodingUTF16(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxLines: 2, Sequential: true, Encoding: EncodingUTF16LE, BOM: true}
+	defer l.Close()
+
+	for _, line := range []string{"a\n", "é\n", "😀\n"} {
+		n, err := l.Write([]byte(line))
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+	}
+
+	b, err := ioutil.ReadFile(filename + ".1")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xff, 0xfe, 'a', 0, '\n', 0, 0xe9, 0, '\n', 0}, b)
+	b, err = ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xff, 0xfe, 0x3d, 0xd8, 0x00, 0xde, '\n', 0}, b)
+
+	// reopening the file counts its lines in the encoding
+	require.NoError(t, l.Close())
+	l2 := &Logger{Filename: filename, MaxLines: 2, Sequential: true, Encoding: EncodingUTF16LE, BOM: true}
+	defer l2.Close()
+	_, err = l2.Write([]byte("b\n"))
+	require.NoError(t, err)
+	b, err = ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xff, 0xfe, 0x3d, 0xd8, 0x00, 0xde, '\n', 0, 'b', 0, '\n', 0}, b)
+	fileCount(dir, 2, t)
+}
+
+func TestEncodingLatin1(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, Encoding: EncodingLatin1, BOM: true}
+	defer l.Close()
+
+	_, err := l.Write([]byte("café ☕\n"))
+	require.NoError(t, err)
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, []byte("caf\xe9 ?\n"), b)
+}
+
+func TestEncodingUTF8BOM(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, BOM: true, Unterminated: true}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	_, err = l.Write([]byte("two\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "\xef\xbb\xbfone\ntwo\n", string(b))
+}
+
+func TestEncodingText(t *testing.T) {
+	var cfg struct{ Encoding Encoding }
+	require.NoError(t, json.Unmarshal([]byte(`{"Encoding":"utf-16be"}`), &cfg))
+	require.Equal(t, EncodingUTF16BE, cfg.Encoding)
+	b, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	require.Equal(t, `{"Encoding":"utf-16be"}`, string(b))
+	require.Error(t, json.Unmarshal([]byte(`{"Encoding":"ebcdic"}`), &cfg))
+}
@@ -0,0 +1,217 @@
+package nanojack
+
+import (
+	"time"
+)
+
+// EventType identifies the kind of an Event.
+type EventType int
+
+const (
+	// EventTruncated indicates that the active log file was truncated by
+	// another process.
+	EventTruncated EventType = iota + 1
+
+	// EventDeleted indicates that the active log file was deleted by another
+	// process, and has been recreated.
+	EventDeleted
+
+	// EventRenamed indicates that the active log file was renamed by another
+	// process, so that its path no longer refers to the open file.
+	EventRenamed
+
+	// EventWatchCreate indicates that another process created a file in the
+	// log directory. It is only emitted when Watch is set.
+	EventWatchCreate
+
+	// EventWatchRename indicates that another process renamed a file in the
+	// log directory. It is only emitted when Watch is set.
+	EventWatchRename
+
+	// EventWatchRemove indicates that another process removed a file from
+	// the log directory. It is only emitted when Watch is set.
+	EventWatchRemove
+
+	// EventWatchError indicates that watching the log directory failed.
+	EventWatchError
+
+	// EventPostRotate reports the outcome of running PostRotateCmd.
+	EventPostRotate
+
+	// EventSignal reports the outcome of a rotation triggered by a signal
+	// installed with HandleSignals.
+	EventSignal
+
+	// EventRotate indicates that the active log file was rotated. Path is the
+	// new backup file, which is empty if no backup was made, and Reason says
+	// why the rotation happened. If the rotation failed, Err is set.
+	EventRotate
+
+	// EventRemove indicates that an old backup file, given by Path, was
+	// removed by cleanup.
+	EventRemove
+
+	// EventWriteError reports an error from an asynchronous write.
+	EventWriteError
+
+	// EventDropped indicates that an asynchronous write was dropped because
+	// the queue was full.
+	EventDropped
+
+	// EventStall indicates that the operation named by Op has been running
+	// for longer than StallThreshold, and may be hung.
+	EventStall
+
+	// EventNotify reports the outcome of sending NotifySignal to NotifyPID
+	// after a rotation.
+	EventNotify
+
+	// EventArchive reports the outcome of archiving the backup file given by
+	// Path.
+	EventArchive
+
+	// EventWebhook reports the outcome of posting a rotation of the backup
+	// given by Path to the Webhook.
+	EventWebhook
+
+	// EventLost indicates that lines appended to the active log file by other
+	// writers between the copy and the truncate of a MechanismCopyTruncate
+	// rotation were destroyed by the truncate. Lines holds how many.
+	EventLost
+
+	// EventCompress reports the outcome of compressing a backup when
+	// Compress is set. Path is the compressed backup, or the uncompressed
+	// one if compression failed.
+	EventCompress
+
+	// EventRetain records the retention decision for the backup given by
+	// Path, with the Reason it was kept or deleted. It is only written to the
+	// JournalFile, never sent to Events.
+	EventRetain
+
+	// EventFilename indicates that SetFilename switched the Logger from the
+	// log file given by Filename to the one given by Path. If the switch
+	// failed, Err is set.
+	EventFilename
+
+	// EventFailover indicates that the Logger switched to FallbackFilename,
+	// given by Path, because its Filename failed with Err.
+	EventFailover
+
+	// EventFailback indicates that the Logger switched back to its Filename,
+	// given by Path, from FallbackFilename.
+	EventFailback
+)
+
+// String returns a human readable name for the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventTruncated:
+		return "truncated"
+	case EventDeleted:
+		return "deleted"
+	case EventRenamed:
+		return "renamed"
+	case EventWatchCreate:
+		return "watch-create"
+	case EventWatchRename:
+		return "watch-rename"
+	case EventWatchRemove:
+		return "watch-remove"
+	case EventWatchError:
+		return "watch-error"
+	case EventPostRotate:
+		return "post-rotate"
+	case EventSignal:
+		return "signal"
+	case EventRotate:
+		return "rotate"
+	case EventRemove:
+		return "remove"
+	case EventWriteError:
+		return "write-error"
+	case EventDropped:
+		return "dropped"
+	case EventStall:
+		return "stall"
+	case EventNotify:
+		return "notify"
+	case EventArchive:
+		return "archive"
+	case EventWebhook:
+		return "webhook"
+	case EventLost:
+		return "lost"
+	case EventCompress:
+		return "compress"
+	case EventRetain:
+		return "retain"
+	case EventFilename:
+		return "filename"
+	case EventFailover:
+		return "failover"
+	case EventFailback:
+		return "failback"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes something notable that happened to a Logger's files.
+type Event struct {
+	// Type identifies what happened.
+	Type EventType
+
+	// Time is the time at which the event was observed.
+	Time time.Time
+
+	// Filename is the log file the event pertains to.
+	Filename string
+
+	// Path is the file affected by the event, for events that concern a file
+	// other than the active log file.
+	Path string
+
+	// Err is the error associated with the event, if any.
+	Err error
+
+	// Output is the combined output of a command run by the Logger.
+	Output string
+
+	// ExitCode is the exit status of a command run by the Logger.
+	ExitCode int
+
+	// Op names the operation an EventStall concerns, "rotate" or "cleanup".
+	Op string
+
+	// DryRun is set if the action the event describes was not carried out
+	// because the Logger is in dry-run mode.
+	DryRun bool
+
+	// Lines is the number of lines an EventLost concerns.
+	Lines int64
+
+	// Reason explains an EventRotate, with the RotationReason for the
+	// rotation, or an EventRetain: "pinned" or "kept" for a backup that was
+	// kept, or the limit that expired it, "count", "age" or "size".
+	Reason string
+}
+
+// emit sends an event to the Logger's Events channel, if there is one, and
+// records it in the journal. Events are dropped rather than blocking the
+// Logger when the channel is full. The caller sets the event's Filename,
+// since emit may be called from goroutines that do not hold the lock.
+func (l *Logger) emit(e Event) {
+	if l.Events == nil && l.JournalFile == "" {
+		return
+	}
+	e.Time = l.now()
+	l.record(e)
+	if l.Events == nil {
+		return
+	}
+	select {
+	case l.Events <- e:
+	default:
+	}
+}
@@ -15,3 +15,24 @@ func Example() {
 		MaxBackups: 3,
 	})
 }
+
+// A generator process can be rotated from outside, as logrotate rotates a
+// daemon, by leaving a PID file where a script can find it, here
+// /var/log/myapp/foo.log.pid, and handling the SIGHUP the script sends.
+func ExampleLogger_HandleSignals() {
+	l := &nanojack.Logger{
+		Filename:   "/var/log/myapp/foo.log",
+		MaxBackups: 3,
+		PIDFile:    true,
+	}
+	defer l.Close()
+	l.HandleSignals()
+
+	g, err := nanojack.NewTemplate("{ts} request {uuid} from {ipv4}", 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := nanojack.Generate(l, g, 1000000); err != nil {
+		log.Fatal(err)
+	}
+}
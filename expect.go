@@ -0,0 +1,74 @@
+package nanojack
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Inspector returns an Inspector for the Logger's log file and its backups,
+// so that the outcome of a run can be checked with its Expect methods.
+func (l *Logger) Inspector() Inspector {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Inspector{
+		Filename:   l.filename(),
+		Sequential: l.Sequential,
+		Compress:   l.Compress,
+		Gzip:       l.Gzip,
+		Encoding:   l.Encoding,
+		Namer:      l.Namer,
+		FileSystem: l.FileSystem,
+	}
+}
+
+// ExpectTotalLines returns an error, listing the lines in each file, unless
+// the log file and its backups hold n lines between them.
+func (i Inspector) ExpectTotalLines(n int64) error {
+	in, err := i.Inspect()
+	if err != nil {
+		return err
+	}
+	if in.TotalLines != n {
+		var counts []string
+		for _, b := range append([]BackupInfo{in.Active}, in.Backups...) {
+			counts = append(counts, fmt.Sprintf("%s: %d", filepath.Base(b.Path), b.Lines))
+		}
+		return fmt.Errorf("expected %d lines in %s and its backups, found %d (%s)",
+			n, in.Active.Path, in.TotalLines, strings.Join(counts, ", "))
+	}
+	return nil
+}
+
+// ExpectBackupCount returns an error, listing the backups, unless the log
+// file has n backups.
+func (i Inspector) ExpectBackupCount(n int) error {
+	in, err := i.Inspect()
+	if err != nil {
+		return err
+	}
+	if len(in.Backups) != n {
+		names := make([]string, len(in.Backups))
+		for j, b := range in.Backups {
+			names[j] = filepath.Base(b.Path)
+		}
+		return fmt.Errorf("expected %d backups of %s, found %d: [%s]",
+			n, in.Active.Path, len(in.Backups), strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// ExpectNoGaps returns an error, listing the missing sequence numbers, if
+// the run of sequential backups has gaps in it. Timestamped backups never
+// have gaps.
+func (i Inspector) ExpectNoGaps() error {
+	in, err := i.Inspect()
+	if err != nil {
+		return err
+	}
+	if len(in.Gaps) > 0 {
+		return fmt.Errorf("expected no gaps in the backups of %s, found %d missing: %v",
+			in.Active.Path, len(in.Gaps), in.Gaps)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpect(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxLines: 2, Sequential: true}
+	defer l.Close()
+	for i := 0; i < 5; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+
+	in := l.Inspector()
+	require.NoError(t, in.ExpectTotalLines(5))
+	require.NoError(t, in.ExpectBackupCount(2))
+	require.NoError(t, in.ExpectNoGaps())
+
+	require.EqualError(t, in.ExpectTotalLines(6),
+		"expected 6 lines in "+logFile(dir)+" and its backups, found 5 "+
+			"(foobar.log: 1, foobar.log.1: 2, foobar.log.2: 2)")
+	require.EqualError(t, in.ExpectBackupCount(1),
+		"expected 1 backups of "+logFile(dir)+", found 2: [foobar.log.1, foobar.log.2]")
+
+	require.NoError(t, os.Remove(sequentialName(logFile(dir), 1)))
+	require.NoError(t, ioutil.WriteFile(sequentialName(logFile(dir), 4), nil, 0644))
+	require.EqualError(t, in.ExpectNoGaps(),
+		"expected no gaps in the backups of "+logFile(dir)+", found 2 missing: [1 3]")
+}
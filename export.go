@@ -0,0 +1,69 @@
+package nanojack
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ExportZip writes a zip archive of the active log file and all of its
+// backups to w, along with a manifest.json holding the Logger's Stats, for
+// attaching to bug reports. Entries keep the base names and modification
+// times of the files. As with Snapshot, the Logger's lock is held while the
+// archive is written.
+func (l *Logger) ExportZip(w io.Writer) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats, err := l.stats()
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	err = eachFamilyFile(&stats, func(path string) error {
+		return addZipFile(zw, l.fs(), path)
+	})
+	if err != nil {
+		return err
+	}
+
+	mw, err := zw.Create(manifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(stats); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// addZipFile adds the file at path on fsys to zw under its base name.
+func addZipFile(zw *zip.Writer, fsys FileSystem, path string) error {
+	f, err := fsys.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.Base(path)
+	hdr.Method = zip.Deflate
+	fw, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, f)
+	return err
+}
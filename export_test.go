@@ -0,0 +1,57 @@
+package nanojack
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportZip(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxLines: 2, Sequential: true}
+	defer l.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	mtime := time.Date(2020, 10, 20, 15, 4, 6, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filename+".1", mtime, mtime))
+
+	var buf bytes.Buffer
+	require.NoError(t, l.ExportZip(&buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 3)
+
+	want := map[string]string{
+		filepath.Base(filename):        "three\n",
+		filepath.Base(filename) + ".1": "one\ntwo\n",
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+
+		if f.Name == manifestName {
+			require.Contains(t, string(b), `"rotations": 1`)
+			continue
+		}
+		require.Equal(t, want[f.Name], string(b), f.Name)
+		if f.Name == filepath.Base(filename)+".1" {
+			require.True(t, mtime.Equal(f.Modified), f.Modified.String())
+		}
+	}
+}
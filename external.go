@@ -0,0 +1,185 @@
+package nanojack
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrTruncated is returned by Write when TruncationIsError is set and the
+// active log file was found to have been truncated by another process.
+var ErrTruncated = errors.New("nanojack: log file was truncated externally")
+
+// ErrRenamed is returned by Write when OnRename is RenameError and the active
+// log file was found to have been renamed by another process.
+var ErrRenamed = errors.New("nanojack: log file was renamed externally")
+
+// RenameAction selects how a Logger responds when the active log file is
+// renamed by another process, such as an external log rotation tool.
+type RenameAction int
+
+const (
+	// RenameIgnore disables rename detection. This is the default.
+	RenameIgnore RenameAction = iota
+
+	// RenameKeep keeps writing to the renamed file, as an application that
+	// is unaware of the rotation would.
+	RenameKeep
+
+	// RenameReopen closes the renamed file and opens the file now at the
+	// Logger's path, creating it if necessary.
+	RenameReopen
+
+	// RenameError causes every write to fail with ErrRenamed until the Logger
+	// is rotated.
+	RenameError
+)
+
+// Reopen closes the active log file and opens the file now at the Logger's
+// path, creating it if necessary, and counts the lines already in it. It is
+// meant for after an external tool has rotated the file, as nginx and others
+// reopen their logs on SIGUSR1. If the file found is full, it is rotated, as
+// it would be when the Logger first opened it. Reopen does nothing if the
+// Logger has no file open.
+func (l *Logger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.acquire(); err != nil {
+		return err
+	}
+	defer l.release()
+	if l.file == nil {
+		return nil
+	}
+	l.debug("debug", "reopening", "path", l.filename())
+	if err := l.close(); err != nil {
+		return err
+	}
+	return l.openExistingOrNew()
+}
+
+// checkExternal looks for interference by other processes with the active
+// log file, according to the Logger's detection settings.
+func (l *Logger) checkExternal() error {
+	if l.device || !l.DetectTruncation && !l.DetectDeletion && l.OnRename == RenameIgnore {
+		return nil
+	}
+
+	if l.CheckInterval > 0 {
+		now := l.now()
+		if !l.lastCheck.IsZero() && now.Sub(l.lastCheck) < l.CheckInterval {
+			return nil
+		}
+		l.lastCheck = now
+	}
+
+	if l.DetectDeletion {
+		deleted, err := l.checkDeleted()
+		if err != nil || deleted {
+			return err
+		}
+	}
+
+	if l.OnRename != RenameIgnore {
+		renamed, err := l.checkRenamed()
+		if err != nil || renamed {
+			return err
+		}
+	}
+
+	if l.DetectTruncation {
+		return l.checkTruncated()
+	}
+	return nil
+}
+
+// checkRenamed checks whether the active log file's path still refers to the
+// open file. If it does not, an EventRenamed is emitted once and the Logger
+// responds according to OnRename.
+func (l *Logger) checkRenamed() (bool, error) {
+	if l.detached {
+		if l.OnRename == RenameError {
+			return true, ErrRenamed
+		}
+		return true, nil
+	}
+
+	open, err := l.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	current, err := l.fs().Stat(l.filename())
+	if err == nil && sameFile(open, current) {
+		return false, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	switch l.OnRename {
+	case RenameReopen:
+		l.emit(Event{Type: EventRenamed, Filename: l.filename()})
+		if err := l.close(); err != nil {
+			return true, err
+		}
+		return true, l.openExistingOrNew()
+	case RenameError:
+		l.detached = true
+		l.emit(Event{Type: EventRenamed, Filename: l.filename(), Err: ErrRenamed})
+		return true, ErrRenamed
+	default:
+		l.detached = true
+		l.emit(Event{Type: EventRenamed, Filename: l.filename()})
+		return true, nil
+	}
+}
+
+// checkDeleted checks whether the active log file still exists at its path.
+// If it does not, the orphaned handle is closed, a new file is created in its
+// place, and an EventDeleted is emitted.
+func (l *Logger) checkDeleted() (bool, error) {
+	_, err := l.fs().Stat(l.filename())
+	if err == nil || !os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err := l.close(); err != nil {
+		return true, err
+	}
+	if err := l.initializeFile(); err != nil {
+		return true, err
+	}
+	l.emit(Event{Type: EventDeleted, Filename: l.filename()})
+	return true, nil
+}
+
+// checkTruncated compares the size of the open file against the number of
+// bytes the Logger believes it contains. If the file has shrunk, the line
+// count is recomputed from disk, the write offset is moved to the new end of
+// the file, and an EventTruncated is emitted.
+func (l *Logger) checkTruncated() error {
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() >= l.size {
+		return nil
+	}
+
+	l.size = info.Size()
+	// the line a held newline would have ended is gone
+	l.held = false
+	if l.lines, err = l.countLines(l.filename()); err != nil {
+		return err
+	}
+	if _, err := l.file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	if l.TruncationIsError {
+		l.emit(Event{Type: EventTruncated, Filename: l.filename(), Err: ErrTruncated})
+		return ErrTruncated
+	}
+	l.emit(Event{Type: EventTruncated, Filename: l.filename()})
+	return nil
+}
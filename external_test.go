@@ -0,0 +1,259 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTruncation(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:         filename,
+		MaxLines:         3,
+		DetectTruncation: true,
+		Events:           events,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 2; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+	require.Empty(t, events)
+
+	require.NoError(t, os.Truncate(filename, 0))
+
+	// Without resyncing, this write would trigger a rotation, and would land
+	// at the old offset leaving a hole of zeros at the start of the file.
+	for i := 0; i < 3; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+	fileCount(dir, 1, t)
+	existsWithLines(filename, 3, t)
+	content, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "boo!\nboo!\nboo!\n", string(content))
+
+	require.Len(t, events, 1)
+	e := <-events
+	require.Equal(t, EventTruncated, e.Type)
+	require.Equal(t, filename, e.Filename)
+	require.NoError(t, e.Err)
+}
+
+func TestReopen(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 3,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 2; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+
+	// an external tool rotates the file, and something else writes a line
+	// to the new one
+	rotated := filename + ".1"
+	require.NoError(t, os.Rename(filename, rotated))
+	require.NoError(t, ioutil.WriteFile(filename, b, 0644))
+
+	require.NoError(t, l.Reopen())
+	for i := 0; i < 2; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+	existsWithLines(rotated, 2, t)
+	existsWithLines(filename, 3, t)
+	fileCount(dir, 2, t)
+}
+
+func TestTruncationIsError(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:          filename,
+		DetectTruncation:  true,
+		TruncationIsError: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Truncate(filename, 0))
+
+	n, err := l.Write(b)
+	require.Equal(t, ErrTruncated, err)
+	require.Equal(t, 0, n)
+	existsWithLines(filename, 0, t)
+
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(filename, 1, t)
+}
+
+func TestDetectDeletion(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:       filename,
+		DetectDeletion: true,
+		Events:         events,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 2; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, os.Remove(filename))
+
+	_, err := l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(filename, 1, t)
+	require.Equal(t, int64(1), l.lines)
+
+	require.Len(t, events, 1)
+	e := <-events
+	require.Equal(t, EventDeleted, e.Type)
+	require.Equal(t, filename, e.Filename)
+}
+
+func TestCheckInterval(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		DetectDeletion: true,
+		CheckInterval:  time.Minute,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filename))
+
+	// the first write was checked, so this one is not
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	notExist(filename, t)
+
+	newFakeTime(time.Minute)
+
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(filename, 1, t)
+}
+
+func TestOnRename(t *testing.T) {
+	t.Run("Keep", testOnRename(t, RenameKeep))
+	t.Run("Reopen", testOnRename(t, RenameReopen))
+	t.Run("Error", testOnRename(t, RenameError))
+}
+
+func testOnRename(t *testing.T, action RenameAction) func(t *testing.T) {
+	return func(t *testing.T) {
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		filename := logFile(dir)
+		moved := filename + ".moved"
+		events := make(chan Event, 10)
+		l := &Logger{
+			Filename: filename,
+			OnRename: action,
+			Events:   events,
+		}
+		defer l.Close()
+
+		b := []byte("boo!\n")
+		_, err := l.Write(b)
+		require.NoError(t, err)
+
+		require.NoError(t, os.Rename(filename, moved))
+		require.NoError(t, ioutil.WriteFile(filename, []byte("external\n"), 0644))
+
+		for i := 0; i < 2; i++ {
+			_, err = l.Write(b)
+			if action == RenameError {
+				require.Equal(t, ErrRenamed, err)
+			} else {
+				require.NoError(t, err)
+			}
+		}
+
+		switch action {
+		case RenameKeep:
+			existsWithLines(moved, 3, t)
+			existsWithLines(filename, 1, t)
+		case RenameReopen:
+			existsWithLines(moved, 1, t)
+			existsWithLines(filename, 3, t)
+		case RenameError:
+			existsWithLines(moved, 1, t)
+			existsWithLines(filename, 1, t)
+		}
+
+		require.Len(t, events, 1)
+		e := <-events
+		require.Equal(t, EventRenamed, e.Type)
+	}
+}
+
+func TestOnRenameMissing(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		OnRename: RenameReopen,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Rename(filename, filename+".moved"))
+
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(filename, 1, t)
+}
@@ -0,0 +1,56 @@
+package nanojack
+
+import (
+	"os"
+)
+
+// canFailover reports whether the Logger can switch to FallbackFilename.
+func (l *Logger) canFailover() bool {
+	return l.FallbackFilename != "" && !l.failedOver && !l.device
+}
+
+// failover switches the Logger from its Filename, which failed with cause,
+// to FallbackFilename. It returns the error from opening the fallback.
+func (l *Logger) failover(cause error) error {
+	l.debug("error", "failing over", "path", l.FallbackFilename, "error", cause)
+	// the primary is broken, so its file can't be expected to close cleanly
+	_ = l.close()
+	l.setFile(nil, 0, 0)
+	l.failedOver = true
+	l.lastFailback = l.now()
+	l.emit(Event{Type: EventFailover, Filename: l.filename(), Path: l.FallbackFilename, Err: cause})
+	return l.openExistingOrNew()
+}
+
+// failback switches the Logger back from FallbackFilename to its Filename if
+// the latter can be written to again. The check is made at most once per
+// CheckInterval.
+func (l *Logger) failback() error {
+	if !l.failedOver {
+		return nil
+	}
+	now := l.now()
+	if l.CheckInterval > 0 && now.Sub(l.lastFailback) < l.CheckInterval {
+		return nil
+	}
+	l.lastFailback = now
+
+	primary := l.Filename
+	if primary == "" {
+		primary = defaultFilename()
+	}
+	// the directories aren't created, since their absence may be the fault
+	f, err := l.fs().OpenFile(primary, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil
+	}
+	f.Close()
+
+	l.debug("debug", "failing back", "path", primary)
+	if err := l.close(); err != nil {
+		return err
+	}
+	l.failedOver = false
+	l.emit(Event{Type: EventFailback, Filename: l.filename(), Path: primary})
+	return l.openExistingOrNew()
+}
@@ -0,0 +1,55 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailover(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	// a file in the place of the primary's directory makes it unusable
+	primaryDir := filepath.Join(dir, "primary")
+	require.NoError(t, ioutil.WriteFile(primaryDir, nil, 0644))
+
+	events := make(chan Event, 10)
+	primary := filepath.Join(primaryDir, "foobar.log")
+	fallback := filepath.Join(dir, "fallback", "foobar.log")
+	l := &Logger{
+		Filename:         primary,
+		FallbackFilename: fallback,
+		Events:           events,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(fallback, 1, t)
+	e := <-events
+	require.Equal(t, EventFailover, e.Type)
+	require.Equal(t, fallback, e.Path)
+	require.Error(t, e.Err)
+
+	// still broken, so the fallback stays in use
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(fallback, 2, t)
+
+	// once the primary recovers, the Logger goes back to it
+	require.NoError(t, os.Remove(primaryDir))
+	require.NoError(t, os.Mkdir(primaryDir, 0755))
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(primary, 1, t)
+	existsWithLines(fallback, 2, t)
+	e = <-events
+	require.Equal(t, EventFailback, e.Type)
+	require.Equal(t, primary, e.Path)
+}
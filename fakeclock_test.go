@@ -0,0 +1,85 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	require.Equal(t, start, c.Now())
+
+	var fired []time.Time
+	record := func() { fired = append(fired, c.Now()) }
+	c.AfterFunc(2*time.Second, record)
+	c.AfterFunc(time.Second, record)
+	stopped := c.AfterFunc(time.Second, record)
+	require.True(t, stopped.Stop())
+	require.False(t, stopped.Stop())
+	after := c.After(3 * time.Second)
+
+	c.Advance(2500 * time.Millisecond)
+	require.Equal(t, []time.Time{start.Add(time.Second), start.Add(2 * time.Second)}, fired)
+	require.Equal(t, start.Add(2500*time.Millisecond), c.Now())
+	require.Empty(t, after)
+
+	c.Sleep(time.Second)
+	require.Equal(t, start.Add(3*time.Second), <-after)
+	require.Equal(t, start.Add(3500*time.Millisecond), c.Now())
+
+	// the clock never goes backwards
+	c.Set(start)
+	require.Equal(t, start.Add(3500*time.Millisecond), c.Now())
+}
+
+func TestFakeClockLogger(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	c := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:       logFile(dir),
+		Clock:          c,
+		WriteLatency:   time.Hour,
+		RotateLatency:  time.Minute,
+		StallThreshold: time.Second,
+		Events:         events,
+	}
+	defer l.Close()
+
+	// the latency passes on the fake clock, not the real one
+	start := time.Now()
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.True(t, time.Since(start) < time.Minute)
+	require.Equal(t, time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC), c.Now())
+
+	// the slow rotation stalls deterministically
+	require.NoError(t, l.Rotate())
+	e := <-events
+	require.Equal(t, EventStall, e.Type)
+	require.Equal(t, time.Date(2020, 1, 1, 1, 0, 1, 0, time.UTC), e.Time)
+	require.Equal(t, EventRotate, (<-events).Type)
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	tk := newTicker(c, time.Second)
+
+	c.Advance(time.Second)
+	require.Equal(t, start.Add(time.Second), <-tk.C)
+	// ticks are dropped for a slow receiver
+	c.Advance(3 * time.Second)
+	require.Equal(t, start.Add(2*time.Second), <-tk.C)
+	require.Empty(t, tk.C)
+
+	tk.Stop()
+	c.Advance(time.Second)
+	require.Empty(t, tk.C)
+}
@@ -0,0 +1,14 @@
+// +build !windows
+
+package nanojack
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID returns the device and inode numbers of the file described by info.
+func fileID(_ string, info os.FileInfo) (FileID, error) {
+	stat := info.Sys().(*syscall.Stat_t)
+	return FileID{Device: uint64(stat.Dev), Inode: uint64(stat.Ino)}, nil
+}
@@ -0,0 +1,30 @@
+package nanojack
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID returns the volume serial number and file index of the file at path.
+func fileID(path string, _ os.FileInfo) (FileID, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return FileID{}, err
+	}
+	h, err := syscall.CreateFile(p, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return FileID{}, err
+	}
+	defer syscall.CloseHandle(h)
+
+	var d syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &d); err != nil {
+		return FileID{}, err
+	}
+	return FileID{
+		Device: uint64(d.VolumeSerialNumber),
+		Inode:  uint64(d.FileIndexHigh)<<32 | uint64(d.FileIndexLow),
+	}, nil
+}
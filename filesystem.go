@@ -0,0 +1,141 @@
+package nanojack
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSystem is what a Logger keeps its log file and backups on. It defaults
+// to the real file system, through the os package. Another FileSystem, such
+// as a MemoryFS, lets the Logger's rotation, retention, cleanup and
+// compression run without touching the disk.
+//
+// The log file and its backups, the PID file and the JournalFile are kept on
+// the FileSystem. The features that deal with the operating system rather
+// than with files need the real file system, and fail with an error naming
+// them on any other: Lock, LockActive, CopyTruncateLocked, DirectIO,
+// Preallocate, Watch, Owner and Group, StrictPermissions, Chaos flipping, and
+// Archivers other than those of this package. Devices are only found on the
+// real file system. Snapshot, LocalArchiver and ListenControl always write to
+// the real file system.
+type FileSystem interface {
+	// OpenFile opens the named file as os.OpenFile does.
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+
+	// Rename renames a file as os.Rename does.
+	Rename(oldpath, newpath string) error
+
+	// Remove removes a file or empty directory as os.Remove does.
+	Remove(name string) error
+
+	// Stat describes the named file as os.Stat does, and Lstat as os.Lstat
+	// does.
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+
+	// MkdirAll creates a directory and its parents as os.MkdirAll does.
+	MkdirAll(path string, perm os.FileMode) error
+
+	// ReadDirNames returns the names of the entries in the directory dir, in
+	// no particular order.
+	ReadDirNames(dir string) ([]string, error)
+
+	// Glob returns the names of the files matching pattern as filepath.Glob
+	// does.
+	Glob(pattern string) ([]string, error)
+
+	// Chtimes changes the access and modification times of the named file
+	// as os.Chtimes does.
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// File is an open file of a FileSystem. *os.File implements it.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.WriterAt
+	io.Seeker
+	io.Closer
+	Name() string
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+}
+
+// osFS is the real file system.
+type osFS struct{}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		// a nil *os.File would make a non-nil File
+		return nil, err
+	}
+	return f, nil
+}
+
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os_Stat(name) }
+func (osFS) Lstat(name string) (os.FileInfo, error)       { return os.Lstat(name) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) ReadDirNames(dir string) ([]string, error)    { return readDirNames(dir) }
+func (osFS) Glob(pattern string) ([]string, error)        { return filepath.Glob(pattern) }
+
+func (osFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+// fs returns the FileSystem the Logger keeps its files on.
+func (l *Logger) fs() FileSystem {
+	if l.FileSystem != nil {
+		return l.FileSystem
+	}
+	return osFS{}
+}
+
+// realFS reports whether fsys is the real file system.
+func realFS(fsys FileSystem) bool {
+	_, ok := fsys.(osFS)
+	return ok
+}
+
+// osFile returns f as an *os.File, or an error naming feature if f is not on
+// the real file system.
+func osFile(f File, feature string) (*os.File, error) {
+	if f, ok := f.(*os.File); ok {
+		return f, nil
+	}
+	return nil, needsRealFS(feature)
+}
+
+// needsRealFS returns the error for using feature on a FileSystem other than
+// the real one.
+func needsRealFS(feature string) error {
+	return fmt.Errorf("nanojack: %s needs the real file system", feature)
+}
+
+// readFile returns the contents of the named file on fsys.
+func readFile(fsys FileSystem, name string) ([]byte, error) {
+	f, err := fsys.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// sameFile reports whether a and b describe the same file, as os.SameFile
+// does, for files of any FileSystem.
+func sameFile(a, b os.FileInfo) bool {
+	if ma, ok := a.(*memInfo); ok {
+		mb, ok := b.(*memInfo)
+		return ok && ma.node == mb.node
+	}
+	return os.SameFile(a, b)
+}
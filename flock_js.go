@@ -0,0 +1,19 @@
+package nanojack
+
+import (
+	"errors"
+	"os"
+)
+
+// errNoLocking is returned by lockFile, since js/wasm has no file locks.
+var errNoLocking = errors.New("nanojack: file locking is not supported on js/wasm")
+
+// lockFile fails, since there are no file locks on js/wasm.
+func lockFile(f *os.File, block bool) error {
+	return errNoLocking
+}
+
+// unlockFile fails, since there are no file locks on js/wasm.
+func unlockFile(f *os.File) error {
+	return errNoLocking
+}
@@ -0,0 +1,217 @@
+// +build go1.16
+
+package nanojack
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing/fstest"
+	"time"
+)
+
+// familyDir is the directory of an FS that holds the stable names.
+const familyDir = "family"
+
+// FS returns a read-only fs.FS over the log file and its backups, so that
+// standard tooling such as fs.WalkDir and testing/fstest can be pointed at
+// what the Logger has produced. The files are listed afresh on every Open.
+//
+// The root of the FS holds the files under their real names, as far as they
+// are in the log file's directory. The directory "family" holds them all
+// under stable names: "current" for the log file, and "1", "2" and so on for
+// the backups, newest first, whatever the naming scheme.
+func (l *Logger) FS() fs.FS {
+	return familyFS{l}
+}
+
+// FS returns a snapshot of the MemoryLogger's files as an fs.FS, laid out as
+// Logger.FS lays out the files on disk, so that code that reads the FS of a
+// Logger can be run without touching the file system. The active file is
+// always present, even when it is empty.
+func (m *MemoryLogger) FS() fs.FS {
+	active, backups := m.Contents(), m.Backups()
+	filename := m.filename()
+	now := clockNow(m.Clock)
+
+	fsys := fstest.MapFS{}
+	add := func(stable, name string, data []byte) {
+		file := &fstest.MapFile{Data: data, Mode: 0644, ModTime: now}
+		fsys[path.Join(familyDir, stable)] = file
+		if filepath.Dir(name) == filepath.Dir(filename) && filepath.Base(name) != familyDir {
+			fsys[filepath.Base(name)] = file
+		}
+	}
+	add("current", filename, active)
+	for i, b := range backups {
+		add(strconv.Itoa(i+1), b.Name, b.Data)
+	}
+	return fsys
+}
+
+type familyFS struct {
+	l *Logger
+}
+
+// fsEntry is a file of a familyFS.
+type fsEntry struct {
+	name string
+	path string
+}
+
+// entries lists the files of the FS in its root, and in familyDir.
+func (f familyFS) entries() (root, family []fsEntry, err error) {
+	f.l.mu.Lock()
+	filename := f.l.filename()
+	backups, err := f.l.backupFiles()
+	f.l.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paths := backups
+	if fileExists(f.l.fs(), filename) {
+		family = append(family, fsEntry{"current", filename})
+		paths = append([]string{filename}, backups...)
+	}
+	for i, path := range backups {
+		family = append(family, fsEntry{strconv.Itoa(i + 1), path})
+	}
+	dir := filepath.Dir(filename)
+	for _, path := range paths {
+		if name := filepath.Base(path); filepath.Dir(path) == dir && name != familyDir {
+			root = append(root, fsEntry{name, path})
+		}
+	}
+	return root, family, nil
+}
+
+// Open implements fs.FS.
+func (f familyFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	root, family, err := f.entries()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	switch name {
+	case ".":
+		d := &fsDir{name: "."}
+		d.add(f.l.fs(), root)
+		d.entries = append(d.entries, fs.FileInfoToDirEntry(dirInfo{familyDir}))
+		sort.Slice(d.entries, func(i, j int) bool {
+			return d.entries[i].Name() < d.entries[j].Name()
+		})
+		return d, nil
+	case familyDir:
+		d := &fsDir{name: familyDir}
+		d.add(f.l.fs(), family)
+		return d, nil
+	}
+
+	entries, base := root, name
+	if dir, file := filepath.Split(filepath.FromSlash(name)); dir == familyDir+string(filepath.Separator) {
+		entries, base = family, file
+	}
+	for _, e := range entries {
+		if e.name == base {
+			file, err := f.l.fs().OpenFile(e.path, os.O_RDONLY, 0)
+			if err != nil {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			return &fsFile{file: file, name: base}, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// fsFile is a file of a familyFS, which it reports under its name there.
+type fsFile struct {
+	file File
+	name string
+}
+
+func (f *fsFile) Read(p []byte) (int, error) { return f.file.Read(p) }
+
+func (f *fsFile) ReadAt(p []byte, off int64) (int, error) { return f.file.ReadAt(p, off) }
+
+func (f *fsFile) Seek(offset int64, whence int) (int64, error) {
+	return f.file.Seek(offset, whence)
+}
+
+func (f *fsFile) Close() error { return f.file.Close() }
+
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return namedInfo{info, f.name}, nil
+}
+
+// namedInfo is a FileInfo reported under another name.
+type namedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i namedInfo) Name() string { return i.name }
+
+// dirInfo describes a directory of a familyFS.
+type dirInfo struct {
+	name string
+}
+
+func (i dirInfo) Name() string       { return i.name }
+func (i dirInfo) Size() int64        { return 0 }
+func (i dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (i dirInfo) ModTime() time.Time { return time.Time{} }
+func (i dirInfo) IsDir() bool        { return true }
+func (i dirInfo) Sys() interface{}   { return nil }
+
+// fsDir is an open directory of a familyFS.
+type fsDir struct {
+	name    string
+	entries []fs.DirEntry
+	read    int
+}
+
+// add lists the files that can still be found among entries in d.
+func (d *fsDir) add(fsys FileSystem, entries []fsEntry) {
+	for _, e := range entries {
+		if info, err := fsys.Stat(e.path); err == nil {
+			d.entries = append(d.entries, fs.FileInfoToDirEntry(namedInfo{info, e.name}))
+		}
+	}
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) { return dirInfo{d.name}, nil }
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *fsDir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.read:]
+	if n <= 0 {
+		d.read = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.read += n
+	return rest[:n], nil
+}
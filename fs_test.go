@@ -0,0 +1,103 @@
+// +build go1.16
+
+package nanojack
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxLines: 2, Sequential: true}
+	defer l.Close()
+	for i := 0; i < 5; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+
+	fsys := l.FS()
+	require.NoError(t, fstest.TestFS(fsys,
+		"foobar.log", "foobar.log.1", "foobar.log.2",
+		"family/current", "family/1", "family/2"))
+
+	// the stable names follow the backups as they rotate
+	b, err := fs.ReadFile(fsys, "family/2")
+	require.NoError(t, err)
+	require.Equal(t, "boo!\nboo!\n", string(b))
+	_, err = fs.Stat(fsys, "family/3")
+	require.True(t, os.IsNotExist(err))
+
+	var walked []string
+	require.NoError(t, fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if !d.IsDir() {
+			walked = append(walked, path)
+		}
+		return err
+	}))
+	require.Equal(t, []string{
+		"family/1", "family/2", "family/current",
+		"foobar.log", "foobar.log.1", "foobar.log.2",
+	}, walked)
+}
+
+func TestFSTimestamped(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxLines: 1}
+	defer l.Close()
+	for i := 0; i < 3; i++ {
+		newFakeTime(time.Second)
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+
+	backups, err := l.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.NoError(t, fstest.TestFS(l.FS(), "foobar.log",
+		filepath.Base(backups[0].Path), filepath.Base(backups[1].Path),
+		"family/current", "family/1", "family/2"))
+}
+
+func TestMemoryLoggerFS(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxLines: 2, Sequential: true}
+	defer l.Close()
+	m := &MemoryLogger{Filename: logFile(dir), MaxLines: 2, Sequential: true}
+	for i := 0; i < 5; i++ {
+		line := []byte(fmt.Sprintf("line %d\n", i))
+		_, err := l.Write(line)
+		require.NoError(t, err)
+		_, err = m.Write(line)
+		require.NoError(t, err)
+	}
+
+	// the in-memory files are laid out, and hold the same, as those on disk
+	names := []string{
+		"foobar.log", "foobar.log.1", "foobar.log.2",
+		"family/current", "family/1", "family/2",
+	}
+	mfs := m.FS()
+	require.NoError(t, fstest.TestFS(mfs, names...))
+	for _, name := range names {
+		want, err := fs.ReadFile(l.FS(), name)
+		require.NoError(t, err)
+		got, err := fs.ReadFile(mfs, name)
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(got), name)
+	}
+}
@@ -0,0 +1,107 @@
+package nanojack
+
+import (
+	"io"
+	"sync"
+)
+
+// Generator produces synthetic log lines, so that realistic-looking corpora
+// can be written through a Logger without a real application behind it.
+// Generators driven by a seeded random source produce the same sequence of
+// lines for the same seed.
+type Generator interface {
+	// Line returns the next line, without a trailing newline. The returned
+	// slice is only valid until the next call to Line.
+	Line() []byte
+}
+
+// Generate writes n lines from g to w, each followed by a newline, as fast as
+// w accepts them.
+func Generate(w io.Writer, g Generator, n int) error {
+	return (&Load{Generator: g}).Run(w, n)
+}
+
+// Load writes lines from a Generator at a controlled rate. Its SetRate, Pause,
+// Resume and Stop methods may be called while it runs, from any goroutine.
+type Load struct {
+	// Generator produces the lines to write.
+	Generator Generator
+
+	// Rate is the number of lines written per second. A zero Rate writes
+	// lines as fast as the writer accepts them.
+	Rate float64
+
+	// ByteRate, if positive, is the number of bytes written per second,
+	// newlines included, and is used instead of Rate. The lines are paced by
+	// their sizes, so that a sustained throughput is kept however much the
+	// lengths of the lines vary.
+	ByteRate float64
+
+	// Schedule, if set, is a timeline of segments that is followed instead of
+	// Rate and ByteRate, so that a shape of traffic, such as a burst between
+	// idle periods, can be defined once and replayed exactly.
+	Schedule []Segment
+
+	// Width, if positive, is the exact size in bytes of every line written,
+	// including its newline, so that the sizes of files are predictable to
+	// the byte. Shorter lines are padded with spaces, and longer ones cut
+	// short.
+	Width int
+
+	// Clock is used to pace the lines. It defaults to the system clock.
+	Clock Clock
+
+	mu      sync.Mutex
+	pace    *RateLimit
+	paused  bool
+	stopped bool
+	resumed *sync.Cond
+	wake    chan struct{}
+}
+
+// Run writes n lines to w, each followed by a newline. Each line is written
+// with a separate call to Write, so that a Logger counts and rotates them as
+// it would lines from an application. With a Schedule, Run stops early if the
+// Schedule ends first, and writes until it ends if n is not positive.
+func (ld *Load) Run(w io.Writer, n int) error {
+	if len(ld.Schedule) > 0 {
+		return ld.runSchedule(w, n)
+	}
+	pace := ld.pacer()
+	var buf []byte
+	for i := 0; i < n; i++ {
+		if !pace.Bytes {
+			pace.wait(1, clockNow(ld.Clock), ld.sleep)
+			if _, stopped := ld.hold(); stopped {
+				return nil
+			}
+		}
+		buf = ld.line(buf)
+		if pace.Bytes {
+			// the line must be made before its size is known
+			pace.wait(len(buf), clockNow(ld.Clock), ld.sleep)
+			if _, stopped := ld.hold(); stopped {
+				return nil
+			}
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// line returns the next line from the Generator in buf, followed by a
+// newline and fitted to Width.
+func (ld *Load) line(buf []byte) []byte {
+	buf = append(buf[:0], ld.Generator.Line()...)
+	if ld.Width > 0 {
+		if len(buf) > ld.Width-1 {
+			buf = buf[:ld.Width-1]
+		}
+		for len(buf) < ld.Width-1 {
+			buf = append(buf, ' ')
+		}
+	}
+	return append(buf, '\n')
+}
@@ -0,0 +1,222 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWidth(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	tmpl, err := NewTemplate("{word} {word} {word}", 1)
+	require.NoError(t, err)
+	l := &Logger{Filename: logFile(dir), MaxLines: 5, Sequential: true}
+	defer l.Close()
+
+	load := &Load{Generator: tmpl, Width: 12}
+	require.NoError(t, load.Run(l, 7))
+
+	// every backup is exactly MaxLines lines of Width bytes
+	info, err := os.Stat(sequentialName(logFile(dir), 1))
+	require.NoError(t, err)
+	require.Equal(t, int64(60), info.Size())
+	b, err := ioutil.ReadFile(logFile(dir))
+	require.NoError(t, err)
+	require.Len(t, b, 24)
+	for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+		require.Len(t, line, 11)
+	}
+}
+
+func TestLoadByteRate(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(start)
+	l := &Logger{Filename: logFile(dir), MaxLines: 100, Clock: clock}
+	defer l.Close()
+
+	tmpl, err := NewTemplate("{word} {uuid}", 1)
+	require.NoError(t, err)
+	load := &Load{Generator: tmpl, ByteRate: 1000, Clock: clock}
+	require.NoError(t, load.Run(l, 50))
+	existsWithLines(logFile(dir), 50, t)
+
+	// the time taken follows the bytes written rather than the lines, less
+	// the single byte allowed at once
+	info, err := os.Stat(logFile(dir))
+	require.NoError(t, err)
+	want := float64(info.Size()-1) / 1000
+	require.InDelta(t, want, clock.Now().Sub(start).Seconds(), 0.001)
+}
+
+func TestLoadSchedule(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(start)
+	l := &Logger{Filename: logFile(dir), MaxLines: 1000, Clock: clock}
+	defer l.Close()
+
+	tmpl, err := NewTemplate("{ts} {word}", 1)
+	require.NoError(t, err)
+	tmpl.Clock = clock
+	load := &Load{Generator: tmpl, Clock: clock, Schedule: []Segment{
+		{Duration: 10 * time.Second, Rate: 10},
+		{Duration: 2 * time.Second, Rate: 100},
+		{Duration: 30 * time.Second},
+		{Duration: time.Second, Rate: 5},
+	}}
+	require.NoError(t, load.Run(l, 0))
+	existsWithLines(logFile(dir), 100+200+5, t)
+	require.Equal(t, 43*time.Second, clock.Now().Sub(start))
+
+	// a line limit ends the schedule early
+	clock = NewFakeClock(start)
+	l2 := &Logger{Filename: logFile(dir) + ".2", MaxLines: 1000, Clock: clock}
+	defer l2.Close()
+	load.Clock = clock
+	require.NoError(t, load.Run(l2, 150))
+	existsWithLines(logFile(dir)+".2", 150, t)
+	require.Equal(t, 10*time.Second+490*time.Millisecond, clock.Now().Sub(start))
+}
+
+// writeFunc calls a function on every write, as the writer of a Load.
+type writeFunc func(p []byte) (int, error)
+
+func (f writeFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestLoadSetRate(t *testing.T) {
+	start := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(start)
+	tmpl, err := NewTemplate("{word}", 1)
+	require.NoError(t, err)
+
+	load := &Load{Generator: tmpl, Rate: 10, Clock: clock}
+	lines := 0
+	w := writeFunc(func(p []byte) (int, error) {
+		lines++
+		if lines == 11 {
+			load.SetRate(100)
+		}
+		return len(p), nil
+	})
+	require.NoError(t, load.Run(w, 21))
+
+	// ten lines a tenth of a second apart, then one already due, and nine
+	// a hundredth apart
+	require.Equal(t, 1090*time.Millisecond, clock.Now().Sub(start).Round(time.Millisecond))
+}
+
+func TestLoadPauseStop(t *testing.T) {
+	tmpl, err := NewTemplate("{word}", 1)
+	require.NoError(t, err)
+
+	lines := make(chan struct{}, 100)
+	w := writeFunc(func(p []byte) (int, error) {
+		lines <- struct{}{}
+		return len(p), nil
+	})
+	load := &Load{Generator: tmpl}
+	load.Pause()
+	done := make(chan error)
+	go func() { done <- load.Run(w, 3) }()
+
+	// nothing is written while paused
+	select {
+	case <-lines:
+		t.Fatal("line written while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	load.Resume()
+	require.NoError(t, <-done)
+	require.Len(t, lines, 3)
+
+	// a stopped Load writes nothing, even when paused
+	load.Pause()
+	go func() { done <- load.Run(w, 3) }()
+	load.Stop()
+	require.NoError(t, <-done)
+	require.Len(t, lines, 3)
+}
+
+func TestLoadSchedulePause(t *testing.T) {
+	start := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(start)
+	tmpl, err := NewTemplate("{word}", 1)
+	require.NoError(t, err)
+
+	load := &Load{Generator: tmpl, Clock: clock, Schedule: []Segment{
+		{Duration: time.Second, Rate: 10},
+	}}
+	lines := 0
+	w := writeFunc(func(p []byte) (int, error) {
+		lines++
+		if lines == 5 {
+			// pause for a minute of the clock
+			load.Pause()
+			go func() {
+				clock.Advance(time.Minute)
+				load.Resume()
+			}()
+		}
+		return len(p), nil
+	})
+	require.NoError(t, load.Run(w, 0))
+
+	// the pause delays the rest of the schedule rather than skipping it
+	require.Equal(t, 10, lines)
+	require.Equal(t, time.Minute+time.Second, clock.Now().Sub(start))
+}
+
+func TestLoadStopWhileIdle(t *testing.T) {
+	tmpl, err := NewTemplate("{word}", 1)
+	require.NoError(t, err)
+
+	lines := make(chan struct{}, 100)
+	w := writeFunc(func(p []byte) (int, error) {
+		lines <- struct{}{}
+		return len(p), nil
+	})
+
+	// a Schedule stopped in an idle segment, having been paused in it
+	load := &Load{Generator: tmpl, Schedule: []Segment{
+		{Duration: 10 * time.Millisecond, Rate: 100},
+		{Duration: time.Hour},
+		{Duration: time.Second, Rate: 100},
+	}}
+	done := make(chan error)
+	go func() { done <- load.Run(w, 0) }()
+	time.Sleep(50 * time.Millisecond)
+	load.Pause()
+	load.Stop()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Stop")
+	}
+	require.Len(t, lines, 1)
+
+	// a slow Rate stopped while waiting for its next line
+	load = &Load{Generator: tmpl, Rate: 0.001}
+	go func() { done <- load.Run(w, 2) }()
+	<-lines
+	load.Stop()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after Stop")
+	}
+	require.Empty(t, lines)
+}
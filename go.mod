@@ -3,6 +3,8 @@ module github.com/observiq/nanojack
 go 1.14
 
 require (
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/sys v0.0.0-20191005200804-aed5e4c7ecf9
 	gopkg.in/yaml.v2 v2.3.0
 )
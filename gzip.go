@@ -0,0 +1,34 @@
+package nanojack
+
+import (
+	"compress/gzip"
+)
+
+// rawWriter writes uncompressed to a Logger's active log file.
+type rawWriter struct {
+	l *Logger
+}
+
+func (w rawWriter) Write(p []byte) (int, error) {
+	return w.l.writeRaw(p)
+}
+
+// writeGzip compresses p into the active log file's gzip stream, starting the
+// stream if necessary.
+func (l *Logger) writeGzip(p []byte) (int, error) {
+	if l.gz == nil {
+		l.gz = gzip.NewWriter(rawWriter{l})
+	}
+	return l.gz.Write(p)
+}
+
+// finishGzip writes out the end of the active log file's gzip stream, if one
+// has been started.
+func (l *Logger) finishGzip() error {
+	if l.gz == nil {
+		return nil
+	}
+	err := l.gz.Close()
+	l.gz = nil
+	return err
+}
@@ -0,0 +1,93 @@
+package nanojack
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// gunzipFile returns the decompressed contents of the gzip file at path.
+func gunzipFile(path string, t testing.TB) string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestGzip(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "foobar.log.gz")
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 2,
+		Gzip:     true,
+	}
+	defer l.Close()
+
+	for _, line := range []string{"one\n", "two\n"} {
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	// flushing makes the lines so far readable from the unfinished stream
+	require.NoError(t, l.Flush())
+	f, err := os.Open(filename)
+	require.NoError(t, err)
+	r, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	b := make([]byte, len("one\ntwo\n"))
+	_, err = r.Read(b)
+	require.NoError(t, err)
+	require.Equal(t, "one\ntwo\n", string(b))
+	f.Close()
+
+	// rotation finishes the stream and starts a new one
+	newFakeTime(time.Second)
+	_, err = l.Write([]byte("three\n"))
+	require.NoError(t, err)
+	backup := filepath.Join(dir, "foobar.log-"+fakeTime().UTC().Format(backupTimeFormat)+".gz")
+	require.Equal(t, "one\ntwo\n", gunzipFile(backup, t))
+
+	require.NoError(t, l.Close())
+	require.Equal(t, "three\n", gunzipFile(filename, t))
+	fileCount(dir, 2, t)
+}
+
+func TestGzipRotatesExisting(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "foobar.log.gz")
+	l := &Logger{
+		Filename: filename,
+		Gzip:     true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	l.Kill()
+
+	// the killed file's stream was never finished, so it is moved aside
+	newFakeTime(time.Second)
+	require.NoError(t, l.Recover())
+	_, err = l.Write([]byte("two\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	require.Equal(t, "two\n", gunzipFile(filename, t))
+	fileCount(dir, 2, t)
+}
@@ -0,0 +1,55 @@
+package nanojack
+
+// Health is a cheap summary of whether a Logger is in a good state.
+type Health struct {
+	// Open is set if the Logger has an active log file open.
+	Open bool
+
+	// Writable is set if a write would go to the open file straight away,
+	// rather than failing or waiting because the Logger is killed or paused.
+	Writable bool
+
+	// SameFile is set if the Logger's Filename refers to the file it has
+	// open, which is not the case if the file was deleted or renamed by
+	// another process.
+	SameFile bool
+
+	// RotateErr is the error from the last rotation, if it failed.
+	RotateErr error
+
+	// CleanupErr is an error from cleaning up old log files after the last
+	// rotation, if there was one. Since cleanup may happen in the
+	// background, it may not be set until some time after the rotation.
+	CleanupErr error
+}
+
+// OK reports whether the Logger is open, writable, writing to the expected
+// file, and did not fail in its last rotation or cleanup.
+func (h Health) OK() bool {
+	return h.Open && h.Writable && h.SameFile && h.RotateErr == nil && h.CleanupErr == nil
+}
+
+// Health reports the state of the Logger. It does not open the log file if
+// it is not already open.
+func (l *Logger) Health() Health {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h := Health{
+		Open:       l.file != nil,
+		RotateErr:  l.rotateErr,
+		CleanupErr: l.cleanupErr,
+	}
+	if !h.Open {
+		return h
+	}
+	h.Writable = !l.killed && !l.paused
+
+	open, err := l.file.Stat()
+	if err != nil {
+		return h
+	}
+	current, err := l.fs().Stat(l.filename())
+	h.SameFile = err == nil && sameFile(open, current)
+	return h
+}
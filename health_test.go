@@ -0,0 +1,50 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:  filename,
+		Mechanism: MechanismCopyTruncate,
+	}
+	defer l.Close()
+
+	h := l.Health()
+	require.False(t, h.Open)
+	require.False(t, h.OK())
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.True(t, l.Health().OK())
+
+	l.Pause()
+	h = l.Health()
+	require.False(t, h.Writable)
+	require.True(t, h.SameFile)
+	l.Resume()
+
+	require.NoError(t, os.Rename(filename, filename+".moved"))
+	h = l.Health()
+	require.True(t, h.Writable)
+	require.False(t, h.SameFile)
+	require.NoError(t, os.Rename(filename+".moved", filename))
+
+	// a directory in the way of the backup fails rotation
+	newFakeTime(time.Second)
+	require.NoError(t, os.Mkdir(backupFile(dir), 0755))
+	require.Error(t, l.Rotate())
+	h = l.Health()
+	require.Error(t, h.RotateErr)
+	require.False(t, h.OK())
+}
@@ -0,0 +1,80 @@
+package nanojack
+
+// Inspector applies nanojack's naming and parsing of log files to a
+// directory produced by something else, such as a customer's real log
+// directory, so that verification tooling can reuse it. An Inspector never
+// creates, writes, renames or deletes a file. Its fields have the meanings
+// of the Logger fields of the same names.
+type Inspector struct {
+	Filename   string   `json:"filename" yaml:"filename"`
+	Sequential bool     `json:"sequential" yaml:"sequential"`
+	Compress   bool     `json:"compress" yaml:"compress"`
+	Gzip       bool     `json:"gzip" yaml:"gzip"`
+	Encoding   Encoding `json:"encoding" yaml:"encoding"`
+	Namer      Namer    `json:"-" yaml:"-"`
+
+	// FileSystem is where the files are found, the real file system if it
+	// is nil.
+	FileSystem FileSystem `json:"-" yaml:"-"`
+}
+
+// Inspection describes a log family as found by an Inspector.
+type Inspection struct {
+	// Active describes the log file itself. Its Timestamp and Sequence are
+	// zero, and it is the zero BackupInfo, apart from Path, if the file does
+	// not exist.
+	Active BackupInfo `json:"active"`
+
+	// Backups describes the backups, newest first.
+	Backups []BackupInfo `json:"backups"`
+
+	// Gaps lists the sequence numbers missing from the run of sequential
+	// backups, in order. It is always empty for timestamped backups.
+	Gaps []int `json:"gaps"`
+
+	// TotalSize and TotalLines sum the sizes and lines of the log file and
+	// all its backups.
+	TotalSize  int64 `json:"totalsize"`
+	TotalLines int64 `json:"totallines"`
+}
+
+// Inspect lists the log file named by Filename and its backups.
+func (i Inspector) Inspect() (Inspection, error) {
+	l := &Logger{
+		Filename:   i.Filename,
+		Sequential: i.Sequential,
+		Compress:   i.Compress,
+		Gzip:       i.Gzip,
+		Encoding:   i.Encoding,
+		Namer:      i.Namer,
+		FileSystem: i.FileSystem,
+	}
+
+	var in Inspection
+	backups, err := l.Backups()
+	if err != nil {
+		return in, err
+	}
+	in.Backups = backups
+
+	in.Active.Path = l.filename()
+	if info, err := l.fs().Stat(in.Active.Path); err == nil {
+		in.Active.Size = info.Size()
+		if in.Active.Lines, err = l.backupLines(in.Active.Path); err != nil {
+			return in, err
+		}
+	}
+
+	next := 1
+	for _, b := range append([]BackupInfo{in.Active}, backups...) {
+		in.TotalSize += b.Size
+		in.TotalLines += b.Lines
+		for ; b.Sequence > next; next++ {
+			in.Gaps = append(in.Gaps, next)
+		}
+		if b.Sequence > 0 {
+			next = b.Sequence + 1
+		}
+	}
+	return in, nil
+}
@@ -0,0 +1,67 @@
+package nanojack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	// a sequential family with backup 2 missing, as left by something else
+	filename := logFile(dir)
+	require.NoError(t, ioutil.WriteFile(filename, []byte("a\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(sequentialName(filename, 1), []byte("a\nb\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(sequentialName(filename, 3), []byte("a\nb\nc\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(sequentialName(filename, 5), nil, 0644))
+	before := dirState(t, dir)
+
+	in, err := Inspector{Filename: filename, Sequential: true}.Inspect()
+	require.NoError(t, err)
+	require.Equal(t, BackupInfo{Path: filename, Size: 2, Lines: 1}, in.Active)
+	require.Len(t, in.Backups, 3)
+	require.Equal(t, 3, in.Backups[1].Sequence)
+	require.Equal(t, []int{2, 4}, in.Gaps)
+	require.Equal(t, int64(12), in.TotalSize)
+	require.Equal(t, int64(6), in.TotalLines)
+
+	// nothing was touched
+	require.Equal(t, before, dirState(t, dir))
+}
+
+// dirState returns the size and modification time of each file in dir.
+func dirState(t *testing.T, dir string) map[string]string {
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	state := make(map[string]string, len(infos))
+	for _, info := range infos {
+		state[info.Name()] = fmt.Sprint(info.Size(), info.ModTime())
+	}
+	return state
+}
+
+func TestInspectTimestamped(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	older := timestampedName(filename, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	newer := timestampedName(filename, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, ioutil.WriteFile(older, []byte("a\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(newer, []byte("a\n"), 0644))
+
+	in, err := Inspector{Filename: filename}.Inspect()
+	require.NoError(t, err)
+	require.Equal(t, BackupInfo{Path: filename}, in.Active)
+	require.Len(t, in.Backups, 2)
+	require.Equal(t, newer, in.Backups[0].Path)
+	require.Equal(t, older, in.Backups[1].Path)
+	require.Empty(t, in.Gaps)
+	fileCount(dir, 2, t)
+}
@@ -0,0 +1,51 @@
+package nanojack
+
+import (
+	"time"
+)
+
+// Tick rotates the active log file if RotateInterval has passed since it was
+// started, unless the Logger is paused. It is called automatically when the
+// Logger uses the system clock, but must be called after moving any other
+// Clock forward.
+func (l *Logger) Tick() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.acquire(); err != nil {
+		return err
+	}
+	defer l.release()
+	return l.tick()
+}
+
+// tick rotates the active log file if it is due by RotateInterval.
+func (l *Logger) tick() error {
+	if l.RotateInterval <= 0 || l.file == nil || l.paused {
+		return nil
+	}
+	if age := l.now().Sub(l.opened); age < l.RotateInterval {
+		return nil
+	}
+	l.debug("debug", "rotating", "reason", "interval")
+	return l.rotate(ReasonInterval)
+}
+
+// startInterval notes that a new active file has been started, and sets the
+// timer to rotate it if the Logger uses the system clock.
+func (l *Logger) startInterval() {
+	l.opened = l.now()
+	l.stopInterval()
+	if l.RotateInterval <= 0 || l.Clock != nil {
+		return
+	}
+	// a failure is reported by the rotation's event and by Health
+	l.interval = time.AfterFunc(l.RotateInterval, func() { _ = l.Tick() })
+}
+
+// stopInterval stops the rotation timer, if there is one.
+func (l *Logger) stopInterval() {
+	if l.interval != nil {
+		l.interval.Stop()
+		l.interval = nil
+	}
+}
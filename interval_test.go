@@ -0,0 +1,67 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTick(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clock := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		Clock:          clock,
+		RotateInterval: time.Hour,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	clock.Advance(59 * time.Minute)
+	require.NoError(t, l.Tick())
+	fileCount(dir, 1, t)
+
+	// the rotation happens as soon as Tick is called, without a write
+	clock.Advance(time.Minute)
+	require.NoError(t, l.Tick())
+	fileCount(dir, 2, t)
+	existsWithLines(filename, 0, t)
+
+	// a write also rotates once the interval has passed
+	clock.Advance(time.Hour)
+	_, err = l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	fileCount(dir, 3, t)
+	existsWithLines(filename, 1, t)
+}
+
+func TestRotateIntervalTimer(t *testing.T) {
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:       logFile(dir),
+		RotateInterval: 50 * time.Millisecond,
+		Events:         events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	// the system clock's timer rotates the idle file
+	e := nextEvent(t, events)
+	require.Equal(t, EventRotate, e.Type)
+	require.NoError(t, e.Err)
+}
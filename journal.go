@@ -0,0 +1,78 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// journalRecord is the JSON form of an Event written to the JournalFile.
+type journalRecord struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Filename string    `json:"filename"`
+	Path     string    `json:"path,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Output   string    `json:"output,omitempty"`
+	ExitCode int       `json:"exitCode,omitempty"`
+	Op       string    `json:"op,omitempty"`
+	DryRun   bool      `json:"dryRun,omitempty"`
+	Lines    int64     `json:"lines,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// record appends e to the JournalFile, opening it if necessary. Failures are
+// ignored, since there is nowhere left to report them.
+func (l *Logger) record(e Event) {
+	if l.JournalFile == "" {
+		return
+	}
+
+	r := journalRecord{
+		Time:     e.Time,
+		Type:     e.Type.String(),
+		Filename: e.Filename,
+		Path:     e.Path,
+		Output:   e.Output,
+		ExitCode: e.ExitCode,
+		Op:       e.Op,
+		DryRun:   e.DryRun,
+		Lines:    e.Lines,
+		Reason:   e.Reason,
+	}
+	if e.Err != nil {
+		r.Error = e.Err.Error()
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	l.journalMu.Lock()
+	defer l.journalMu.Unlock()
+	if l.journal == nil {
+		if err := l.fs().MkdirAll(filepath.Dir(l.JournalFile), 0744); err != nil {
+			return
+		}
+		f, err := l.fs().OpenFile(l.JournalFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		l.journal = f
+	}
+	_, _ = l.journal.Write(b)
+}
+
+// closeJournal closes the JournalFile if it is open.
+func (l *Logger) closeJournal() error {
+	l.journalMu.Lock()
+	defer l.journalMu.Unlock()
+	if l.journal == nil {
+		return nil
+	}
+	err := l.journal.Close()
+	l.journal = nil
+	return err
+}
@@ -0,0 +1,68 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	journal := filepath.Join(dir, "journal", "nanojack.jsonl")
+	l := &Logger{
+		Filename:    filename,
+		MaxBackups:  1,
+		JournalFile: journal,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	newFakeTime(time.Second)
+	_, err = l.RotateSync()
+	require.NoError(t, err)
+	first := backupFile(dir)
+
+	newFakeTime(time.Second)
+	_, err = l.RotateSync()
+	require.NoError(t, err)
+	second := backupFile(dir)
+	require.NoError(t, l.Close())
+
+	b, err := ioutil.ReadFile(journal)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 6)
+
+	var records []journalRecord
+	for _, line := range lines {
+		var r journalRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		require.Equal(t, filename, r.Filename)
+		records = append(records, r)
+	}
+	for i, want := range []struct{ typ, path, reason string }{
+		{"rotate", first, "manual"},
+		{"retain", first, "kept"},
+		{"rotate", second, "manual"},
+		{"retain", second, "kept"},
+		{"retain", first, "count"},
+		{"remove", first, ""},
+	} {
+		require.Equal(t, want.typ, records[i].Type)
+		require.Equal(t, want.path, records[i].Path)
+		require.Equal(t, want.reason, records[i].Reason)
+	}
+	require.True(t, records[5].Time.Equal(fakeTime()))
+}
@@ -0,0 +1,169 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// JSONType is the type of a field generated by a JSONLog.
+type JSONType string
+
+const (
+	// JSONString fields hold strings.
+	JSONString JSONType = "string"
+
+	// JSONInt fields hold integers.
+	JSONInt JSONType = "int"
+
+	// JSONFloat fields hold floating point numbers.
+	JSONFloat JSONType = "float"
+
+	// JSONBool fields hold true or false.
+	JSONBool JSONType = "bool"
+
+	// JSONTimestamp fields hold the current time as an RFC 3339 string.
+	JSONTimestamp JSONType = "timestamp"
+
+	// JSONObject fields hold an object made of the field's own Fields.
+	JSONObject JSONType = "object"
+)
+
+// JSONField describes one field of the lines generated by a JSONLog.
+type JSONField struct {
+	// Name is the key of the field.
+	Name string `json:"name" yaml:"name"`
+
+	// Type is the type of the field's values.
+	Type JSONType `json:"type" yaml:"type"`
+
+	// Cardinality is the number of distinct values the field takes. A zero
+	// Cardinality leaves the values effectively unbounded. It is ignored by
+	// bool, timestamp and object fields.
+	Cardinality int `json:"cardinality" yaml:"cardinality"`
+
+	// Fields are the fields of an object field.
+	Fields []JSONField `json:"fields" yaml:"fields"`
+
+	// Depth nests an object field's Fields that many levels deep, each level
+	// under a key of the same Name, so that {"a":{"x":1}} with a Depth of 2
+	// becomes {"a":{"a":{"x":1}}}. A Depth of 0 or 1 does not nest.
+	Depth int `json:"depth" yaml:"depth"`
+}
+
+// JSONLog is a Generator of JSON objects, one per line, shaped by a schema.
+// Values are drawn from a random source, so the same schema and seed always
+// produce the same lines, except for timestamps, which are the current time.
+type JSONLog struct {
+	// Clock is the source of the time used by timestamp fields. It defaults
+	// to the system clock.
+	Clock Clock
+
+	rng    *rand.Rand
+	fields []jsonField
+	buf    []byte
+}
+
+// jsonField is a JSONField with its key encoded ahead of time.
+type jsonField struct {
+	JSONField
+	key    []byte
+	fields []jsonField
+}
+
+// NewJSONLog returns a JSONLog generating lines with the given fields, whose
+// values are drawn from a source seeded with seed.
+func NewJSONLog(fields []JSONField, seed int64) (*JSONLog, error) {
+	compiled, err := compileJSONFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLog{rng: rand.New(rand.NewSource(seed)), fields: compiled}, nil
+}
+
+// compileJSONFields checks fields and encodes their keys.
+func compileJSONFields(fields []JSONField) ([]jsonField, error) {
+	out := make([]jsonField, 0, len(fields))
+	for _, f := range fields {
+		key, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON field name %q: %s", f.Name, err)
+		}
+		c := jsonField{JSONField: f, key: append(key, ':')}
+		switch f.Type {
+		case JSONString, JSONInt, JSONFloat, JSONBool, JSONTimestamp:
+		case JSONObject:
+			if c.fields, err = compileJSONFields(f.Fields); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid type %q for JSON field %q", f.Type, f.Name)
+		}
+		if f.Cardinality < 0 {
+			return nil, fmt.Errorf("invalid cardinality %d for JSON field %q", f.Cardinality, f.Name)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// Line implements Generator.
+func (j *JSONLog) Line() []byte {
+	j.buf = j.appendObject(j.buf[:0], j.fields)
+	return j.buf
+}
+
+// appendObject appends an object made of fields to b.
+func (j *JSONLog) appendObject(b []byte, fields []jsonField) []byte {
+	b = append(b, '{')
+	for i, f := range fields {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, f.key...)
+		b = j.appendValue(b, f)
+	}
+	return append(b, '}')
+}
+
+// appendValue appends a value of field f to b.
+func (j *JSONLog) appendValue(b []byte, f jsonField) []byte {
+	switch f.Type {
+	case JSONString:
+		v := j.value(f)
+		b = append(b, '"')
+		b = append(b, words[v%int64(len(words))]...)
+		b = append(b, '-')
+		b = strconv.AppendInt(b, v, 10)
+		return append(b, '"')
+	case JSONInt:
+		return strconv.AppendInt(b, j.value(f), 10)
+	case JSONFloat:
+		return strconv.AppendFloat(b, float64(j.value(f))/100, 'f', -1, 64)
+	case JSONBool:
+		return strconv.AppendBool(b, j.rng.Intn(2) == 1)
+	case JSONTimestamp:
+		b = append(b, '"')
+		b = clockNow(j.Clock).UTC().AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"')
+	}
+	for i := 1; i < f.Depth; i++ {
+		b = append(b, '{')
+		b = append(b, f.key...)
+	}
+	b = j.appendObject(b, f.fields)
+	for i := 1; i < f.Depth; i++ {
+		b = append(b, '}')
+	}
+	return b
+}
+
+// value returns a random number limited to the cardinality of f.
+func (j *JSONLog) value(f jsonField) int64 {
+	if f.Cardinality > 0 {
+		return int64(j.rng.Intn(f.Cardinality))
+	}
+	return int64(j.rng.Intn(1 << 30))
+}
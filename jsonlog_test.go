@@ -0,0 +1,69 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLogSchema(t *testing.T) {
+	clock := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC))
+	j, err := NewJSONLog([]JSONField{
+		{Name: "time", Type: JSONTimestamp},
+		{Name: "level", Type: JSONString, Cardinality: 3},
+		{Name: "status", Type: JSONInt, Cardinality: 5},
+		{Name: "latency", Type: JSONFloat},
+		{Name: "cached", Type: JSONBool},
+		{Name: "http", Type: JSONObject, Depth: 3, Fields: []JSONField{
+			{Name: "path", Type: JSONString},
+		}},
+	}, 11)
+	require.NoError(t, err)
+	j.Clock = clock
+
+	levels := map[string]bool{}
+	statuses := map[float64]bool{}
+	for i := 0; i < 200; i++ {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(j.Line(), &line))
+		require.Len(t, line, 6)
+		require.Equal(t, "2020-10-20T15:04:05Z", line["time"])
+		levels[line["level"].(string)] = true
+		statuses[line["status"].(float64)] = true
+		require.IsType(t, float64(0), line["latency"])
+		require.IsType(t, true, line["cached"])
+
+		http := line["http"].(map[string]interface{})
+		http = http["http"].(map[string]interface{})
+		http = http["http"].(map[string]interface{})
+		require.IsType(t, "", http["path"])
+	}
+	require.Len(t, levels, 3)
+	require.Len(t, statuses, 5)
+}
+
+func TestJSONLogDeterministic(t *testing.T) {
+	schema := []JSONField{{Name: "msg", Type: JSONString}, {Name: "n", Type: JSONInt}}
+	lines := func(seed int64) []string {
+		j, err := NewJSONLog(schema, seed)
+		require.NoError(t, err)
+		var out []string
+		for i := 0; i < 10; i++ {
+			out = append(out, string(j.Line()))
+		}
+		return out
+	}
+	require.Equal(t, lines(1), lines(1))
+	require.NotEqual(t, lines(1), lines(2))
+}
+
+func TestJSONLogInvalid(t *testing.T) {
+	_, err := NewJSONLog([]JSONField{{Name: "x", Type: "date"}}, 0)
+	require.Error(t, err)
+	_, err = NewJSONLog([]JSONField{{Name: "x", Type: JSONInt, Cardinality: -1}}, 0)
+	require.Error(t, err)
+	_, err = NewJSONLog([]JSONField{{Name: "o", Type: JSONObject, Fields: []JSONField{{Name: "y"}}}}, 0)
+	require.Error(t, err)
+}
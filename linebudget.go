@@ -0,0 +1,64 @@
+package nanojack
+
+import (
+	"errors"
+)
+
+// ErrLineBudget is returned by writes once MaxTotalLines lines have been
+// written and LineBudget is LineBudgetStop.
+var ErrLineBudget = errors.New("nanojack: line budget exhausted")
+
+// LineBudgetPolicy selects what a Logger does once it has written
+// MaxTotalLines lines.
+type LineBudgetPolicy int
+
+const (
+	// LineBudgetStop fails every further write with ErrLineBudget, so that
+	// exactly MaxTotalLines lines are produced. This is the default.
+	LineBudgetStop LineBudgetPolicy = iota
+
+	// LineBudgetWrap deletes the backups and truncates the active log file,
+	// and starts the budget over, so that the log family never holds more
+	// than MaxTotalLines lines. Pinned backups are kept.
+	LineBudgetWrap
+)
+
+// spendLine applies MaxTotalLines to a line that is about to be written.
+func (l *Logger) spendLine() error {
+	if l.MaxTotalLines <= 0 || l.totalLines < l.MaxTotalLines {
+		return nil
+	}
+	if l.LineBudget != LineBudgetWrap {
+		return ErrLineBudget
+	}
+	return l.wrap()
+}
+
+// wrap empties the log family, so that writing can begin again from nothing.
+func (l *Logger) wrap() error {
+	l.debug("debug", "wrapping", "reason", "line budget", "lines", l.totalLines)
+	if err := l.close(); err != nil {
+		return err
+	}
+
+	backups, err := l.backupFiles()
+	if err != nil {
+		return err
+	}
+	for _, path := range backups {
+		if l.isPinned(path) || !l.approveDelete(path) {
+			continue
+		}
+		l.expect(opRemove, path)
+		l.emit(Event{Type: EventRemove, Filename: l.filename(), Path: path})
+		l.remove(path)
+	}
+
+	f, err := truncateFile(l.fs(), l.filename())
+	if err != nil {
+		return err
+	}
+	l.setFile(f, 0, 0)
+	l.totalLines = 0
+	return l.prepareFile()
+}
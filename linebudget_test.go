@@ -0,0 +1,66 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineBudget(t *testing.T) {
+	t.Run("Stop", func(t *testing.T) {
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{
+			Filename:      logFile(dir),
+			MaxLines:      2,
+			MaxTotalLines: 5,
+		}
+		defer l.Close()
+
+		for i := 0; i < 5; i++ {
+			newFakeTime(time.Second)
+			_, err := l.Write([]byte("boo!\n"))
+			require.NoError(t, err)
+		}
+		_, err := l.Write([]byte("boo!\n"))
+		require.Equal(t, ErrLineBudget, err)
+
+		// two backups of two lines, and one line in the active file
+		fileCount(dir, 3, t)
+		existsWithLines(logFile(dir), 1, t)
+		stats, err := l.Stats()
+		require.NoError(t, err)
+		require.Equal(t, int64(5), stats.TotalLines)
+	})
+
+	t.Run("Wrap", func(t *testing.T) {
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{
+			Filename:      logFile(dir),
+			MaxLines:      2,
+			MaxTotalLines: 3,
+			LineBudget:    LineBudgetWrap,
+		}
+		defer l.Close()
+
+		for i := 0; i < 4; i++ {
+			newFakeTime(time.Second)
+			_, err := l.Write([]byte("boo!\n"))
+			require.NoError(t, err)
+		}
+
+		// the fourth line started the family over
+		fileCount(dir, 1, t)
+		existsWithLines(logFile(dir), 1, t)
+		stats, err := l.Stats()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), stats.TotalLines)
+	})
+}
@@ -0,0 +1,122 @@
+package nanojack
+
+import (
+	"sync"
+	"time"
+)
+
+// SetRate changes the rate of a Load that may be running, in lines per
+// second, or in bytes per second if ByteRate is in use. A zero rate writes
+// lines as fast as the writer accepts them. It has no effect on a Schedule.
+func (ld *Load) SetRate(rate float64) {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	if ld.ByteRate > 0 {
+		ld.ByteRate = rate
+	} else {
+		ld.Rate = rate
+	}
+	if ld.pace != nil {
+		ld.pace.SetLimit(rate)
+	}
+}
+
+// Pause stops a Load from writing lines until Resume is called. A line that
+// is already being written is finished. Pausing a Schedule delays the rest
+// of it by the time spent paused.
+func (ld *Load) Pause() {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.paused = true
+	ld.interrupt()
+}
+
+// Resume allows a paused Load to write lines again.
+func (ld *Load) Resume() {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.paused = false
+	if ld.resumed != nil {
+		ld.resumed.Broadcast()
+	}
+}
+
+// Stop ends the Load: Run returns without error once the line being written,
+// if any, is finished, and any later Run returns at once. A paused Load is
+// stopped as well.
+func (ld *Load) Stop() {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.stopped = true
+	if ld.resumed != nil {
+		ld.resumed.Broadcast()
+	}
+	ld.interrupt()
+}
+
+// interrupt cuts short the sleep of a running Load, if any. It must be
+// called with the Load's lock held.
+func (ld *Load) interrupt() {
+	if ld.wake != nil {
+		close(ld.wake)
+		ld.wake = nil
+	}
+}
+
+// sleep waits for d on the Clock, but no longer than until the Load is
+// paused or stopped. A Clock that controls how it waits, such as a
+// FakeClock, is always waited on in full.
+func (ld *Load) sleep(d time.Duration) {
+	if _, ok := ld.Clock.(sleeper); ok {
+		clockSleep(ld.Clock, d)
+		return
+	}
+
+	ld.mu.Lock()
+	if ld.paused || ld.stopped {
+		ld.mu.Unlock()
+		return
+	}
+	if ld.wake == nil {
+		ld.wake = make(chan struct{})
+	}
+	wake := ld.wake
+	ld.mu.Unlock()
+
+	done, t := clockAfter(ld.Clock, d)
+	defer t.Stop()
+	select {
+	case <-done:
+	case <-wake:
+	}
+}
+
+// pacer returns the RateLimit that paces the lines written by Run, and keeps
+// it so that SetRate can change it.
+func (ld *Load) pacer() *RateLimit {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	ld.pace = &RateLimit{Limit: ld.Rate, Burst: 1}
+	if ld.ByteRate > 0 {
+		ld.pace = &RateLimit{Limit: ld.ByteRate, Burst: 1, Bytes: true}
+	}
+	return ld.pace
+}
+
+// hold blocks while the Load is paused, and returns how long it was paused
+// for, by the Clock, and whether the Load has been stopped.
+func (ld *Load) hold() (time.Duration, bool) {
+	ld.mu.Lock()
+	defer ld.mu.Unlock()
+	if !ld.paused || ld.stopped {
+		return 0, ld.stopped
+	}
+	began := clockNow(ld.Clock)
+	if ld.resumed == nil {
+		ld.resumed = sync.NewCond(&ld.mu)
+	}
+	for ld.paused && !ld.stopped {
+		ld.resumed.Wait()
+	}
+	return clockNow(ld.Clock).Sub(began), ld.stopped
+}
@@ -0,0 +1,141 @@
+package nanojack
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrLocked is returned when Lock is LockFailFast and another process holds
+// the lock for the same log file.
+var ErrLocked = errors.New("nanojack: log file is locked by another process")
+
+// LockMode selects how a Logger coordinates with other processes writing to
+// the same log file. Coordination uses an advisory lock on a file next to the
+// log file, named after it with a ".lock" suffix, so it only protects
+// against other processes that use the same mechanism.
+type LockMode int
+
+const (
+	// LockNone disables coordination. This is the default.
+	LockNone LockMode = iota
+
+	// LockBlock takes the lock when the log file is first opened and holds
+	// it until Close. A second process waits until the first has closed.
+	LockBlock
+
+	// LockFailFast takes the lock when the log file is first opened and
+	// holds it until Close. A second process fails with ErrLocked.
+	LockFailFast
+
+	// LockShared allows several processes to write the same log file. The
+	// lock is held for the duration of every write and rotation, and the
+	// Logger resynchronizes with the file on disk each time it takes the
+	// lock, so that exactly one process rotates when the file is full. This
+	// requires recounting the lines of the file on every write.
+	LockShared
+)
+
+// acquire takes the inter-process lock as required by Lock. It must be paired
+// with a call to release once the current operation is complete.
+func (l *Logger) acquire() error {
+	if l.Lock == LockNone {
+		return nil
+	}
+
+	if l.lockf == nil {
+		if err := l.fs().MkdirAll(l.dir(), 0744); err != nil {
+			return err
+		}
+		lf, err := l.fs().OpenFile(l.filename()+".lock", os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return err
+		}
+		f, err := osFile(lf, "Lock")
+		if err != nil {
+			lf.Close()
+			return err
+		}
+		if l.Lock != LockShared {
+			if err := lockFile(f, l.Lock == LockBlock); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		l.lockf = f
+	}
+
+	if l.Lock != LockShared {
+		return nil
+	}
+	if err := lockFile(l.lockf, true); err != nil {
+		return err
+	}
+	if err := l.resync(); err != nil {
+		_ = unlockFile(l.lockf)
+		return err
+	}
+	return nil
+}
+
+// release releases a lock taken for the current operation by acquire.
+func (l *Logger) release() {
+	if l.Lock == LockShared && l.lockf != nil {
+		_ = unlockFile(l.lockf)
+	}
+}
+
+// unlock releases and closes the lock file, if any.
+func (l *Logger) unlock() error {
+	if l.lockf == nil {
+		return nil
+	}
+	err := l.lockf.Close()
+	l.lockf = nil
+	return err
+}
+
+// resync brings the Logger up to date with changes made to the log file by
+// other processes since it last held the lock.
+func (l *Logger) resync() error {
+	if l.file == nil {
+		return nil
+	}
+
+	name := l.filename()
+	open, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	current, err := l.fs().Stat(name)
+	if os.IsNotExist(err) {
+		// another process has rotated the file and not yet created the new
+		// one; let the normal open logic create it.
+		return l.close()
+	}
+	if err != nil {
+		return err
+	}
+
+	if !sameFile(open, current) {
+		// another process has rotated the file; open the new one just as
+		// this process would have, rotating it if it is already full.
+		if err := l.close(); err != nil {
+			return err
+		}
+		return l.openExistingOrNew()
+	}
+
+	lines, err := l.countLines(name)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	l.setFile(l.file, current.Size(), lines)
+	if l.DirectIO {
+		return l.loadTail()
+	}
+	return nil
+}
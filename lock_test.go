@@ -0,0 +1,154 @@
+package nanojack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFailFast(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l1 := &Logger{Filename: logFile(dir), Lock: LockFailFast}
+	defer l1.Close()
+	l2 := &Logger{Filename: logFile(dir), Lock: LockFailFast}
+	defer l2.Close()
+
+	b := []byte("boo!\n")
+	_, err := l1.Write(b)
+	require.NoError(t, err)
+
+	n, err := l2.Write(b)
+	require.Equal(t, ErrLocked, err)
+	require.Equal(t, 0, n)
+
+	require.NoError(t, l1.Close())
+
+	_, err = l2.Write(b)
+	require.NoError(t, err)
+	existsWithLines(logFile(dir), 2, t)
+}
+
+func TestLockBlock(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l1 := &Logger{Filename: logFile(dir), Lock: LockBlock}
+	defer l1.Close()
+	l2 := &Logger{Filename: logFile(dir), Lock: LockBlock}
+	defer l2.Close()
+
+	b := []byte("boo!\n")
+	_, err := l1.Write(b)
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := l2.Write(b)
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		require.FailNow(t, "second logger did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, l1.Close())
+	require.NoError(t, <-done)
+	existsWithLines(logFile(dir), 2, t)
+}
+
+func TestLockShared(t *testing.T) {
+	t.Run("MoveCreate", testLockShared(t, false))
+	t.Run("CopyTruncate", testLockShared(t, true))
+}
+
+func testLockShared(t *testing.T, copyTruncate bool) func(t *testing.T) {
+	return func(t *testing.T) {
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		newLogger := func() *Logger {
+			return &Logger{
+				Filename:     logFile(dir),
+				MaxLines:     4,
+				CopyTruncate: copyTruncate,
+				Lock:         LockShared,
+			}
+		}
+		l1 := newLogger()
+		defer l1.Close()
+		l2 := newLogger()
+		defer l2.Close()
+
+		b := []byte("a\n")
+		for i := 0; i < 5; i++ {
+			newFakeTime(time.Second)
+			_, err := l1.Write(b)
+			require.NoError(t, err)
+			_, err = l2.Write(b)
+			require.NoError(t, err)
+		}
+
+		// exactly one of the loggers rotated after the fourth line, and again
+		// after the eighth.
+		existsWithLines(logFile(dir), 2, t)
+		files, err := l1.oldLogFiles()
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		for _, f := range files {
+			existsWithLines(filepath.Join(dir, f.Name()), 4, t)
+		}
+
+		// the active file, two backups, and the lock file
+		fileCount(dir, 4, t)
+	}
+}
+
+func TestLockActive(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, LockActive: true}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	f, err := os.OpenFile(filename, os.O_RDWR, 0)
+	require.NoError(t, err)
+	require.Equal(t, ErrLocked, lockFile(f, false))
+	require.NoError(t, f.Close())
+
+	// the lock moves to the new active file, and the backup is released
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	f, err = os.OpenFile(filename, os.O_RDWR, 0)
+	require.NoError(t, err)
+	require.Equal(t, ErrLocked, lockFile(f, false))
+	require.NoError(t, f.Close())
+
+	f, err = os.OpenFile(backupFile(dir), os.O_RDWR, 0)
+	require.NoError(t, err)
+	require.NoError(t, lockFile(f, false))
+	require.NoError(t, f.Close())
+
+	// a second Logger can't open the locked file
+	l2 := &Logger{Filename: filename, LockActive: true}
+	defer l2.Close()
+	_, err = l2.Write([]byte("boo!\n"))
+	require.Equal(t, ErrLocked, err)
+	require.NoError(t, l.Close())
+	_, err = l2.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+}
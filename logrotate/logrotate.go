@@ -0,0 +1,283 @@
+// Package logrotate parses a subset of the logrotate configuration syntax
+// into nanojack Logger configurations, so that existing logrotate configs can
+// be replayed in tests.
+//
+// The supported directives are rotate, maxage, size, hourly, daily, weekly, monthly,
+// yearly, copytruncate, nocopytruncate, compress, nocompress and
+// postrotate/endscript. Other directives are recorded but otherwise ignored.
+// Directives that appear outside of a block apply to every block that
+// follows them, as they do in logrotate.
+package logrotate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/observiq/nanojack"
+)
+
+// Config is the configuration of a single log file, as parsed from a
+// logrotate block. A block naming several files yields one Config per file.
+type Config struct {
+	// Path is the log file the configuration applies to.
+	Path string
+
+	// Rotate is the number of backups to keep, or -1 if unset.
+	Rotate int
+
+	// MaxAge is the age after which backups are removed, set in days by the
+	// maxage directive, or 0 if unset.
+	MaxAge time.Duration
+
+	// Size is the size in bytes at which the file is rotated, or 0 if unset.
+	Size int64
+
+	// Interval is the time between rotations set by a frequency directive
+	// such as daily, or 0 if unset. Months and years are approximated as 30
+	// and 365 days.
+	Interval time.Duration
+
+	// CopyTruncate is set by the copytruncate directive.
+	CopyTruncate bool
+
+	// Compress is set by the compress directive.
+	Compress bool
+
+	// PostRotate is the script between postrotate and endscript.
+	PostRotate string
+
+	// Ignored lists the directives that were not understood.
+	Ignored []string
+}
+
+// Logger returns a Logger configured as closely as possible to c. Settings
+// that nanojack has no equivalent for, such as Size, are not applied. The
+// backups are timestamped, so that Compress gzips them as logrotate would.
+//
+// The logrotate directive "rotate 0" discards the old file instead of keeping
+// a backup, which maps to nanojack.MechanismTruncate.
+func (c Config) Logger() *nanojack.Logger {
+	l := &nanojack.Logger{Filename: c.Path}
+	if c.Rotate > 0 {
+		l.MaxBackups = c.Rotate
+	}
+	l.MaxBackupAge = c.MaxAge
+	l.RotateInterval = c.Interval
+	l.Compress = c.Compress
+	if c.CopyTruncate {
+		l.Mechanism = nanojack.MechanismCopyTruncate
+	}
+	if c.Rotate == 0 {
+		l.Mechanism = nanojack.MechanismTruncate
+	}
+	if c.PostRotate != "" {
+		l.PostRotateCmd = []string{"/bin/sh", "-c", c.PostRotate}
+	}
+	return l
+}
+
+var intervals = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// Parse reads a logrotate configuration from r and returns the configuration
+// of every log file it names, in order.
+func Parse(r io.Reader) ([]Config, error) {
+	p := parser{defaults: Config{Rotate: -1}}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.line++
+		if err := p.parseLine(scanner.Text()); err != nil {
+			return nil, fmt.Errorf("line %d: %s", p.line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if p.script != nil {
+		return nil, fmt.Errorf("line %d: postrotate without endscript", p.line)
+	}
+	if p.paths != nil {
+		return nil, fmt.Errorf("line %d: unterminated block", p.line)
+	}
+	return p.configs, nil
+}
+
+type parser struct {
+	line     int
+	defaults Config
+	configs  []Config
+
+	// paths and block are set while inside a block.
+	paths []string
+	block *Config
+
+	// script is set while inside a postrotate script.
+	script []string
+}
+
+func (p *parser) parseLine(line string) error {
+	trimmed := strings.TrimSpace(line)
+
+	if p.script != nil {
+		if trimmed == "endscript" {
+			p.current().PostRotate = strings.Join(p.script, "\n")
+			p.script = nil
+			return nil
+		}
+		p.script = append(p.script, trimmed)
+		return nil
+	}
+
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return nil
+	}
+
+	if strings.HasSuffix(trimmed, "{") {
+		if p.paths != nil {
+			return fmt.Errorf("nested block")
+		}
+		paths, err := splitPaths(strings.TrimSuffix(trimmed, "{"))
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("block without a path")
+		}
+		block := p.defaults
+		block.Ignored = append([]string(nil), p.defaults.Ignored...)
+		p.paths = paths
+		p.block = &block
+		return nil
+	}
+
+	if trimmed == "}" {
+		if p.paths == nil {
+			return fmt.Errorf("unexpected }")
+		}
+		for _, path := range p.paths {
+			c := *p.block
+			c.Path = path
+			p.configs = append(p.configs, c)
+		}
+		p.paths = nil
+		p.block = nil
+		return nil
+	}
+
+	return p.directive(strings.Fields(trimmed))
+}
+
+// current returns the configuration that directives currently apply to.
+func (p *parser) current() *Config {
+	if p.block != nil {
+		return p.block
+	}
+	return &p.defaults
+}
+
+func (p *parser) directive(fields []string) error {
+	c := p.current()
+	name, args := fields[0], fields[1:]
+
+	if interval, ok := intervals[name]; ok {
+		c.Interval = interval
+		return nil
+	}
+
+	switch name {
+	case "rotate":
+		if len(args) != 1 {
+			return fmt.Errorf("rotate takes one argument")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid rotate count %q", args[0])
+		}
+		c.Rotate = n
+	case "maxage":
+		if len(args) != 1 {
+			return fmt.Errorf("maxage takes one argument")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid maxage %q", args[0])
+		}
+		c.MaxAge = time.Duration(n) * 24 * time.Hour
+	case "size":
+		if len(args) != 1 {
+			return fmt.Errorf("size takes one argument")
+		}
+		size, err := parseSize(args[0])
+		if err != nil {
+			return err
+		}
+		c.Size = size
+	case "copytruncate":
+		c.CopyTruncate = true
+	case "nocopytruncate":
+		c.CopyTruncate = false
+	case "compress":
+		c.Compress = true
+	case "nocompress":
+		c.Compress = false
+	case "postrotate":
+		p.script = []string{}
+	case "endscript":
+		return fmt.Errorf("endscript without postrotate")
+	default:
+		c.Ignored = append(c.Ignored, name)
+	}
+	return nil
+}
+
+// parseSize parses a logrotate size such as 100, 100k, 10M or 1G.
+func parseSize(s string) (int64, error) {
+	mult := int64(1)
+	num := s
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult, num = 1<<10, s[:len(s)-1]
+	case strings.HasSuffix(s, "M"):
+		mult, num = 1<<20, s[:len(s)-1]
+	case strings.HasSuffix(s, "G"):
+		mult, num = 1<<30, s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
+
+// splitPaths splits the space separated, optionally double quoted, paths that
+// precede a block.
+func splitPaths(s string) ([]string, error) {
+	var paths []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] != '"' {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			paths = append(paths, s[:end])
+			s = s[end:]
+			continue
+		}
+		end := strings.IndexByte(s[1:], '"')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated quote")
+		}
+		paths = append(paths, s[1:end+1])
+		s = s[end+2:]
+	}
+	return paths, nil
+}
@@ -0,0 +1,113 @@
+package logrotate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/observiq/nanojack"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	conf := `
+# global defaults
+weekly
+rotate 4
+compress
+
+/var/log/app/*.log "/var/log/other dir/x.log" {
+    daily
+    rotate 7
+    maxage 3
+    size 100k
+    copytruncate
+    missingok
+    postrotate
+        kill -HUP $(cat /run/app.pid)
+        echo rotated
+    endscript
+}
+
+/var/log/plain.log {
+    nocompress
+}
+`
+	configs, err := Parse(strings.NewReader(conf))
+	require.NoError(t, err)
+	require.Len(t, configs, 3)
+
+	for i, path := range []string{"/var/log/app/*.log", "/var/log/other dir/x.log"} {
+		c := configs[i]
+		require.Equal(t, path, c.Path)
+		require.Equal(t, 7, c.Rotate)
+		require.Equal(t, 3*24*time.Hour, c.MaxAge)
+		require.Equal(t, int64(100*1024), c.Size)
+		require.Equal(t, 24*time.Hour, c.Interval)
+		require.True(t, c.CopyTruncate)
+		require.True(t, c.Compress)
+		require.Equal(t, "kill -HUP $(cat /run/app.pid)\necho rotated", c.PostRotate)
+		require.Equal(t, []string{"missingok"}, c.Ignored)
+	}
+
+	c := configs[2]
+	require.Equal(t, "/var/log/plain.log", c.Path)
+	require.Equal(t, 4, c.Rotate)
+	require.Zero(t, c.MaxAge)
+	require.Equal(t, int64(0), c.Size)
+	require.Equal(t, 7*24*time.Hour, c.Interval)
+	require.False(t, c.CopyTruncate)
+	require.False(t, c.Compress)
+	require.Empty(t, c.PostRotate)
+	require.Empty(t, c.Ignored)
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"rotate":             "/a.log {\nrotate\n}",
+		"rotate value":       "/a.log {\nrotate x\n}",
+		"maxage value":       "/a.log {\nmaxage -1\n}",
+		"size":               "/a.log {\nsize 10X\n}",
+		"nested":             "/a.log {\n/b.log {\n}\n}",
+		"unterminated":       "/a.log {\nrotate 1",
+		"stray brace":        "}",
+		"no path":            "{\n}",
+		"no endscript":       "/a.log {\npostrotate\necho\n",
+		"endscript":          "/a.log {\nendscript\n}",
+		"unterminated quote": `"/a.log {` + "\n}",
+	}
+	for name, conf := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(conf))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	l := Config{
+		Path:         "/var/log/a.log",
+		Rotate:       3,
+		MaxAge:       time.Hour,
+		Interval:     24 * time.Hour,
+		CopyTruncate: true,
+		Compress:     true,
+		PostRotate:   "echo hi",
+	}.Logger()
+	require.Equal(t, "/var/log/a.log", l.Filename)
+	require.Equal(t, 3, l.MaxBackups)
+	require.Equal(t, time.Hour, l.MaxBackupAge)
+	require.Equal(t, 24*time.Hour, l.RotateInterval)
+	require.Equal(t, nanojack.MechanismCopyTruncate, l.Mechanism)
+	require.True(t, l.Compress)
+	require.Equal(t, []string{"/bin/sh", "-c", "echo hi"}, l.PostRotateCmd)
+
+	l = Config{Path: "/var/log/a.log", Rotate: -1}.Logger()
+	require.Equal(t, 0, l.MaxBackups)
+	require.Equal(t, nanojack.MechanismRenameCreate, l.Mechanism)
+	require.False(t, l.Compress)
+	require.Nil(t, l.PostRotateCmd)
+
+	l = Config{Path: "/var/log/a.log", Rotate: 0, CopyTruncate: true}.Logger()
+	require.Equal(t, nanojack.MechanismTruncate, l.Mechanism)
+}
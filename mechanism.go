@@ -0,0 +1,109 @@
+package nanojack
+
+import (
+	"fmt"
+	"os"
+)
+
+// RotationMechanism selects how the active log file is turned into a backup.
+// The mechanisms differ in whether the active log file keeps its inode across
+// rotations, which matters to readers that identify files by inode.
+type RotationMechanism int
+
+const (
+	// MechanismRenameCreate renames the active file to the backup name and
+	// creates a new active file in its place. Every generation of the active
+	// file has a new inode. This is the default.
+	MechanismRenameCreate RotationMechanism = iota
+
+	// MechanismCopyTruncate copies the active file to the backup name and
+	// then truncates it. The active file keeps the same inode forever.
+	MechanismCopyTruncate
+
+	// MechanismTruncate truncates the active file in place without making a
+	// backup at all. The active file keeps the same inode forever.
+	MechanismTruncate
+)
+
+// CopyTruncateMode selects what happens to lines that other writers append
+// to the active log file between the copy and the truncate of a
+// MechanismCopyTruncate rotation. The Logger's own writes never race with
+// its rotations, so this only concerns other processes, or other Loggers,
+// writing to the same file.
+type CopyTruncateMode int
+
+const (
+	// CopyTruncateLossy lets the truncate destroy the lines appended since
+	// the copy, as logrotate's copytruncate does, and reports how many were
+	// lost in an EventLost. RotateLatency widens the window in which this
+	// can happen. This is the default.
+	CopyTruncateLossy CopyTruncateMode = iota
+
+	// CopyTruncateLocked holds an exclusive lock on the active log file, of
+	// the kind taken for Lock, from before the copy until after the
+	// truncate. The lock is an advisory flock, so it only protects against
+	// writers that take the same lock before appending; no line of theirs is
+	// lost. Ordinary appenders, such as a shell redirection or a program
+	// using O_APPEND, are not held up by it and can still lose lines written
+	// between the final copy and the truncate. To narrow that window,
+	// whatever has been appended since the first copy is copied into the
+	// backup again immediately before the truncate. The copy is synced to
+	// disk before the truncate, while the lock is held.
+	CopyTruncateLocked
+)
+
+var mechanismNames = map[RotationMechanism]string{
+	MechanismRenameCreate: "renamecreate",
+	MechanismCopyTruncate: "copytruncate",
+	MechanismTruncate:     "truncate",
+}
+
+// String returns the configuration name of the mechanism.
+func (m RotationMechanism) String() string {
+	if name, ok := mechanismNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("RotationMechanism(%d)", int(m))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m RotationMechanism) MarshalText() ([]byte, error) {
+	if _, ok := mechanismNames[m]; !ok {
+		return nil, fmt.Errorf("invalid rotation mechanism %d", int(m))
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *RotationMechanism) UnmarshalText(text []byte) error {
+	for mech, name := range mechanismNames {
+		if name == string(text) {
+			*m = mech
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid rotation mechanism %q", text)
+}
+
+// mechanism returns the configured rotation mechanism, taking the older
+// CopyTruncate option into account.
+func (l *Logger) mechanism() RotationMechanism {
+	if l.Mechanism == MechanismRenameCreate && l.CopyTruncate {
+		return MechanismCopyTruncate
+	}
+	return l.Mechanism
+}
+
+// truncateFile truncates the file at name in place and returns it opened for
+// writing at its start.
+func truncateFile(fsys FileSystem, name string) (File, error) {
+	f, err := fsys.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,229 @@
+package nanojack
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestMechanismInode(t *testing.T) {
+	t.Run("RenameCreate", testMechanismInode(t, MechanismRenameCreate, false))
+	t.Run("CopyTruncate", testMechanismInode(t, MechanismCopyTruncate, true))
+	t.Run("Truncate", testMechanismInode(t, MechanismTruncate, true))
+}
+
+func testMechanismInode(t *testing.T, mech RotationMechanism, sameInode bool) func(t *testing.T) {
+	return func(t *testing.T) {
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		filename := logFile(dir)
+		l := &Logger{
+			Filename:  filename,
+			MaxLines:  1,
+			Mechanism: mech,
+		}
+		defer l.Close()
+
+		b := []byte("boo!\n")
+		_, err := l.Write(b)
+		require.NoError(t, err)
+		before, err := os.Stat(filename)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			newFakeTime(time.Second)
+			_, err = l.Write(b)
+			require.NoError(t, err)
+		}
+		after, err := os.Stat(filename)
+		require.NoError(t, err)
+
+		require.Equal(t, sameInode, os.SameFile(before, after))
+		existsWithLines(filename, 1, t)
+		if mech == MechanismTruncate {
+			fileCount(dir, 1, t)
+		} else {
+			fileCount(dir, 4, t)
+		}
+	}
+}
+
+func TestMechanismCopyTruncateCompat(t *testing.T) {
+	require.Equal(t, MechanismRenameCreate, (&Logger{}).mechanism())
+	require.Equal(t, MechanismCopyTruncate, (&Logger{CopyTruncate: true}).mechanism())
+	require.Equal(t, MechanismTruncate, (&Logger{CopyTruncate: true, Mechanism: MechanismTruncate}).mechanism())
+}
+
+func TestMechanismText(t *testing.T) {
+	l := Logger{}
+	require.NoError(t, json.Unmarshal([]byte(`{"mechanism": "truncate"}`), &l))
+	require.Equal(t, MechanismTruncate, l.Mechanism)
+
+	require.NoError(t, yaml.Unmarshal([]byte(`mechanism: copytruncate`), &l))
+	require.Equal(t, MechanismCopyTruncate, l.Mechanism)
+
+	require.Error(t, yaml.Unmarshal([]byte(`mechanism: bogus`), &l))
+
+	text, err := MechanismRenameCreate.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "renamecreate", string(text))
+
+	_, err = RotationMechanism(42).MarshalText()
+	require.Error(t, err)
+}
+
+func TestCopyTruncateSync(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var syncs int
+	defer countSyncs(&syncs)()
+
+	l := &Logger{
+		Filename:  logFile(dir),
+		Mechanism: MechanismCopyTruncate,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	existsWithLines(backupFile(dir), 1, t)
+	// the backup, and on Unix its directory
+	require.NotZero(t, syncs)
+
+	syncs = 0
+	l.NoCopySync = true
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	require.Zero(t, syncs)
+}
+
+func TestCopyTruncateMode(t *testing.T) {
+	t.Run("Lossy", testCopyTruncateMode(CopyTruncateLossy, 1, 2))
+	t.Run("Locked", testCopyTruncateMode(CopyTruncateLocked, 3, 0))
+}
+
+func testCopyTruncateMode(mode CopyTruncateMode, backupLines, lost int64) func(t *testing.T) {
+	return func(t *testing.T) {
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		filename := logFile(dir)
+		events := make(chan Event, 10)
+		l := &Logger{
+			Filename:         filename,
+			Mechanism:        MechanismCopyTruncate,
+			CopyTruncateMode: mode,
+			RotateLatency:    time.Millisecond,
+			Events:           events,
+		}
+		defer l.Close()
+
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+
+		// another writer appends between the copy and the truncate
+		sleep = func(time.Duration) {
+			f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("other\nother\n"))
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}
+		defer func() { sleep = time.Sleep }()
+
+		newFakeTime(time.Second)
+		require.NoError(t, l.Rotate())
+		existsWithLines(backupFile(dir), backupLines, t)
+		existsWithLines(filename, 0, t)
+
+		if lost > 0 {
+			e := nextEvent(t, events)
+			require.Equal(t, EventLost, e.Type)
+			require.Equal(t, lost, e.Lines)
+		}
+		require.Equal(t, EventRotate, nextEvent(t, events).Type)
+	}
+}
+
+func TestCopyTruncateLockedConcurrent(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:         filename,
+		MaxLines:         10,
+		Sequential:       true,
+		Mechanism:        MechanismCopyTruncate,
+		CopyTruncateMode: CopyTruncateLocked,
+		RotateLatency:    time.Millisecond,
+		Append:           true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	// another process appends under the same lock throughout the rotations
+	stop := make(chan struct{})
+	done := make(chan error)
+	appended := 0
+	go func() {
+		f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			done <- err
+			return
+		}
+		defer f.Close()
+		for {
+			select {
+			case <-stop:
+				done <- nil
+				return
+			default:
+			}
+			if err := lockFile(f, true); err != nil {
+				done <- err
+				return
+			}
+			_, err := f.Write([]byte("other\n"))
+			unlockFile(f)
+			if err != nil {
+				done <- err
+				return
+			}
+			appended++
+		}
+	}()
+
+	for i := 1; i < 100; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	close(stop)
+	require.NoError(t, <-done)
+
+	// every line is in the log file or one of its backups
+	names, err := readDirNames(dir)
+	require.NoError(t, err)
+	var lines int
+	for _, name := range names {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		lines += bytes.Count(b, []byte("\n"))
+	}
+	require.Equal(t, 100+appended, lines)
+}
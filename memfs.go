@@ -0,0 +1,449 @@
+package nanojack
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errIsDir    = errors.New("is a directory")
+	errNotDir   = errors.New("not a directory")
+	errNotEmpty = errors.New("directory not empty")
+)
+
+// MemoryFS is a FileSystem held in memory. A Logger given one as its
+// FileSystem rotates, retains, cleans up and compresses its files exactly as
+// it would on disk, without touching the disk, for unit tests and for
+// platforms that have none. Files keep their contents while they are open,
+// even once renamed or removed, as they do on Unix. The zero MemoryFS is
+// empty and ready to use.
+type MemoryFS struct {
+	// Clock is the source of the files' modification times. It defaults to
+	// the system clock.
+	Clock Clock
+
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+// memNode is a file or directory of a MemoryFS.
+type memNode struct {
+	dir     bool
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// ReadFile returns the contents of the named file.
+func (m *MemoryFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.dir {
+		return nil, &os.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return append([]byte(nil), n.data...), nil
+}
+
+// OpenFile implements FileSystem.
+func (m *MemoryFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path := filepath.Clean(name)
+	n, ok := m.nodes[path]
+	switch {
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+	case ok && n.dir && flag&(os.O_WRONLY|os.O_RDWR) != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: errIsDir}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case !ok:
+		if err := m.checkParent("open", path); err != nil {
+			return nil, err
+		}
+		n = &memNode{mode: perm.Perm(), modTime: m.now()}
+		m.set(path, n)
+	case flag&os.O_TRUNC != 0:
+		n.data = nil
+		n.modTime = m.now()
+	}
+	return &memFile{m: m, name: name, node: n, flag: flag}, nil
+}
+
+// Rename implements FileSystem.
+func (m *MemoryFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	from, to := filepath.Clean(oldpath), filepath.Clean(newpath)
+	n, err := m.lookup("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	if err := m.checkParent("rename", to); err != nil {
+		return err
+	}
+	if existing, ok := m.nodes[to]; ok && (existing.dir || n.dir) {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrExist}
+	}
+	delete(m.nodes, from)
+	m.nodes[to] = n
+	if n.dir {
+		prefix := from + string(filepath.Separator)
+		for path, child := range m.nodes {
+			if strings.HasPrefix(path, prefix) {
+				delete(m.nodes, path)
+				m.nodes[to+string(filepath.Separator)+path[len(prefix):]] = child
+			}
+		}
+	}
+	return nil
+}
+
+// Remove implements FileSystem.
+func (m *MemoryFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path := filepath.Clean(name)
+	n, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+	if n.dir && len(m.children(path)) > 0 {
+		return &os.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+	delete(m.nodes, path)
+	return nil
+}
+
+// Stat implements FileSystem.
+func (m *MemoryFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return n.info(name), nil
+}
+
+// Lstat implements FileSystem. A MemoryFS has no symbolic links, so it is
+// the same as Stat.
+func (m *MemoryFS) Lstat(name string) (os.FileInfo, error) {
+	return m.Stat(name)
+}
+
+// MkdirAll implements FileSystem.
+func (m *MemoryFS) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var missing []string
+	for p := filepath.Clean(path); !isRoot(p); p = filepath.Dir(p) {
+		if n, ok := m.nodes[p]; ok {
+			if !n.dir {
+				return &os.PathError{Op: "mkdir", Path: p, Err: errNotDir}
+			}
+			break
+		}
+		missing = append(missing, p)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		m.set(missing[i], &memNode{dir: true, mode: os.ModeDir | perm.Perm(), modTime: m.now()})
+	}
+	return nil
+}
+
+// ReadDirNames implements FileSystem.
+func (m *MemoryFS) ReadDirNames(dir string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path := filepath.Clean(dir)
+	if !isRoot(path) {
+		n, err := m.lookup("open", dir)
+		if err != nil {
+			return nil, err
+		}
+		if !n.dir {
+			return nil, &os.PathError{Op: "readdirent", Path: dir, Err: errNotDir}
+		}
+	}
+	var names []string
+	for _, child := range m.children(path) {
+		names = append(names, filepath.Base(child))
+	}
+	return names, nil
+}
+
+// Glob implements FileSystem.
+func (m *MemoryFS) Glob(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var matches []string
+	for path := range m.nodes {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			matches = append(matches, path)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Chtimes implements FileSystem. A MemoryFS keeps no access times.
+func (m *MemoryFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, err := m.lookup("chtimes", name)
+	if err != nil {
+		return err
+	}
+	n.modTime = mtime
+	return nil
+}
+
+// lookup returns the node at name, or an error for op if there is none. It
+// must be called with the lock held.
+func (m *MemoryFS) lookup(op, name string) (*memNode, error) {
+	n, ok := m.nodes[filepath.Clean(name)]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+	return n, nil
+}
+
+// checkParent returns an error for op unless the directory that would hold
+// path exists. It must be called with the lock held.
+func (m *MemoryFS) checkParent(op, path string) error {
+	dir := filepath.Dir(path)
+	if isRoot(dir) {
+		return nil
+	}
+	n, ok := m.nodes[dir]
+	if !ok {
+		return &os.PathError{Op: op, Path: path, Err: os.ErrNotExist}
+	}
+	if !n.dir {
+		return &os.PathError{Op: op, Path: path, Err: errNotDir}
+	}
+	return nil
+}
+
+// children returns the paths of the entries directly in the directory at
+// path. It must be called with the lock held.
+func (m *MemoryFS) children(path string) []string {
+	var paths []string
+	for p := range m.nodes {
+		if p != path && filepath.Dir(p) == path {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// set adds n at path. It must be called with the lock held.
+func (m *MemoryFS) set(path string, n *memNode) {
+	if m.nodes == nil {
+		m.nodes = make(map[string]*memNode)
+	}
+	m.nodes[path] = n
+}
+
+// now returns the current time according to the Clock.
+func (m *MemoryFS) now() time.Time {
+	return clockNow(m.Clock)
+}
+
+// isRoot reports whether path is a root, or the working, directory, which a
+// MemoryFS always has.
+func isRoot(path string) bool {
+	return path == "." || filepath.Dir(path) == path
+}
+
+// info describes n, found at name.
+func (n *memNode) info(name string) *memInfo {
+	return &memInfo{
+		name:    filepath.Base(name),
+		node:    n,
+		size:    int64(len(n.data)),
+		mode:    n.mode,
+		modTime: n.modTime,
+	}
+}
+
+// memInfo describes a file or directory of a MemoryFS.
+type memInfo struct {
+	name    string
+	node    *memNode
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return i.size }
+func (i *memInfo) Mode() os.FileMode  { return i.mode }
+func (i *memInfo) ModTime() time.Time { return i.modTime }
+func (i *memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memInfo) Sys() interface{}   { return nil }
+
+// memFile is an open file of a MemoryFS.
+type memFile struct {
+	m      *MemoryFS
+	name   string
+	node   *memNode
+	flag   int
+	off    int64
+	closed bool
+}
+
+// check returns an error for op if the file is closed, or was not opened
+// for reading, or for writing if write is set. It must be called with the
+// lock held.
+func (f *memFile) check(op string, write bool) error {
+	mode := f.flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	switch {
+	case f.closed:
+		return &os.PathError{Op: op, Path: f.name, Err: os.ErrClosed}
+	case f.node.dir:
+		return &os.PathError{Op: op, Path: f.name, Err: errIsDir}
+	case write && mode == os.O_RDONLY, !write && mode == os.O_WRONLY:
+		return &os.PathError{Op: op, Path: f.name, Err: os.ErrPermission}
+	}
+	return nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	n, err := f.readAt("read", p, f.off)
+	f.off += int64(n)
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	return f.readAt("read", p, off)
+}
+
+func (f *memFile) readAt(op string, p []byte, off int64) (int, error) {
+	if err := f.check(op, false); err != nil {
+		return 0, err
+	}
+	if off >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.flag&os.O_APPEND != 0 {
+		f.off = int64(len(f.node.data))
+	}
+	n, err := f.writeAt("write", p, f.off)
+	f.off += int64(n)
+	return n, err
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.flag&os.O_APPEND != 0 {
+		return 0, errors.New("nanojack: invalid use of WriteAt on file opened with O_APPEND")
+	}
+	return f.writeAt("write", p, off)
+}
+
+func (f *memFile) writeAt(op string, p []byte, off int64) (int, error) {
+	if err := f.check(op, true); err != nil {
+		return 0, err
+	}
+	if end := off + int64(len(p)); end > int64(len(f.node.data)) {
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+	copy(f.node.data[off:], p)
+	f.node.modTime = f.m.now()
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.closed {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: os.ErrClosed}
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: os.ErrInvalid}
+	}
+	f.off = offset
+	return offset, nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if err := f.check("truncate", true); err != nil {
+		return err
+	}
+	if size < 0 {
+		return &os.PathError{Op: "truncate", Path: f.name, Err: os.ErrInvalid}
+	}
+	if size <= int64(len(f.node.data)) {
+		f.node.data = f.node.data[:size:size]
+	} else {
+		f.node.data = append(f.node.data, make([]byte, size-int64(len(f.node.data)))...)
+	}
+	f.node.modTime = f.m.now()
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.closed {
+		return nil, &os.PathError{Op: "stat", Path: f.name, Err: os.ErrClosed}
+	}
+	return f.node.info(f.name), nil
+}
+
+func (f *memFile) Name() string { return f.name }
+
+// Sync implements File. The contents of a MemoryFS are always as durable as
+// they will ever be.
+func (f *memFile) Sync() error { return nil }
+
+func (f *memFile) Close() error {
+	f.m.mu.Lock()
+	defer f.m.mu.Unlock()
+	if f.closed {
+		return &os.PathError{Op: "close", Path: f.name, Err: os.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
@@ -0,0 +1,303 @@
+package nanojack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memLogFile returns a log file name in a directory that does not exist on
+// disk, so that a test can check that nothing was written there.
+func memLogFile(t *testing.T) string {
+	dir := filepath.Join(os.TempDir(), "nanojack-memfs", strings.ReplaceAll(t.Name(), "/", ""))
+	_, err := os.Stat(dir)
+	require.True(t, os.IsNotExist(err))
+	return filepath.Join(dir, "foobar.log")
+}
+
+// requireNotOnDisk checks that nothing was created at the directory of
+// filename on the real file system.
+func requireNotOnDisk(t *testing.T, filename string) {
+	_, err := os.Stat(filepath.Dir(filename))
+	require.True(t, os.IsNotExist(err), "%s exists on disk", filepath.Dir(filename))
+}
+
+// memLines returns the lines of the file at path on fsys.
+func memLines(t *testing.T, fsys *MemoryFS, path string) []string {
+	b, err := fsys.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Fields(string(b))
+}
+
+func TestMemoryFSRotation(t *testing.T) {
+	filename := memLogFile(t)
+	clock := NewFakeClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	fsys := &MemoryFS{Clock: clock}
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   2,
+		MaxBackups: 2,
+		Clock:      clock,
+		FileSystem: fsys,
+	}
+	defer l.Close()
+
+	for i := 0; i < 9; i++ {
+		clock.Advance(time.Second)
+		_, err := l.Write([]byte("line\n"))
+		require.NoError(t, err)
+	}
+
+	// cleanup is done before Write returns
+	require.Equal(t, []string{"line"}, memLines(t, fsys, filename))
+	backups, err := l.backupFiles()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Equal(t, timestampedName(filename, time.Date(2020, 1, 2, 3, 4, 14, 0, time.UTC)), backups[0])
+	for _, b := range backups {
+		require.Equal(t, []string{"line", "line"}, memLines(t, fsys, b))
+	}
+
+	names, err := fsys.ReadDirNames(filepath.Dir(filename))
+	require.NoError(t, err)
+	require.Len(t, names, 3)
+	requireNotOnDisk(t, filename)
+}
+
+func TestMemoryFSSequential(t *testing.T) {
+	filename := memLogFile(t)
+	fsys := &MemoryFS{}
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   1,
+		MaxBackups: 3,
+		Sequential: true,
+		FileSystem: fsys,
+	}
+	defer l.Close()
+
+	for i := 1; i <= 5; i++ {
+		_, err := l.Write([]byte(strings.Repeat("x", i) + "\n"))
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []string{"xxxxx"}, memLines(t, fsys, filename))
+	for n, want := range []string{"xxxx", "xxx", "xx"} {
+		require.Equal(t, []string{want}, memLines(t, fsys, sequentialName(filename, n+1)))
+	}
+	_, err := fsys.Stat(sequentialName(filename, 4))
+	require.True(t, os.IsNotExist(err))
+	require.NoError(t, l.Inspector().ExpectTotalLines(4))
+	requireNotOnDisk(t, filename)
+}
+
+func TestMemoryFSCopyTruncate(t *testing.T) {
+	filename := memLogFile(t)
+	fsys := &MemoryFS{}
+	l := &Logger{
+		Filename:     filename,
+		MaxLines:     1,
+		CopyTruncate: true,
+		Sequential:   true,
+		FileSystem:   fsys,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("first\n"))
+	require.NoError(t, err)
+	before, err := fsys.Stat(filename)
+	require.NoError(t, err)
+
+	_, err = l.Write([]byte("second\n"))
+	require.NoError(t, err)
+	after, err := fsys.Stat(filename)
+	require.NoError(t, err)
+
+	require.True(t, sameFile(before, after))
+	require.Equal(t, []string{"second"}, memLines(t, fsys, filename))
+	require.Equal(t, []string{"first"}, memLines(t, fsys, sequentialName(filename, 1)))
+
+	stats, err := l.Stats()
+	require.NoError(t, err)
+	require.Equal(t, int64(len("second\n")), stats.Active.Size)
+	require.Equal(t, FileID{}, stats.Active.ID)
+	require.Equal(t, []byte("second\n"), stats.Active.Fingerprint)
+	require.Len(t, stats.Backups, 1)
+	requireNotOnDisk(t, filename)
+}
+
+func TestMemoryFSCompress(t *testing.T) {
+	filename := memLogFile(t)
+	clock := NewFakeClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	fsys := &MemoryFS{Clock: clock}
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   1,
+		Compress:   true,
+		Clock:      clock,
+		FileSystem: fsys,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	clock.Advance(time.Second)
+	_, err = l.Write([]byte("foo!\n"))
+	require.NoError(t, err)
+	l.stopCompress()
+
+	gz := timestampedName(filename, clock.Now()) + compressSuffix
+	b, err := fsys.ReadFile(gz)
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(zr)
+	require.NoError(t, err)
+	require.Equal(t, "boo!\n", string(content))
+
+	backups, err := l.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	require.Equal(t, gz, backups[0].Path)
+	require.Equal(t, int64(1), backups[0].Lines)
+	requireNotOnDisk(t, filename)
+}
+
+func TestMemoryFSRealOnly(t *testing.T) {
+	l := &Logger{
+		Filename:   memLogFile(t),
+		LockActive: true,
+		FileSystem: &MemoryFS{},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.EqualError(t, err, "nanojack: LockActive needs the real file system")
+}
+
+func TestMemoryFSLock(t *testing.T) {
+	l := &Logger{
+		Filename:   memLogFile(t),
+		Lock:       LockFailFast,
+		FileSystem: &MemoryFS{},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.EqualError(t, err, "nanojack: Lock needs the real file system")
+	requireNotOnDisk(t, l.Filename)
+}
+
+func TestMemoryFSOpenFile(t *testing.T) {
+	fsys := &MemoryFS{}
+	require.NoError(t, fsys.MkdirAll(filepath.Join("logs", "old"), 0744))
+
+	f, err := fsys.OpenFile(filepath.Join("logs", "a.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("one\n"))
+	require.NoError(t, err)
+
+	// the open file follows the rename, as it does on Unix
+	require.NoError(t, fsys.Rename(filepath.Join("logs", "a.log"), filepath.Join("logs", "old", "a.log")))
+	_, err = f.Write([]byte("two\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Equal(t, []string{"one", "two"}, memLines(t, fsys, filepath.Join("logs", "old", "a.log")))
+
+	_, err = fsys.OpenFile(filepath.Join("logs", "a.log"), os.O_RDONLY, 0)
+	require.True(t, os.IsNotExist(err))
+	_, err = fsys.OpenFile(filepath.Join("missing", "a.log"), os.O_CREATE|os.O_WRONLY, 0644)
+	require.True(t, os.IsNotExist(err))
+	_, err = fsys.OpenFile(filepath.Join("logs", "old", "a.log"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	require.True(t, os.IsExist(err))
+
+	require.Error(t, fsys.Remove(filepath.Join("logs", "old")))
+	matches, err := fsys.Glob(filepath.Join("logs", "*", "*.log"))
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join("logs", "old", "a.log")}, matches)
+
+	require.NoError(t, fsys.Remove(filepath.Join("logs", "old", "a.log")))
+	names, err := fsys.ReadDirNames(filepath.Join("logs", "old"))
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
+
+func TestMemoryFSArchive(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := memLogFile(t)
+	clock := NewFakeClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 1,
+		Archivers: []Archiver{
+			LocalArchiver{Dir: dir},
+			ArchiverFunc(func(path string) error { return nil }),
+		},
+		Clock:      clock,
+		FileSystem: &MemoryFS{Clock: clock},
+		Events:     events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	_, err = l.Write([]byte("two\n"))
+	require.NoError(t, err)
+
+	var errs []error
+	for len(errs) < 2 {
+		if e := nextEvent(t, events); e.Type == EventArchive {
+			errs = append(errs, e.Err)
+		}
+	}
+	require.NoError(t, errs[0])
+	require.EqualError(t, errs[1], "nanojack: Archiver nanojack.ArchiverFunc needs the real file system")
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, filepath.Base(timestampedName(filename, clock.Now()))))
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(b))
+	requireNotOnDisk(t, filename)
+}
+
+func TestMemoryFSPIDFile(t *testing.T) {
+	filename := memLogFile(t)
+	fsys := &MemoryFS{}
+	l := &Logger{
+		Filename:   filename,
+		PIDFile:    true,
+		FileSystem: fsys,
+	}
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	pid, err := readPIDFile(fsys, filename+".pid")
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), pid)
+
+	require.NoError(t, l.Close())
+	_, err = fsys.Stat(filename + ".pid")
+	require.True(t, os.IsNotExist(err))
+	requireNotOnDisk(t, filename)
+}
+
+func TestMemoryFSStrictPermissions(t *testing.T) {
+	l := &Logger{
+		Filename:          memLogFile(t),
+		StrictPermissions: true,
+		FileSystem:        &MemoryFS{},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.EqualError(t, err, "nanojack: StrictPermissions needs the real file system")
+}
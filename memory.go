@@ -0,0 +1,139 @@
+package nanojack
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryLogger is a rotating logger that keeps the active log file and its
+// backups in memory, for testing code that embeds nanojack where disk access
+// is undesirable or unavailable.
+//
+// MemoryLogger is a Logger with the given settings on a MemoryFS of its own,
+// so it rotates, names and retains its files as a Logger with the same
+// settings does on disk, but never touches the file system. Settings of
+// Logger that MemoryLogger does not have are left at their defaults.
+type MemoryLogger struct {
+	// Filename is the name of the active log file, from which backup names
+	// are derived. It uses <processname>-nanojack.log in os.TempDir() if
+	// empty.
+	Filename string `json:"filename" yaml:"filename"`
+
+	// MaxLines is the maximum lines to the log file before it gets rotated.
+	// It defaults to 10 lines.
+	MaxLines int `json:"maxlines" yaml:"maxlines"`
+
+	// MaxBackups is the maximum number of old log files to retain.  The default
+	// is to retain all old log files.
+	MaxBackups int `json:"maxbackups" yaml:"maxbackups"`
+
+	// Sequential defines whether backups are named by
+	// timestamp (example-2020-10-20T15-04-05.000000000.log) or
+	// by simple integer (example.log.1)
+	Sequential bool `json:"sequential" yaml:"sequential"`
+
+	// MaxBackupAge is the maximum age of old log files to retain, as for
+	// Logger. The default is to retain old log files regardless of age.
+	MaxBackupAge time.Duration `json:"maxbackupage" yaml:"maxbackupage"`
+
+	// MaxTotalSize is the most bytes that old log files may take up
+	// together, as for Logger. The default is no limit.
+	MaxTotalSize int64 `json:"maxtotalsize" yaml:"maxtotalsize"`
+
+	// Clock, if set, is used in place of the system clock to name backups,
+	// to date the files and to judge their age.
+	Clock Clock `json:"-" yaml:"-"`
+
+	mu     sync.Mutex
+	fs     MemoryFS
+	logger Logger
+}
+
+// MemoryFile is a file held by a MemoryLogger.
+type MemoryFile struct {
+	// Name is the name the file would have on disk.
+	Name string
+
+	// Data is the contents of the file.
+	Data []byte
+}
+
+// Write implements io.Writer. If a write would cause the active file to be
+// larger than MaxLines, it is first rotated into a backup.
+func (m *MemoryLogger) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.configure().Write(p)
+}
+
+// Close implements io.Closer. The contents of a MemoryLogger remain available
+// after it is closed, and writing to it again continues where it left off.
+func (m *MemoryLogger) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.logger.Close()
+}
+
+// Rotate moves the contents of the active file into a new backup, and then
+// removes the oldest backups according to MaxBackups.
+func (m *MemoryLogger) Rotate() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.configure().Rotate()
+}
+
+// configure brings the settings of the Logger that keeps the files up to
+// date with those of m, and returns it. It must be called with the lock held.
+func (m *MemoryLogger) configure() *Logger {
+	l := &m.logger
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Filename = m.Filename
+	l.MaxLines = m.MaxLines
+	l.MaxBackups = m.MaxBackups
+	l.Sequential = m.Sequential
+	l.MaxBackupAge = m.MaxBackupAge
+	l.MaxTotalSize = m.MaxTotalSize
+	l.Clock = m.Clock
+	m.fs.Clock = m.Clock
+	l.FileSystem = &m.fs
+	return l
+}
+
+// Contents returns a copy of the contents of the active file.
+func (m *MemoryLogger) Contents() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, _ := m.fs.ReadFile(m.filename())
+	return b
+}
+
+// Backups returns copies of the backup files, newest first.
+func (m *MemoryLogger) Backups() []MemoryFile {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := m.configure()
+	l.mu.Lock()
+	paths, err := l.backupFiles()
+	l.mu.Unlock()
+	if err != nil {
+		// nothing has been written yet
+		return nil
+	}
+	backups := make([]MemoryFile, 0, len(paths))
+	for _, path := range paths {
+		if data, err := m.fs.ReadFile(path); err == nil {
+			backups = append(backups, MemoryFile{Name: path, Data: data})
+		}
+	}
+	return backups
+}
+
+// filename returns the name of the active file.
+func (m *MemoryLogger) filename() string {
+	if m.Filename != "" {
+		return m.Filename
+	}
+	return defaultFilename()
+}
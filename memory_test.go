@@ -0,0 +1,99 @@
+package nanojack
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLogger(t *testing.T) {
+	currentTime = fakeTime
+	dir := filepath.Join("var", "log")
+
+	m := &MemoryLogger{
+		Filename:   logFile(dir),
+		MaxLines:   2,
+		MaxBackups: 2,
+	}
+	defer m.Close()
+
+	var names []string
+	for i := 0; i < 7; i++ {
+		if i > 0 && i%2 == 0 {
+			names = append([]string{backupFile(dir)}, names...)
+		}
+		n, err := m.Write([]byte(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		newFakeTime(time.Second)
+	}
+
+	require.Equal(t, []byte("6\n"), m.Contents())
+	backups := m.Backups()
+	require.Equal(t, []MemoryFile{
+		{Name: names[0], Data: []byte("4\n5\n")},
+		{Name: names[1], Data: []byte("2\n3\n")},
+	}, backups)
+
+	require.NoError(t, m.Rotate())
+	require.Empty(t, m.Contents())
+	backups = m.Backups()
+	require.Len(t, backups, 2)
+	require.Equal(t, []byte("6\n"), backups[0].Data)
+	require.Equal(t, []byte("4\n5\n"), backups[1].Data)
+}
+
+func TestMemoryLoggerSequential(t *testing.T) {
+	m := &MemoryLogger{
+		Filename:   "foo.log",
+		MaxLines:   1,
+		Sequential: true,
+	}
+
+	for i := 0; i < 4; i++ {
+		_, err := m.Write([]byte(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []byte("3\n"), m.Contents())
+	require.Equal(t, []MemoryFile{
+		{Name: "foo.log.1", Data: []byte("2\n")},
+		{Name: "foo.log.2", Data: []byte("1\n")},
+		{Name: "foo.log.3", Data: []byte("0\n")},
+	}, m.Backups())
+}
+
+func TestMemoryLoggerClock(t *testing.T) {
+	dir := filepath.Join("var", "log")
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := NewFakeClock(start)
+	m := &MemoryLogger{
+		Filename:     logFile(dir),
+		MaxLines:     1,
+		MaxBackupAge: 90 * time.Second,
+		Clock:        clock,
+	}
+	defer m.Close()
+
+	for i := 0; i < 4; i++ {
+		_, err := m.Write([]byte(fmt.Sprintf("%d\n", i)))
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+	}
+
+	// backups are named by the Clock, and the one made over 90 seconds
+	// before the last rotation has expired
+	require.Equal(t, []MemoryFile{
+		{Name: timestampedName(logFile(dir), start.Add(3*time.Minute)), Data: []byte("2\n")},
+		{Name: timestampedName(logFile(dir), start.Add(2*time.Minute)), Data: []byte("1\n")},
+	}, m.Backups())
+
+	// the snapshot's files are dated by the Clock too
+	info, err := fs.Stat(m.FS(), familyDir+"/current")
+	require.NoError(t, err)
+	require.Equal(t, clock.Now(), info.ModTime())
+}
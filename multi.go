@@ -0,0 +1,58 @@
+package nanojack
+
+// MultiLogger is a group of Loggers that share a Clock and an Events
+// channel, so that scenarios involving several log files, such as all of
+// them rotating at the same instant, are simple to write. Events from the
+// different Loggers are told apart by their Filename.
+type MultiLogger struct {
+	Loggers []*Logger
+}
+
+// NewMultiLogger groups loggers into a MultiLogger, setting the Clock and
+// Events of each of them to clock and events.
+func NewMultiLogger(clock Clock, events chan<- Event, loggers ...*Logger) *MultiLogger {
+	for _, l := range loggers {
+		l.Clock = clock
+		l.Events = events
+	}
+	return &MultiLogger{Loggers: loggers}
+}
+
+// Write writes p to every Logger. It returns the first error encountered,
+// but writes to the remaining Loggers regardless.
+func (m *MultiLogger) Write(p []byte) (int, error) {
+	var err error
+	for _, l := range m.Loggers {
+		if _, werr := l.Write(p); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Rotate rotates every Logger. Since they share a Clock, timestamped
+// backups made by the same call carry the same timestamp, unless the Clock
+// is a real one. It returns the first error encountered.
+func (m *MultiLogger) Rotate() error {
+	var err error
+	for _, l := range m.Loggers {
+		if rerr := l.Rotate(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// Close closes every Logger. It returns the first error encountered.
+func (m *MultiLogger) Close() error {
+	var err error
+	for _, l := range m.Loggers {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package nanojack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiLogger(t *testing.T) {
+	// the Loggers must not use the package clock
+	currentTime = func() time.Time { panic("package clock used") }
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clock := NewFakeClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	events := make(chan Event, 10)
+	m := NewMultiLogger(clock, events,
+		&Logger{Filename: filepath.Join(dir, "a.log")},
+		&Logger{Filename: filepath.Join(dir, "b.log")},
+	)
+	defer m.Close()
+
+	b := []byte("boo!\n")
+	n, err := m.Write(b)
+	require.NoError(t, err)
+	require.Equal(t, len(b), n)
+
+	clock.Advance(time.Hour)
+	require.NoError(t, m.Rotate())
+
+	// both rotated at exactly the same instant
+	for _, name := range []string{"a", "b"} {
+		existsWithLines(filepath.Join(dir, name+"-2020-01-02T04-04-05.000000000.log"), 1, t)
+		e := nextEvent(t, events)
+		require.Equal(t, EventRotate, e.Type)
+		require.Equal(t, filepath.Join(dir, name+".log"), e.Filename)
+		require.True(t, e.Time.Equal(clock.Now()))
+	}
+	fileCount(dir, 4, t)
+}
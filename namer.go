@@ -0,0 +1,250 @@
+package nanojack
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	_ Namer         = TimestampNamer{}
+	_ SpanNamer     = SpanTimestampNamer{}
+	_ SequenceNamer = SequentialNamer{}
+)
+
+// Namer is a scheme for naming timestamped backups. The Logger names its
+// timestamped backups through a Namer, which is TimestampNamer unless
+// another is set, for example one that keeps backups in directories
+// partitioned by date.
+type Namer interface {
+	// BackupName returns the path at which to keep a backup of filename
+	// made at time t. Any directories in the path that do not exist are
+	// created.
+	BackupName(filename string, t time.Time) string
+
+	// ParseBackup reports whether path is a backup of filename named by
+	// BackupName, and if so, the time that was given to BackupName.
+	ParseBackup(filename, path string) (t time.Time, ok bool)
+
+	// BackupGlob returns a pattern, in the syntax of filepath.Glob, that
+	// matches every backup of filename. It may match other files too, which
+	// ParseBackup rejects.
+	BackupGlob(filename string) string
+}
+
+// TimestampNamer is the built in naming scheme for timestamped backups. A
+// backup is kept in the same directory as the log file, and named by
+// inserting a hyphen and the time between its name and extension, as in
+// foo-2020-10-20T15-04-05.000000000.log.
+type TimestampNamer struct{}
+
+// BackupName implements Namer.
+func (TimestampNamer) BackupName(filename string, t time.Time) string {
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	var buf [len(backupTimeFormat)]byte
+	timestamp := t.UTC().AppendFormat(buf[:0], backupTimeFormat)
+	return filepath.Join(filepath.Dir(filename), base[:len(base)-len(ext)]+"-"+string(timestamp)+ext)
+}
+
+// ParseBackup implements Namer.
+func (TimestampNamer) ParseBackup(filename, path string) (time.Time, bool) {
+	ts, ok := timestampPart(filename, path)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupTimeFormat, ts)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// timestampPart returns what is between the hyphen after the name of
+// filename and its extension in path, if path is in the same directory as
+// filename and has that form.
+func timestampPart(filename, path string) (string, bool) {
+	if filepath.Dir(path) != filepath.Dir(filename) {
+		return "", false
+	}
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)] + "-"
+	name := filepath.Base(path)
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) || len(name) < len(prefix)+len(ext) {
+		return "", false
+	}
+	return name[len(prefix) : len(name)-len(ext)], true
+}
+
+// BackupGlob implements Namer.
+func (TimestampNamer) BackupGlob(filename string) string {
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	return filepath.Join(filepath.Dir(filename), base[:len(base)-len(ext)]+"-*"+ext)
+}
+
+// SpanNamer is implemented by a Namer that names a backup by the span of
+// time its log file covers rather than by a single time. The Logger uses
+// BackupSpanName in place of BackupName when its Namer implements it.
+type SpanNamer interface {
+	Namer
+
+	// BackupSpanName returns the path at which to keep a backup of filename
+	// that was started at start and rotated at end. ParseBackup reports end
+	// as the time of the backup.
+	BackupSpanName(filename string, start, end time.Time) string
+}
+
+// spanSeparator separates the two times in a name made by
+// SpanTimestampNamer.
+const spanSeparator = "_"
+
+// SpanTimestampNamer names each backup with both the time its log file was
+// started and the time it was rotated, as in
+// foo-2020-10-20T15-04-05.000000000_2020-10-20T15-09-05.000000000.log, as some
+// appliances do. It is otherwise like TimestampNamer.
+type SpanTimestampNamer struct{}
+
+// BackupName implements Namer, using t as both times.
+func (n SpanTimestampNamer) BackupName(filename string, t time.Time) string {
+	return n.BackupSpanName(filename, t, t)
+}
+
+// BackupSpanName implements SpanNamer.
+func (SpanTimestampNamer) BackupSpanName(filename string, start, end time.Time) string {
+	span := start.UTC().Format(backupTimeFormat) + spanSeparator + end.UTC().Format(backupTimeFormat)
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	return filepath.Join(filepath.Dir(filename), base[:len(base)-len(ext)]+"-"+span+ext)
+}
+
+// ParseBackup implements Namer.
+func (SpanTimestampNamer) ParseBackup(filename, path string) (time.Time, bool) {
+	span, ok := timestampPart(filename, path)
+	if !ok {
+		return time.Time{}, false
+	}
+	times := strings.Split(span, spanSeparator)
+	if len(times) != 2 {
+		return time.Time{}, false
+	}
+	if _, err := time.Parse(backupTimeFormat, times[0]); err != nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupTimeFormat, times[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// BackupGlob implements Namer.
+func (SpanTimestampNamer) BackupGlob(filename string) string {
+	return TimestampNamer{}.BackupGlob(filename)
+}
+
+// SequenceNamer is a scheme for naming sequential backups, which are
+// numbered from 1, the most recent, upwards. The Logger names its sequential
+// backups through SequentialNamer.
+type SequenceNamer interface {
+	// BackupName returns the path of the nth backup of filename.
+	BackupName(filename string, n int) string
+
+	// ParseBackup reports whether path is a backup of filename named by
+	// BackupName, and if so, its number.
+	ParseBackup(filename, path string) (n int, ok bool)
+}
+
+// SequentialNamer is the built in naming scheme for sequential backups,
+// which are named by appending a dot and a number to the log file's name, as
+// in foo.log.1.
+type SequentialNamer struct {
+	// ExtensionLast puts the number before the log file's extension rather
+	// than after it, as in foo.1.log, so that the backups match the same
+	// globs as the log file.
+	ExtensionLast bool
+}
+
+// BackupName implements SequenceNamer.
+func (s SequentialNamer) BackupName(filename string, n int) string {
+	prefix, ext := s.split(filename)
+	return prefix + "." + strconv.Itoa(n) + ext
+}
+
+// ParseBackup implements SequenceNamer.
+func (s SequentialNamer) ParseBackup(filename, path string) (n int, ok bool) {
+	prefix, ext := s.split(filename)
+	prefix += "."
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, ext) || len(path) < len(prefix)+len(ext) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(path[len(prefix) : len(path)-len(ext)])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// split returns the parts of filename that go before and after the number.
+func (s SequentialNamer) split(filename string) (prefix, ext string) {
+	if !s.ExtensionLast {
+		return filename, ""
+	}
+	ext = filepath.Ext(filename)
+	return filename[:len(filename)-len(ext)], ext
+}
+
+// namedLogFiles returns the timestamped backups named by the Logger's Namer,
+// newest first. Backups compressed by Compress are found under the names
+// they were given with compressSuffix added.
+func (l *Logger) namedLogFiles() ([]logInfo, error) {
+	filename := l.filename()
+	n := l.namer()
+	pattern := n.BackupGlob(filename)
+	paths, err := l.fs().Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("can't find backups: %s", err)
+	}
+
+	var present map[string]bool
+	if l.compressing() {
+		gz, err := l.fs().Glob(pattern + compressSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("can't find backups: %s", err)
+		}
+		present = make(map[string]bool, len(paths))
+		for _, path := range paths {
+			present[path] = true
+		}
+		paths = append(paths, gz...)
+	}
+
+	logFiles := []logInfo{}
+	for _, path := range paths {
+		name := path
+		if present != nil && strings.HasSuffix(path, compressSuffix) {
+			name = strings.TrimSuffix(path, compressSuffix)
+			if present[name] {
+				// the backup is being compressed; count it once
+				continue
+			}
+		}
+		t, ok := n.ParseBackup(filename, name)
+		if !ok {
+			continue
+		}
+		f, err := l.fs().Lstat(path)
+		if err != nil || f.IsDir() {
+			continue
+		}
+		logFiles = append(logFiles, logInfo{t, path, f})
+	}
+
+	sort.Sort(byFormatTime(logFiles))
+
+	return logFiles, nil
+}
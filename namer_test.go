@@ -0,0 +1,182 @@
+package nanojack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dateDirNamer keeps backups in a directory per day, named with the time of
+// day before the extension.
+type dateDirNamer struct{}
+
+const dateDirFormat = "2006-01-02/150405.000000000"
+
+func (dateDirNamer) BackupName(filename string, t time.Time) string {
+	ext := filepath.Ext(filename)
+	parts := strings.SplitN(t.UTC().Format(dateDirFormat), "/", 2)
+	return filepath.Join(filepath.Dir(filename), parts[0], strings.TrimSuffix(filepath.Base(filename), ext)+"-"+parts[1]+ext)
+}
+
+func (dateDirNamer) ParseBackup(filename, path string) (time.Time, bool) {
+	ext := filepath.Ext(filename)
+	prefix := strings.TrimSuffix(filepath.Base(filename), ext) + "-"
+	day := filepath.Base(filepath.Dir(path))
+	name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), prefix), ext)
+	t, err := time.Parse(dateDirFormat, day+"/"+name)
+	return t, err == nil
+}
+
+func (dateDirNamer) BackupGlob(filename string) string {
+	ext := filepath.Ext(filename)
+	return filepath.Join(filepath.Dir(filename), "*", strings.TrimSuffix(filepath.Base(filename), ext)+"-*"+ext)
+}
+
+func TestNamer(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxBackups: 1,
+		Namer:      dateDirNamer{},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	first := dateDirNamer{}.BackupName(filename, fakeTime())
+	existsWithLines(first, 1, t)
+
+	newFakeTime(time.Second)
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Equal(t, []string{first}, result.Removed)
+	exists(dateDirNamer{}.BackupName(filename, fakeTime()), t)
+
+	stats, err := l.Stats()
+	require.NoError(t, err)
+	require.Len(t, stats.Backups, 1)
+}
+
+func TestTimestampNamer(t *testing.T) {
+	n := TimestampNamer{}
+	filename := filepath.Join("logs", "foo.log")
+	when := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+
+	name := n.BackupName(filename, when)
+	require.Equal(t, filepath.Join("logs", "foo-2020-10-20T15-04-05.000000000.log"), name)
+	matched, err := filepath.Match(n.BackupGlob(filename), name)
+	require.NoError(t, err)
+	require.True(t, matched)
+
+	parsed, ok := n.ParseBackup(filename, name)
+	require.True(t, ok)
+	require.True(t, when.Equal(parsed))
+
+	for _, other := range []string{
+		filepath.Join("other", "foo-2020-10-20T15-04-05.000000000.log"),
+		filepath.Join("logs", "foo-not-a-time.log"),
+		filepath.Join("logs", "foo.log"),
+	} {
+		_, ok = n.ParseBackup(filename, other)
+		require.False(t, ok, other)
+	}
+}
+
+func TestSequentialNamer(t *testing.T) {
+	n := SequentialNamer{}
+	require.Equal(t, "foo.log.3", n.BackupName("foo.log", 3))
+	num, ok := n.ParseBackup("foo.log", "foo.log.3")
+	require.True(t, ok)
+	require.Equal(t, 3, num)
+	for _, other := range []string{"foo.log", "foo.log.0", "foo.log.x", "bar.log.1"} {
+		_, ok = n.ParseBackup("foo.log", other)
+		require.False(t, ok, other)
+	}
+}
+
+func TestSequentialNamerExtensionLast(t *testing.T) {
+	n := SequentialNamer{ExtensionLast: true}
+	require.Equal(t, "foo.3.log", n.BackupName("foo.log", 3))
+	require.Equal(t, "foo.3", n.BackupName("foo", 3))
+	num, ok := n.ParseBackup("foo.log", "foo.3.log")
+	require.True(t, ok)
+	require.Equal(t, 3, num)
+	for _, other := range []string{"foo.log", "foo..log", "foo.log.3", "foo.0.log", "foo.x.log", "bar.1.log"} {
+		_, ok = n.ParseBackup("foo.log", other)
+		require.False(t, ok, other)
+	}
+}
+
+func TestExtensionLast(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		MaxBackups:    2,
+		Sequential:    true,
+		ExtensionLast: true,
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+		require.NoError(t, l.Rotate())
+	}
+
+	// the oldest backup was removed to keep two
+	existsWithLines(filepath.Join(dir, "foobar.1.log"), 1, t)
+	existsWithLines(filepath.Join(dir, "foobar.2.log"), 1, t)
+	notExist(filepath.Join(dir, "foobar.3.log"), t)
+	fileCount(dir, 3, t)
+}
+
+func TestSpanTimestampNamer(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxBackups: 1,
+		Namer:      SpanTimestampNamer{},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	start := fakeTime()
+
+	newFakeTime(time.Minute)
+	require.NoError(t, l.Rotate())
+	first := SpanTimestampNamer{}.BackupSpanName(filename, start, fakeTime())
+	require.Equal(t, filepath.Join(dir, "foobar-"+start.UTC().Format(backupTimeFormat)+"_"+fakeTime().UTC().Format(backupTimeFormat)+".log"), first)
+	existsWithLines(first, 1, t)
+
+	end, ok := SpanTimestampNamer{}.ParseBackup(filename, first)
+	require.True(t, ok)
+	require.True(t, end.Equal(fakeTime()))
+	_, ok = SpanTimestampNamer{}.ParseBackup(filename, backupFile(dir))
+	require.False(t, ok)
+
+	// cleanup recognizes the backups by their names
+	newFakeTime(time.Minute)
+	_, err = l.RotateSync()
+	require.NoError(t, err)
+	notExist(first, t)
+	fileCount(dir, 2, t)
+}
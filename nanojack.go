@@ -14,35 +14,53 @@
 //
 // Nanojack assumes that only one process is writing to the output files.
 // Using the same nanojack configuration from multiple processes on the same
-// machine will result in improper behavior.
+// machine will result in improper behavior, unless the Logger's Lock is set
+// to coordinate between them.
 package nanojack
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
 
 const (
-	backupTimeFormat = "2006-01-02T15-04-05.000000000"
-	defaultMaxLines  = 10
+	backupTimeFormat         = "2006-01-02T15-04-05.000000000"
+	defaultMaxLines          = 10
+	defaultPostRotateTimeout = 30 * time.Second
 )
 
 // ensure we always implement io.WriteCloser
 var _ io.WriteCloser = (*Logger)(nil)
 
+// WriteRotator is a rotating log writer. It is implemented by Logger and
+// MemoryLogger, so that code embedding nanojack can be tested against either.
+type WriteRotator interface {
+	io.WriteCloser
+
+	// Rotate moves the current log contents into a backup.
+	Rotate() error
+}
+
+var (
+	_ WriteRotator = (*Logger)(nil)
+	_ WriteRotator = (*MemoryLogger)(nil)
+)
+
 // Logger is an io.WriteCloser that writes to the specified filename.
 //
 // Logger opens or creates the logfile on first Write.  If the file exists and
-// is less than MaxLines, nanojack will open and append to that file.
-// If the file exists and its size is >= MaxLines, the file is renamed
+// contains fewer than MaxLines lines, nanojack will open and append to that
+// file. If the file exists and contains MaxLines or more lines, it is renamed
 // by putting the current time in a timestamp in the name immediately before the
 // file's extension (or the end of the filename if there's no extension). A new
 // log file is then created using original filename.
@@ -65,35 +83,524 @@ var _ io.WriteCloser = (*Logger)(nil)
 // Whenever a new logfile gets created, old log files may be deleted.  The most
 // recent files according to the encoded timestamp will be retained, up to a
 // number equal to MaxBackups (or all of them if MaxBackups is 0). If MaxBackups
-// is 0, no old log files will be deleted.
+// is 0, no old log files will be deleted. If it is NoBackups, every backup is
+// deleted as soon as it is made.
 type Logger struct {
 	// Filename is the file to write logs to.  Backup log files will be retained
 	// in the same directory.  It uses <processname>-nanojack.log in
-	// os.TempDir() if empty.
+	// os.TempDir() if empty. If it is /dev/stdout, /dev/stderr, or another
+	// character device or pipe, lines are still counted and rotations still
+	// happen and are reported, but no backups are made or cleaned up.
 	Filename string `json:"filename" yaml:"filename"`
 
+	// FallbackFilename, if set, is written to instead of Filename when the
+	// latter can't be opened or written, such as when its directory has been
+	// removed or its permissions revoked, with an EventFailover. While the
+	// fallback is in use, the Logger checks before each write, at most once
+	// per CheckInterval, whether Filename can be opened again, and if so
+	// switches back to it with an EventFailback. The fallback is rotated
+	// and cleaned up like Filename.
+	FallbackFilename string `json:"fallbackfilename" yaml:"fallbackfilename"`
+
 	// MaxLines is the maximum lines to the log file before it gets rotated.
 	// It defaults to 10 lines.
 	MaxLines int `json:"maxlines" yaml:"maxlines"`
 
 	// MaxBackups is the maximum number of old log files to retain.  The default
-	// is to retain all old log files.
+	// of 0 is to retain all old log files. NoBackups retains none: each
+	// backup is deleted by the cleanup that follows its rotation.
 	MaxBackups int `json:"maxbackups" yaml:"maxbackups"`
 
+	// RotateInterval, if positive, is how long a log file is written before
+	// it is rotated, even if it is not full. The check is made before each
+	// write and by Tick. With the system clock, a timer also rotates the
+	// file on time when nothing is being written. With any other Clock, the
+	// file is only rotated by a write or a call to Tick, so that tests using
+	// a FakeClock can advance it and then call Tick to make pending
+	// rotations happen synchronously.
+	RotateInterval time.Duration `json:"rotateinterval" yaml:"rotateinterval"`
+
+	// MaxRotationsPerMinute, if positive, caps how many times the active log
+	// file may be rotated in any minute, to protect the disk when a
+	// scenario's thresholds are set too low. RotationLimit selects what
+	// happens to a rotation beyond the cap.
+	MaxRotationsPerMinute int `json:"maxrotationsperminute" yaml:"maxrotationsperminute"`
+
+	// RotationLimit selects what happens when a rotation would exceed
+	// MaxRotationsPerMinute. See RotationLimitPolicy for the available
+	// policies.
+	RotationLimit RotationLimitPolicy `json:"rotationlimit" yaml:"rotationlimit"`
+
+	// MaxTotalLines, if positive, is how many lines the Logger writes across
+	// the active log file and all its backups, so that a scenario can
+	// produce an exact number of lines however they are rotated. LineBudget
+	// selects what happens to the lines after that.
+	MaxTotalLines int64 `json:"maxtotallines" yaml:"maxtotallines"`
+
+	// LineBudget selects what happens to writes once MaxTotalLines lines
+	// have been written. See LineBudgetPolicy for the available policies.
+	LineBudget LineBudgetPolicy `json:"linebudget" yaml:"linebudget"`
+
+	// Observers are told of every line written and every rotation, in order,
+	// so that a test harness can keep a running account of what was written
+	// without reading the files back.
+	Observers []Observer `json:"-" yaml:"-"`
+
+	// Transformers are applied in order to each line before it is written,
+	// and may modify or annotate it, or drop it entirely. Rotation decisions,
+	// Triggers and Observers all see the transformed line.
+	Transformers []Transformer `json:"-" yaml:"-"`
+
+	// Triggers are custom conditions, checked before each write, that cause
+	// the active file to be rotated before the write when any of them is
+	// met. They are checked after the built in conditions, and only when
+	// those have not caused a rotation.
+	Triggers []Trigger `json:"-" yaml:"-"`
+
+	// MaxBackupAge is the maximum age of old log files to retain. When it is
+	// combined with MaxBackups, a backup is deleted if it exceeds either
+	// limit. The age of a timestamped backup is taken from its name, and that
+	// of a sequential backup from its modification time. The default is to
+	// retain old log files regardless of age.
+	MaxBackupAge time.Duration `json:"maxbackupage" yaml:"maxbackupage"`
+
+	// MaxTotalSize is the most bytes that old log files may take up
+	// together. The oldest backups are deleted until the rest fit. It is
+	// applied after MaxBackups and MaxBackupAge, to the backups they keep.
+	// The default is no limit.
+	MaxTotalSize int64 `json:"maxtotalsize" yaml:"maxtotalsize"`
+
 	// CopyTruncate defines the mechanism by which a file is backed up.
 	// By default a backup is created by renaming the old file and creating
 	// a new file in its place. If CopyTruncate is true, the old file will be
 	// copied to a new file and then truncated.
+	//
+	// Deprecated: CopyTruncate is equivalent to setting Mechanism to
+	// MechanismCopyTruncate, and is ignored if Mechanism is set to anything
+	// else.
 	CopyTruncate bool `json:"copytruncate" yaml:"copytruncate"`
 
+	// Mechanism defines the mechanism by which a file is backed up. See
+	// RotationMechanism for the available mechanisms.
+	Mechanism RotationMechanism `json:"mechanism" yaml:"mechanism"`
+
+	// NoCopySync skips syncing the backup and its directory to disk before
+	// the active file is truncated by MechanismCopyTruncate. Without the
+	// sync, a crash shortly after a rotation can lose the whole rotated
+	// generation, since it is no longer in the active file and may not have
+	// reached the backup yet.
+	NoCopySync bool `json:"nocopysync" yaml:"nocopysync"`
+
+	// CopyTruncateMode selects what happens to lines that other writers
+	// append to the active log file while MechanismCopyTruncate is rotating
+	// it. See CopyTruncateMode for the available modes.
+	CopyTruncateMode CopyTruncateMode `json:"copytruncatemode" yaml:"copytruncatemode"`
+
+	// CopyBufferSize, if positive, is the size in bytes of the chunks in
+	// which MechanismCopyTruncate copies the active log file to its backup,
+	// and of the buffer used where the copy has to pass through user space.
+	// Larger chunks suit fast local disks, and smaller ones keep slow network
+	// storage responsive. By default, the whole file is handed to the kernel
+	// at once where possible, and a 32 KiB buffer is used otherwise.
+	CopyBufferSize int `json:"copybuffersize" yaml:"copybuffersize"`
+
+	// Compress gzips each timestamped backup after rotation, on a pool of
+	// background goroutines, so that writes are never held up by it. The
+	// compressed backup is named with a ".gz" suffix, and the uncompressed
+	// one removed. The outcome of each compression is reported in an
+	// EventCompress, and the Archivers are given the compressed backup once
+	// it is complete. Compress has no effect on sequential backups or
+	// backups named by a Namer. Close waits for queued compressions to
+	// finish.
+	Compress bool `json:"compress" yaml:"compress"`
+
+	// CompressWorkers is the number of backups compressed at once. It
+	// defaults to one.
+	CompressWorkers int `json:"compressworkers" yaml:"compressworkers"`
+
+	// CompressQueue is the number of backups that may wait to be compressed.
+	// When the queue is full, rotation blocks until there is room, so that
+	// a Logger rotating faster than it can compress slows down rather than
+	// piling up uncompressed backups. It defaults to 16.
+	CompressQueue int `json:"compressqueue" yaml:"compressqueue"`
+
+	// Namer, if set, names timestamped backups in place of the built in
+	// scheme, which is that of TimestampNamer. It is not used for sequential
+	// backups.
+	Namer Namer `json:"-" yaml:"-"`
+
 	// Sequential defines whether backups are renamed by
 	// timestamp (example-2020-10-20T15-04-05.000000000.log) or
 	// by simple integer (example.log.1)
 	Sequential bool `json:"sequential" yaml:"sequential"`
 
-	lines int64
-	file  *os.File
-	mu    sync.Mutex
+	// ExtensionLast puts the number of a sequential backup before the
+	// extension, as in example.1.log, rather than after it.
+	ExtensionLast bool `json:"extensionlast" yaml:"extensionlast"`
+
+	// WriteLatency is an artificial delay applied before every write. It can
+	// be used to simulate a slow disk.
+	WriteLatency time.Duration `json:"writelatency" yaml:"writelatency"`
+
+	// RotateLatency is an artificial delay applied in the middle of a
+	// rotation: after the current file has been moved aside and before its
+	// replacement is created, or between the copy and the truncate when
+	// Mechanism is MechanismCopyTruncate. It can be used to widen the window in which a
+	// reader may observe a rotation in progress.
+	RotateLatency time.Duration `json:"rotatelatency" yaml:"rotatelatency"`
+
+	// LatencyJitter adds a random duration in the range [0, LatencyJitter) to
+	// each WriteLatency and RotateLatency delay.
+	LatencyJitter time.Duration `json:"latencyjitter" yaml:"latencyjitter"`
+
+	// RateLimit, if set, paces writes to a sustained rate. Writes block until
+	// they are allowed by the limit. See RateLimit for details.
+	RateLimit *RateLimit `json:"ratelimit" yaml:"ratelimit"`
+
+	// AsyncQueue, if positive, makes writes asynchronous. Write copies the
+	// data into a queue holding up to AsyncQueue writes and returns at once,
+	// and a background goroutine performs the file I/O and rotation. Errors
+	// from background writes are reported in an EventWriteError. Close
+	// waits for the queue to drain, and writes after Close are synchronous.
+	AsyncQueue int `json:"asyncqueue" yaml:"asyncqueue"`
+
+	// AsyncOverflow selects what Write does when the AsyncQueue is full. See
+	// OverflowPolicy for the available policies.
+	AsyncOverflow OverflowPolicy `json:"asyncoverflow" yaml:"asyncoverflow"`
+
+	// WriteTimeout, if positive, bounds how long a synchronous Write may take.
+	// A Write that takes longer, for instance because of a slow disk or a
+	// blocked rotation, returns ErrWriteTimeout. The write itself cannot be
+	// interrupted, and still completes in the background.
+	WriteTimeout time.Duration `json:"writetimeout" yaml:"writetimeout"`
+
+	// Preallocate, if positive, is the number of bytes of disk space to
+	// allocate for each new active log file, ideally the size it will reach
+	// before it is rotated. Preallocation does not change the apparent size
+	// of the file. It reduces fragmentation and keeps write latency flat, and
+	// is only supported on Linux; elsewhere, and on file systems that do not
+	// support it, it is skipped.
+	Preallocate int64 `json:"preallocate" yaml:"preallocate"`
+
+	// SyncWrites opens the active log file with O_SYNC, so that every write
+	// reaches the disk before Write returns.
+	SyncWrites bool `json:"syncwrites" yaml:"syncwrites"`
+
+	// SyncEveryLines, if positive, syncs the active log file to disk, as
+	// Sync does, after every SyncEveryLines lines written.
+	SyncEveryLines int `json:"synceverylines" yaml:"synceverylines"`
+
+	// SyncEveryDuration, if positive, syncs the active log file to disk, as
+	// Sync does, after the first write made once SyncEveryDuration has
+	// passed since the last sync. It is checked only when writing.
+	SyncEveryDuration time.Duration `json:"synceveryduration" yaml:"synceveryduration"`
+
+	// Append opens the active log file with O_APPEND, so that every write
+	// lands at the end of the file even when other processes, or other
+	// Loggers, write to it through descriptors of their own. Lines from
+	// several writers then interleave whole rather than overwriting each
+	// other. Append has no effect when DirectIO is set.
+	Append bool `json:"append" yaml:"append"`
+
+	// DirectIO opens the active log file with O_DIRECT, bypassing the page
+	// cache. Since direct I/O must be done in whole, aligned blocks, each
+	// write rewrites the last partial block of the file and then truncates
+	// the file to its true length. DirectIO is only supported on Linux, and
+	// not by every file system.
+	DirectIO bool `json:"directio" yaml:"directio"`
+
+	// BufferSize, if positive, is the size in bytes of a buffer that writes
+	// are collected in before they are written to the active log file. The
+	// buffer is written out when it is full, before each rotation, on Flush,
+	// and on Close. Lines in the buffer are lost if the Logger is killed.
+	BufferSize int `json:"buffersize" yaml:"buffersize"`
+
+	// FlushInterval, if positive, is how often the buffer is written out when
+	// BufferSize is set.
+	FlushInterval time.Duration `json:"flushinterval" yaml:"flushinterval"`
+
+	// Unterminated holds back the trailing newline of each line until the
+	// next write, so that the active log file ends in a partial line between
+	// writes, as it does for applications that flush partial lines. The
+	// newline is written before the file is rotated or closed, but is lost
+	// if the Logger is killed.
+	Unterminated bool `json:"unterminated" yaml:"unterminated"`
+
+	// Encoding is the character encoding in which lines are written. Lines
+	// given to Write must be UTF-8, and are converted as they are written.
+	// It defaults to UTF-8.
+	Encoding Encoding `json:"encoding" yaml:"encoding"`
+
+	// BOM writes the byte order mark of the Encoding at the start of each
+	// new log file. Latin-1 has no byte order mark.
+	BOM bool `json:"bom" yaml:"bom"`
+
+	// Gzip writes the active log file as a gzip stream, so that Filename
+	// should normally end in ".gz". The stream is finished when the file is
+	// rotated or closed, leaving each backup a complete gzip file. Since a
+	// stream can't be resumed, an existing file is always rotated when it is
+	// opened. Data held by the compressor is only written out on Flush, so it
+	// is lost if the Logger is killed.
+	Gzip bool `json:"gzip" yaml:"gzip"`
+
+	// Owner and Group, if set, are the user and group given ownership of each
+	// log file the Logger creates, both active files and backups. Each may
+	// be a name or a numeric ID. Changing ownership usually requires running
+	// as root, and is not supported on Windows.
+	Owner string `json:"owner" yaml:"owner"`
+	Group string `json:"group" yaml:"group"`
+
+	// Mode is the permission bits of a log file created where there was none
+	// before. It defaults to 0644. Files that replace a rotated file keep the
+	// permissions of the file they replace.
+	Mode os.FileMode `json:"mode" yaml:"mode"`
+
+	// StrictPermissions sets the permissions of each created file exactly,
+	// rather than as masked by the process umask.
+	StrictPermissions bool `json:"strictpermissions" yaml:"strictpermissions"`
+
+	// Chaos, if set, randomly interferes with the Logger's operation. See
+	// Chaos for details.
+	Chaos *Chaos `json:"chaos" yaml:"chaos"`
+
+	// Duplicates, if set, re-writes copies of lines that were already
+	// written. See Duplicates for details.
+	Duplicates *Duplicates `json:"duplicates" yaml:"duplicates"`
+
+	// DetectTruncation enables a check before each write that the active log
+	// file has not been truncated by another process. When truncation is
+	// detected, the line count is recomputed from the file's remaining
+	// contents, writing continues at the new end of the file, and an
+	// EventTruncated is emitted.
+	DetectTruncation bool `json:"detecttruncation" yaml:"detecttruncation"`
+
+	// TruncationIsError causes a write that detects truncation to fail with
+	// ErrTruncated. The Logger is resynchronized either way, so the following
+	// write proceeds normally.
+	TruncationIsError bool `json:"truncationiserror" yaml:"truncationiserror"`
+
+	// DetectDeletion enables a check before each write that the active log
+	// file still exists. When the file has been deleted by another process, a
+	// new file is created in its place and an EventDeleted is emitted, rather
+	// than writing to the unlinked file forever.
+	DetectDeletion bool `json:"detectdeletion" yaml:"detectdeletion"`
+
+	// OnRename enables a check before each write that the active log file's
+	// path still refers to the file the Logger has open, and selects how the
+	// Logger responds when another process has renamed the file away. See
+	// RenameAction for the available responses.
+	OnRename RenameAction `json:"onrename" yaml:"onrename"`
+
+	// CheckInterval limits how often the DetectTruncation, DetectDeletion and
+	// OnRename checks are made. When zero, they are made before every write.
+	// Otherwise, a write only checks if at least CheckInterval has passed
+	// since the previous check.
+	CheckInterval time.Duration `json:"checkinterval" yaml:"checkinterval"`
+
+	// Watch enables a watcher on the log directory that emits events for
+	// files created, renamed, or removed there by other processes, so that
+	// external interference can be correlated with the Logger's own actions.
+	Watch bool `json:"watch" yaml:"watch"`
+
+	// PostRotateCmd is a command, given as the program followed by its
+	// arguments, that is run in the background after each rotation, like
+	// logrotate's postrotate scripts. The environment variables
+	// NANOJACK_FILENAME and NANOJACK_BACKUP are set to the paths of the log
+	// file and the new backup file; the backup may meanwhile be compressed,
+	// archived or cleaned up. The command's combined output and exit status
+	// are reported in an EventPostRotate; a failing command does not fail the
+	// rotation. Close waits for running commands to finish.
+	PostRotateCmd []string `json:"postrotatecmd" yaml:"postrotatecmd"`
+
+	// PostRotateTimeout is the time after which PostRotateCmd is killed. It
+	// defaults to 30 seconds.
+	PostRotateTimeout time.Duration `json:"postrotatetimeout" yaml:"postrotatetimeout"`
+
+	// NotifyPID and NotifySignal, if both are set, cause NotifySignal to be
+	// sent to the process NotifyPID after each rotation, once PostRotateCmd
+	// has been started, as applications do to tell a sidecar that they have rolled
+	// their logs. The outcome is reported in an EventNotify; a failure does
+	// not fail the rotation.
+	NotifyPID    int       `json:"notifypid" yaml:"notifypid"`
+	NotifySignal os.Signal `json:"-" yaml:"-"`
+
+	// Archivers are run in the background on each new backup after
+	// rotation, once NotifySignal has been sent. The outcome of each one is
+	// reported in an EventArchive; a failure does not fail the rotation.
+	Archivers []Archiver `json:"-" yaml:"-"`
+
+	// S3, if set, uploads each backup to Amazon S3 after rotation, along
+	// with the Archivers. See S3Upload for details.
+	S3 *S3Upload `json:"s3" yaml:"s3"`
+
+	// ArchiveRetries is the number of times a failed Archiver is retried.
+	ArchiveRetries int `json:"archiveretries" yaml:"archiveretries"`
+
+	// ArchiveBackoff is the delay before the first retry of a failed
+	// Archiver, which doubles with each further retry. It defaults to one
+	// second.
+	ArchiveBackoff time.Duration `json:"archivebackoff" yaml:"archivebackoff"`
+
+	// Webhook, if set, is sent a description of each rotation. See Webhook
+	// for details.
+	Webhook *Webhook `json:"webhook" yaml:"webhook"`
+
+	// OnBeforeDelete, if set, is called with the path of each backup that
+	// cleanup is about to delete, while the Logger's lock is held. The
+	// backup is only deleted if it returns true, so a scenario can copy the
+	// file away first, or keep a particular generation. A kept backup is
+	// offered again at the next cleanup. It is not called in dry-run mode.
+	OnBeforeDelete func(path string) bool `json:"-" yaml:"-"`
+
+	// FailWhenPaused causes writes to fail with ErrPaused while the Logger is
+	// paused, instead of blocking until it is resumed.
+	FailWhenPaused bool `json:"failwhenpaused" yaml:"failwhenpaused"`
+
+	// DryRun causes rotations and the deletion of old log files to be
+	// decided and reported as events, with DryRun set, but not carried out.
+	// Lines are still written to the active log file, which is never rotated,
+	// but the Logger counts lines as if it had been. This can be used to
+	// validate retention settings against an existing directory.
+	DryRun bool `json:"dryrun" yaml:"dryrun"`
+
+	// Lock selects how the Logger coordinates with other processes writing
+	// to the same log file. See LockMode for the available modes.
+	Lock LockMode `json:"lock" yaml:"lock"`
+
+	// LockActive takes an exclusive lock on the active log file itself for
+	// as long as it is open, as some producers do, using flock on Unix and
+	// LockFileEx on Windows. On Unix the lock is advisory, while on Windows
+	// it prevents other processes from reading the start of the file. If
+	// another process already holds a lock on the file, opening it fails
+	// with ErrLocked.
+	LockActive bool `json:"lockactive" yaml:"lockactive"`
+
+	// PIDFile creates a file next to the log file, named after it with a
+	// ".pid" suffix, holding the ID of the writing process, so that scripts
+	// can find the process to signal. It is created when the log file is
+	// first opened and removed by Close. If the PID file names another
+	// process that is still running, opening the log file fails with
+	// ErrRunning; a PID file left by a process that has exited is replaced.
+	PIDFile bool `json:"pidfile" yaml:"pidfile"`
+
+	// JournalFile, if set, is a file to which a JSON record of every event is
+	// appended, whether or not Events is set. It gives a durable history of
+	// the Logger's rotations, cleanups and errors to correlate with the
+	// behavior of a log reader.
+	JournalFile string `json:"journalfile" yaml:"journalfile"`
+
+	// Debug, if set, is called with a level ("debug" or "error"), a message,
+	// and alternating keys and values to explain the Logger's internal
+	// decisions, such as why it rotated and what it deleted. It may be called
+	// from several goroutines at once.
+	Debug func(level, msg string, keyvals ...interface{}) `json:"-" yaml:"-"`
+
+	// StallThreshold, if positive, is how long a rotation or a cleanup of old
+	// log files may take before an EventStall is emitted. The event is
+	// emitted while the operation is still running, so that a hung rename on
+	// a network file system, which blocks every write behind it, is noticed.
+	StallThreshold time.Duration `json:"stallthreshold" yaml:"stallthreshold"`
+
+	// Clock, if set, is used in place of the system clock for all of the
+	// Logger's time keeping, such as backup timestamps and event times. If
+	// it also controls sleeping and timers, as FakeClock does, the Logger
+	// waits by it too, including for WriteTimeout and FlushInterval. S3
+	// uploads are the exception: they are always signed with the system
+	// clock, since S3 rejects requests whose time is too far from its own.
+	Clock Clock `json:"-" yaml:"-"`
+
+	// FileSystem, if set, is what the log file and its backups are kept on
+	// in place of the real file system. See FileSystem for what it covers.
+	FileSystem FileSystem `json:"-" yaml:"-"`
+
+	// Events, if set, receives an Event for notable things that happen to
+	// the Logger's files. Sends never block; events are dropped if the
+	// channel is full.
+	Events chan<- Event `json:"-" yaml:"-"`
+
+	lines     int64
+	size      int64
+	rotations int64
+	file      File
+	killed    bool
+	device    bool
+	detached  bool
+	watcher   *watcher
+	lockf     *os.File
+	pidFile   string
+	signals   *signalHandler
+	control   *controlServer
+	paused    bool
+	async     *asyncWriter
+	asyncDone bool
+	tail      []byte
+	held      bool
+	encoded   []byte
+	gz        *gzip.Writer
+
+	// cleanupResult, when set, collects the outcome of deleting old log files
+	// during a call to RotateSync.
+	cleanupResult *CleanupResult
+
+	// pinned holds the paths of backups protected from cleanup.
+	pinned map[string]bool
+
+	// recentRotations holds the times of the rotations made in the last
+	// minute, oldest first, when MaxRotationsPerMinute is set.
+	recentRotations []time.Time
+
+	// compressor is the pool of goroutines compressing backups when
+	// Compress is set.
+	compressor *compressor
+
+	// postRotating counts the PostRotateCmd runs that are still going, so
+	// that Close can wait for them.
+	postRotating sync.WaitGroup
+
+	// backupMeta holds what is known about each backup made by the Logger,
+	// by path.
+	backupMeta map[string]backupMeta
+
+	// linesBefore is the number of lines in the files that came before the
+	// active log file.
+	linesBefore int64
+
+	// failedOver is set while the Logger writes to FallbackFilename, and
+	// lastFailback is when it last checked whether it could switch back.
+	failedOver   bool
+	lastFailback time.Time
+
+	// rotateTo, when set, is the path RotateTo is moving the active log file
+	// to.
+	rotateTo string
+
+	// totalLines counts the lines written since the Logger was created, or
+	// since it last wrapped, for MaxTotalLines.
+	totalLines int64
+
+	// journal is the open JournalFile. It has its own lock, since events are
+	// emitted from goroutines that don't hold mu.
+	journal   File
+	journalMu sync.Mutex
+
+	// opened is when the active file was started, and interval is the timer
+	// that rotates it when RotateInterval has passed.
+	opened   time.Time
+	interval *time.Timer
+
+	// rotateErr and cleanupErr are the errors from the last rotation and
+	// the cleanup that followed it, as reported by Health.
+	rotateErr  error
+	cleanupErr error
+	buf        *bufio.Writer
+	flusher    *flusher
+	resumed    *sync.Cond
+	lastCheck  time.Time
+	batching   bool
+	batch      []byte
+	unsynced   int
+	lastSync   time.Time
+	mu         sync.Mutex
 }
 
 var (
@@ -102,6 +609,9 @@ var (
 
 	// os_Stat exists so it can be mocked out by tests.
 	os_Stat = os.Stat
+
+	// sleep exists so it can be mocked out by tests.
+	sleep = time.Sleep
 )
 
 // Write implements io.Writer.  If a write would cause the log file to be larger
@@ -109,32 +619,150 @@ var (
 // current time, and a new log file is created using the original log file name.
 // If the length of the write is greater than MaxLines, an error is returned.
 func (l *Logger) Write(p []byte) (n int, err error) {
+	if l.AsyncQueue > 0 {
+		return l.enqueue(p)
+	}
+	if l.WriteTimeout > 0 {
+		return l.writeWithTimeout(p)
+	}
+	return l.write(p)
+}
+
+// write synchronously writes p to the log file.
+func (l *Logger) write(p []byte) (n int, err error) {
+	if l.RateLimit != nil {
+		l.RateLimit.wait(len(p), l.now(), l.sleep)
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	return l.writeLocked(p)
+}
+
+// writeLocked writes p to the log file. It must be called with the Logger's
+// lock held.
+func (l *Logger) writeLocked(p []byte) (n int, err error) {
+	if err := l.waitResumed(); err != nil {
+		return 0, err
+	}
+
+	if l.killed {
+		return 0, ErrKilled
+	}
+
+	if err := l.acquire(); err != nil {
+		return 0, err
+	}
+	defer l.release()
 
 	if l.file == nil {
+		if err = l.writePIDFile(); err != nil {
+			return 0, err
+		}
 		if err = l.openExistingOrNew(); err != nil {
+			if !l.canFailover() {
+				return 0, err
+			}
+			if err = l.failover(err); err != nil {
+				return 0, err
+			}
+		}
+	} else if err = l.failback(); err != nil {
+		return 0, err
+	}
+
+	if l.Watch && l.watcher == nil {
+		if !realFS(l.fs()) {
+			return 0, needsRealFS("Watch")
+		}
+		if err := l.startWatcher(); err != nil {
 			return 0, err
 		}
 	}
 
+	if err := l.checkExternal(); err != nil {
+		return 0, err
+	}
+
+	if l.Chaos != nil {
+		if err := l.Chaos.strike(l); err != nil {
+			return 0, err
+		}
+	}
+
+	line := l.transform(p)
+	if line == nil {
+		// dropped by a transformer
+		return len(p), nil
+	}
+
+	if err := l.spendLine(); err != nil {
+		return 0, err
+	}
+
+	if err := l.tick(); err != nil {
+		return 0, err
+	}
+
 	if l.lines+1 > l.max() {
-		if err := l.rotate(); err != nil {
+		l.debug("debug", "rotating", "reason", "max lines", "lines", l.lines)
+		if err := l.rotate(ReasonMaxLines); err != nil {
+			return 0, err
+		}
+	} else if l.triggered(line) {
+		l.debug("debug", "rotating", "reason", "trigger")
+		if err := l.rotate(ReasonTrigger); err != nil {
 			return 0, err
 		}
 	}
 
-	n, err = l.file.Write(p)
+	l.delay(l.WriteLatency)
+
+	n, err = l.writeLine(line)
+	if err != nil && n == 0 && l.canFailover() {
+		if err = l.failover(err); err == nil {
+			n, err = l.writeLine(line)
+		}
+	}
 	l.lines++
+	l.totalLines++
+	if n > 0 {
+		l.observeWrite(line[:n])
+	}
+	if err == nil {
+		err = l.syncPolicy()
+	}
+	if err == nil && l.Duplicates != nil {
+		err = l.duplicate(line)
+	}
 
-	return n, err
+	return transformedCount(p, line, n), err
 }
 
 // Close implements io.Closer, and closes the current logfile.
 func (l *Logger) Close() error {
+	l.stopAsync()
+	l.stopFlusher()
+	l.stopCompress()
+	l.postRotating.Wait()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return l.close()
+	l.stopSignals()
+	l.stopControl()
+	if err := l.stopWatcher(); err != nil {
+		return err
+	}
+	if err := l.close(); err != nil {
+		return err
+	}
+	if err := l.closeJournal(); err != nil {
+		return err
+	}
+	if err := l.removePIDFile(); err != nil {
+		return err
+	}
+	return l.unlock()
 }
 
 // close closes the file if it is open.
@@ -142,11 +770,41 @@ func (l *Logger) close() error {
 	if l.file == nil {
 		return nil
 	}
+	l.stopInterval()
+	ferr := l.terminate()
+	if err := l.flush(); ferr == nil {
+		ferr = err
+	}
+	if gerr := l.finishGzip(); ferr == nil {
+		ferr = gerr
+	}
 	err := l.file.Close()
 	l.file = nil
+	if ferr != nil {
+		return ferr
+	}
 	return err
 }
 
+// writeFile writes p to the active log file, compressing it if Gzip is set.
+func (l *Logger) writeFile(p []byte) (n int, err error) {
+	if l.Gzip {
+		return l.writeGzip(p)
+	}
+	return l.writeRaw(p)
+}
+
+// writeRaw writes p straight to the active log file.
+func (l *Logger) writeRaw(p []byte) (n int, err error) {
+	if l.DirectIO {
+		n, err = l.writeDirect(p)
+	} else {
+		n, err = l.file.Write(p)
+	}
+	l.size += int64(n)
+	return n, err
+}
+
 // Rotate causes Logger to close the existing log file and immediately create a
 // new one.  This is a helper function for applications that want to initiate
 // rotations outside of the normal rotation rules, such as in response to
@@ -155,217 +813,501 @@ func (l *Logger) close() error {
 func (l *Logger) Rotate() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return l.rotate()
+	if err := l.acquire(); err != nil {
+		return err
+	}
+	defer l.release()
+	l.debug("debug", "rotating", "reason", "requested")
+	return l.rotate(ReasonManual)
+}
+
+// RotateTo rotates the log file like Rotate, but moves it to path rather than
+// to a name given by the naming policy, so that a scenario can make backups
+// with names a collector doesn't expect. The directories of path are created
+// if necessary. Since the backup doesn't look like one to the Logger, it is
+// never cleaned up. The rotation mechanism still applies, except that
+// MechanismTruncate also makes the backup, by renaming.
+func (l *Logger) RotateTo(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.acquire(); err != nil {
+		return err
+	}
+	defer l.release()
+	l.debug("debug", "rotating", "reason", "requested", "backup", path)
+	l.rotateTo = path
+	defer func() { l.rotateTo = "" }()
+	return l.rotate(ReasonManual)
 }
 
 // rotate closes the current file, moves it aside with an appropriate extension
 //  in the name, (if it exists), opens a new file with the original filename,
-// and then runs cleanup.
-func (l *Logger) rotate() error {
+// and then runs cleanup. reason says why the rotation is happening.
+func (l *Logger) rotate(reason RotationReason) error {
+	if ok, err := l.limitRotation(); !ok {
+		return err
+	}
+	if l.device || (l.file == nil && l.isDevice(l.filename())) {
+		return l.rotateDevice(reason)
+	}
+	if l.DryRun && l.file != nil {
+		return l.dryRotate(reason)
+	}
+	defer l.watchdog("rotate", l.filename())()
+
+	lines := l.lines
 	if err := l.close(); err != nil {
+		l.rotateErr = err
 		return err
 	}
+	l.rotations++
 
 	if l.fileExists() {
-		if err := l.backup(); err != nil {
+		name, err := l.backup()
+		if err != nil {
+			l.rotateErr = err
+			l.debug("error", "rotation failed", "error", err)
+			l.emit(Event{Type: EventRotate, Filename: l.filename(), Path: name, Err: err, Reason: string(reason)})
 			return err
 		}
+		meta := backupMeta{reason: reason}
+		if lines > 0 {
+			meta.firstLine, meta.lastLine = l.linesBefore+1, l.linesBefore+lines
+		}
+		l.linesBefore += lines
+		if name != "" {
+			l.noteBackup(name, meta)
+		}
+		l.debug("debug", "rotated", "backup", name)
+		l.emit(Event{Type: EventRotate, Filename: l.filename(), Path: name, Reason: string(reason)})
+		l.observeRotate(name)
+		l.postRotate(name)
+		l.notify(name)
+		l.webhook(name, lines)
+		if l.compressing() {
+			l.compress(name)
+		} else {
+			l.archive(name, l.filename())
+		}
 	} else if err := l.initializeFile(); err != nil {
+		l.rotateErr = err
 		return err
+	} else {
+		l.observeRotate("")
 	}
+	l.rotateErr = nil
 
+	l.cleanupErr = nil
+	var err error
 	if l.Sequential {
-		// sequential extention should never create files beyond the max
-		return nil
+		err = l.cleanupSequential()
+	} else {
+		// cleanup old timestamped files
+		err = l.cleanup()
 	}
-
-	// cleanup old timestamped files
-	return l.cleanup()
+	if err != nil {
+		l.cleanupErr = err
+	}
+	if derr := l.repeatLast(); err == nil {
+		err = derr
+	}
+	return err
 }
 
 // fileExists returns true if the logger's primary file already exists
 func (l *Logger) fileExists() bool {
-	return fileExists(l.filename())
+	return fileExists(l.fs(), l.filename())
 }
 
-func fileExists(path string) bool {
-	_, err := os_Stat(path)
+func fileExists(fsys FileSystem, path string) bool {
+	_, err := fsys.Stat(path)
 	return err == nil
 }
 
 // initializeFile tries to create the logger's primary file
 func (l *Logger) initializeFile() error {
-	if err := os.MkdirAll(l.dir(), 0744); err != nil {
+	if err := l.fs().MkdirAll(l.dir(), 0744); err != nil {
 		return fmt.Errorf("can't make directories for new logfile: %s", err)
 	}
-	f, err := os.OpenFile(l.filename(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0644))
+	if !l.fileExists() {
+		l.expect(opCreate, l.filename())
+	}
+	f, err := l.fs().OpenFile(l.filename(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, l.mode())
 	if err != nil {
 		return fmt.Errorf("can't open new logfile: %s", err)
 	}
-	l.file = f
-	l.lines = 0
-	return nil
+	l.setFile(f, 0, 0)
+	if err := l.applyMode(l.filename(), l.mode()); err != nil {
+		return err
+	}
+	if err := l.applyOwner(l.filename()); err != nil {
+		return err
+	}
+	return l.prepareFile()
 }
 
-// backup and replace the log file according to the configured mechanism.
-// This method assumes that the appropriate directory exists.
-func (l *Logger) backup() (err error) {
-	var f *os.File
+// setFile makes f the active log file, which is known to contain the given
+// number of bytes and lines.
+func (l *Logger) setFile(f File, size, lines int64) {
+	l.file = f
+	l.size = size
+	l.lines = lines
+	l.detached = false
+	l.device = false
+	if f != nil {
+		l.startInterval()
+	}
+}
 
-	if l.Sequential {
+// backup and replace the log file according to the configured mechanism, and
+// return the name of the backup file, which is empty if none was made.
+// This method assumes that the appropriate directory exists, and that the
+// log file has been closed.
+func (l *Logger) backup() (name string, err error) {
+	var f File
+	mode := l.activeMode()
+
+	switch {
+	case l.rotateTo != "":
+		name = l.rotateTo
+		if err := l.fs().MkdirAll(filepath.Dir(name), 0744); err != nil {
+			return "", fmt.Errorf("can't make directories for backup: %s", err)
+		}
+		// doMove renames unless the mechanism is copytruncate
+		f, err = l.doMove(l.filename(), name)
+	case l.mechanism() == MechanismTruncate:
+		f, err = truncateFile(l.fs(), l.filename())
+	case l.Sequential:
+		name = l.sequentialBackup(1)
 		f, err = l.backupSequential()
-	} else {
-		l.file.Close()
-		f, err = doMove(l.filename(), l.timestampedBackupName(), l.CopyTruncate)
+	default:
+		name = l.timestampedBackupName()
+		if filepath.Dir(name) != l.dir() {
+			if err := l.fs().MkdirAll(filepath.Dir(name), 0744); err != nil {
+				return "", fmt.Errorf("can't make directories for backup: %s", err)
+			}
+		}
+		f, err = l.doMove(l.filename(), name)
 	}
 
 	if err != nil {
-		return
+		return "", err
 	}
 
-	l.file = f
-	l.lines = 0
-	return
+	l.setFile(f, 0, 0)
+	if err := l.applyOwner(l.filename()); err != nil {
+		return name, err
+	}
+	if err := l.applyMode(l.filename(), mode); err != nil {
+		return name, err
+	}
+	if name != "" {
+		if err := l.applyOwner(name); err != nil {
+			return name, err
+		}
+		if err := l.applyMode(name, mode); err != nil {
+			return name, err
+		}
+	}
+	return name, l.prepareFile()
 }
 
-func (l *Logger) backupSequential() (*os.File, error) {
+func (l *Logger) backupSequential() (File, error) {
 	name := l.filename()
 
-	if l.MaxBackups == 0 {
-		cascade(name, 1)
-	} else {
-		maxBackupName := fmt.Sprintf("%s.%d", name, l.MaxBackups)
-		if fileExists(maxBackupName) {
-			_ = os.Remove(maxBackupName)
-		}
+	nums, err := l.sequentialNumbers()
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[int]bool, len(nums))
+	for _, n := range nums {
+		present[n] = true
+	}
 
-		cascade(name, 1)
+	maxBackupName := l.sequentialBackup(l.MaxBackups)
+	if l.MaxBackups > 0 && present[l.MaxBackups] && !l.isPinned(maxBackupName) && l.approveDelete(maxBackupName) {
+		l.expect(opRemove, maxBackupName)
+		l.emit(Event{Type: EventRemove, Filename: l.filename(), Path: maxBackupName})
+		l.debug("debug", "removing old log file", "path", maxBackupName)
+		l.remove(maxBackupName)
+		delete(present, l.MaxBackups)
 	}
 
-	l.file.Close()
-	return doMove(name, fmt.Sprintf("%s.%d", name, 1), l.CopyTruncate)
-}
+	if err := l.cascade(name, present); err != nil {
+		return nil, err
+	}
 
-func cascade(name string, fromN int) error {
-	from := fmt.Sprintf("%s.%d", name, fromN)
-	to := fmt.Sprintf("%s.%d", name, fromN+1)
+	return l.doMove(name, l.sequentialBackup(1))
+}
 
-	if !fileExists(from) {
-		return nil
+// cascade makes room for a new first backup of name by renaming each backup
+// in the unbroken run starting at 1 to the next number up. Renames are done
+// newest to oldest, so no backup is overwritten. present holds the backup
+// numbers that exist.
+func (l *Logger) cascade(name string, present map[int]bool) error {
+	last := 0
+	for present[last+1] {
+		last++
 	}
 
-	if fileExists(to) {
-		if err := cascade(name, fromN+1); err != nil {
+	for n := last; n > 0; n-- {
+		from := l.sequentialNamer().BackupName(name, n)
+		to := l.sequentialNamer().BackupName(name, n+1)
+		l.expect(opRename, from)
+		l.expect(opCreate, to)
+		if _, err := move(l.fs(), from, to); err != nil {
 			return err
 		}
+		l.movePin(from, to)
+		l.moveBackup(from, to)
 	}
+	return nil
+}
 
-	_, err := move(from, to)
-	return err
+// sequentialNumbers returns the numbers of the sequential backups in the log
+// directory, in ascending order. It reads the directory once and does not
+// stat the files.
+func (l *Logger) sequentialNumbers() ([]int, error) {
+	names, err := l.fs().ReadDirNames(l.dir())
+	if err != nil {
+		return nil, fmt.Errorf("can't read log file directory: %s", err)
+	}
+	base := filepath.Base(l.filename())
+	var nums []int
+	for _, name := range names {
+		if n, ok := l.sequentialNamer().ParseBackup(base, name); ok {
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+	return nums, nil
 }
 
-func doMove(from, to string, copyTrunc bool) (*os.File, error) {
-	if copyTrunc {
-		return copyTruncate(from, to)
+// doMove backs up from to the path to using the configured mechanism, and
+// returns the file that replaces from.
+func (l *Logger) doMove(from, to string) (File, error) {
+	pause := func() { l.delay(l.RotateLatency) }
+	if l.mechanism() == MechanismCopyTruncate {
+		if !fileExists(l.fs(), to) {
+			l.expect(opCreate, to)
+		}
+		locked := l.CopyTruncateMode == CopyTruncateLocked
+		f, lost, err := copyTruncate(l.fs(), from, to, l.CopyBufferSize, !l.NoCopySync, locked, pause)
+		if lost > 0 {
+			l.debug("debug", "lines lost in copytruncate", "lines", lost)
+			l.emit(Event{Type: EventLost, Filename: l.filename(), Path: to, Lines: lost})
+		}
+		return f, err
 	}
-	return moveCreate(from, to)
+	l.expect(opRename, from)
+	l.expect(opCreate, to, from)
+	return moveCreate(l.fs(), from, to, pause, l.sleep)
 }
 
-func copyTruncate(from, to string) (*os.File, error) {
+// delay sleeps for d plus up to LatencyJitter. Nothing happens if d is zero.
+func (l *Logger) delay(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if l.LatencyJitter > 0 {
+		d += time.Duration(rand.Int63n(int64(l.LatencyJitter)))
+	}
+	l.sleep(d)
+}
+
+// copyTruncate copies from to the path to, in chunks of size bytes if size
+// is positive, and truncates from. The pause function is called between the
+// copy and the truncate. If locked is set, from is locked against other
+// writers throughout, and data appended in the meantime regardless is copied
+// too. Otherwise it is destroyed by the truncate, which returns the number of
+// lines it held. If sync is set, the copy and its directory are synced to
+// disk before the truncate. Locking needs fsys to be the real file system.
+func copyTruncate(fsys FileSystem, from, to string, size int, sync, locked bool, pause func()) (f File, lost int64, err error) {
 
-	info, err := os_Stat(from)
+	info, err := fsys.Stat(from)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	f, err := os.OpenFile(from, os.O_RDWR, info.Mode())
+	f, err = fsys.OpenFile(from, os.O_RDWR, info.Mode())
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	bkp, err := os.OpenFile(to, os.O_CREATE|os.O_RDWR, info.Mode())
+	active := f
+	defer func() {
+		if err != nil {
+			active.Close()
+		}
+	}()
+	if locked {
+		osf, err := osFile(f, "CopyTruncateLocked")
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := lockFile(osf, true); err != nil {
+			return nil, 0, err
+		}
+		defer unlockFile(osf)
+	}
+
+	bkp, err := fsys.OpenFile(to, os.O_CREATE|os.O_RDWR, info.Mode())
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer bkp.Close()
 
-	// this is a no-op on windows
-	if err := chown(to, info); err != nil {
-		return nil, err
+	// this is a no-op on windows, and other file systems have no owners
+	if realFS(fsys) {
+		if err := chown(to, info); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	if _, err := io.Copy(bkp, f); err != nil {
-		return nil, err
+	if _, err := copyFiles(bkp, f, size); err != nil {
+		return nil, 0, err
+	}
+
+	pause()
+
+	if locked {
+		if _, err := copyFiles(bkp, f, size); err != nil {
+			return nil, 0, err
+		}
+	} else if lost, err = countNewlines(f); err != nil {
+		return nil, 0, err
+	}
+
+	// the copy is made durable before the only other copy of its lines is
+	// destroyed
+	if sync {
+		if err := fileSync(bkp); err != nil {
+			return nil, 0, err
+		}
+		if realFS(fsys) {
+			if err := syncDir(filepath.Dir(to)); err != nil {
+				return nil, 0, err
+			}
+		}
 	}
 
 	if err := f.Truncate(0); err != nil {
-		return nil, err
+		return nil, 0, err
 	} else if _, err = f.Seek(0, 0); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+	return f, lost, nil
+}
 
-	return f, nil
+// countNewlines counts the newlines in the rest of r.
+func countNewlines(r io.Reader) (int64, error) {
+	var lines int64
+	var buf [32 * 1024]byte
+	for {
+		n, err := r.Read(buf[:])
+		lines += int64(bytes.Count(buf[:n], newline))
+		if err == io.EOF {
+			return lines, nil
+		} else if err != nil {
+			return 0, err
+		}
+	}
 }
 
-func move(from, to string) (os.FileInfo, error) {
+func move(fsys FileSystem, from, to string) (os.FileInfo, error) {
 
-	info, err := os_Stat(from)
+	info, err := fsys.Stat(from)
 	if err != nil {
 		return info, err
 	}
 
 	// move the existing file
-	if err := os.Rename(from, to); err != nil {
+	if err := fsys.Rename(from, to); err != nil {
 		return info, fmt.Errorf("can't rename log file: %s", err)
 	}
 
 	return info, nil
 }
 
-func moveCreate(from, to string) (*os.File, error) {
+// moveCreate renames from to the path to and creates a new file at from. The
+// pause function is called between the rename and the create, and sleep
+// waits between attempts at the rename.
+func moveCreate(fsys FileSystem, from, to string, pause func(), sleep func(time.Duration)) (File, error) {
 
 	tries := 0
 	var info os.FileInfo
 	var err error
 	for {
-		info, err = move(from, to)
+		info, err = move(fsys, from, to)
 		if err != nil {
 			tries++
 			if tries > 20 {
 				return nil, err
 			}
-			time.Sleep(10 * time.Millisecond)
+			sleep(10 * time.Millisecond)
 		}
 		break
 	}
 
+	pause()
+
 	// we use truncate here because this should only get called when we've moved
 	// the file ourselves. if someone else creates the file in the meantime,
 	// just wipe out the contents.
-	f, err := os.OpenFile(from, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	f, err := fsys.OpenFile(from, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
 	if err != nil {
 		return nil, fmt.Errorf("can't open new logfile: %s", err)
 	}
 
-	// this is a no-op on windows
-	if err := chown(from, info); err != nil {
-		return nil, err
+	// this is a no-op on windows, and other file systems have no owners
+	if realFS(fsys) {
+		if err := chown(from, info); err != nil {
+			return nil, err
+		}
 	}
 
 	return f, nil
 }
 
-// timestampedBackupName creates a new filename from the given name, inserting a UTC
-// timestamp between the filename and the extension.
+// timestampedBackupName returns the name of a new timestamped backup of the
+// log file, made now, as given by the Logger's Namer.
 func (l *Logger) timestampedBackupName() string {
-	name := l.filename()
-	dir := filepath.Dir(name)
-	filename := filepath.Base(name)
-	ext := filepath.Ext(filename)
-	prefix := filename[:len(filename)-len(ext)]
-	t := currentTime().UTC()
-	timestamp := t.Format(backupTimeFormat)
-	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, timestamp, ext))
+	n := l.namer()
+	if s, ok := n.(SpanNamer); ok && !l.opened.IsZero() {
+		return s.BackupSpanName(l.filename(), l.opened, l.now())
+	}
+	return n.BackupName(l.filename(), l.now())
+}
+
+// timestampedName returns the name of the backup of name made at t by
+// TimestampNamer.
+func timestampedName(name string, t time.Time) string {
+	return TimestampNamer{}.BackupName(name, t)
+}
+
+// sequentialName returns the name of the nth sequential backup of name.
+func sequentialName(name string, n int) string {
+	return SequentialNamer{}.BackupName(name, n)
+}
+
+// namer returns the naming scheme for the Logger's timestamped backups.
+func (l *Logger) namer() Namer {
+	if l.Namer != nil {
+		return l.Namer
+	}
+	return TimestampNamer{}
+}
+
+// sequentialNamer returns the naming scheme for the Logger's sequential
+// backups.
+func (l *Logger) sequentialNamer() SequenceNamer {
+	return SequentialNamer{ExtensionLast: l.ExtensionLast}
+}
+
+// sequentialBackup returns the name of the nth sequential backup of the log
+// file.
+func (l *Logger) sequentialBackup(n int) string {
+	return l.sequentialNamer().BackupName(l.filename(), n)
 }
 
 // openExistingOrNew opens the logfile if it exists.
@@ -373,7 +1315,10 @@ func (l *Logger) timestampedBackupName() string {
 // put it over the MaxLines, a new file is created.
 func (l *Logger) openExistingOrNew() error {
 	filename := l.filename()
-	info, err := os_Stat(filename)
+	if l.isDevice(filename) {
+		return l.openDevice()
+	}
+	info, err := l.fs().Stat(filename)
 	if os.IsNotExist(err) {
 		return l.initializeFile()
 	}
@@ -381,130 +1326,191 @@ func (l *Logger) openExistingOrNew() error {
 		return fmt.Errorf("error getting log file info: %s", err)
 	}
 
-	if info.Size()+1 > l.max() {
-		return l.rotate()
+	if l.Gzip {
+		l.debug("debug", "rotating", "reason", "gzip stream can't be resumed")
+		return l.rotate(ReasonExisting)
 	}
 
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	lines, err := l.countLines(filename)
 	if err != nil {
-		// if we fail to open the old log file for some reason, just ignore
-		// it and open a new log file.
+		// if we fail to count the lines in the old log file for some reason,
+		// just ignore it and open a new log file.
 		return l.initializeFile()
 	}
-	l.file = file
-	l.lines, err = linesInFile(l.filename())
+
+	if lines+1 > l.max() {
+		l.debug("debug", "rotating", "reason", "existing file full", "lines", lines)
+		return l.rotate(ReasonExisting)
+	}
+
+	file, err := l.fs().OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
-		// if we fail to count the lines in the old log file for some reason,
-		// just ignore it and open a new log file.
+		// if we fail to open the old log file for some reason, just ignore
+		// it and open a new log file.
 		return l.initializeFile()
 	}
-	return nil
+	l.setFile(file, info.Size(), lines)
+	return l.prepareFile()
 }
 
 // filename generates the name of the logfile from the current time.
 func (l *Logger) filename() string {
+	if l.failedOver {
+		return l.FallbackFilename
+	}
 	if l.Filename != "" {
 		return l.Filename
 	}
+	return defaultFilename()
+}
+
+// lockedFilename is filename for callers that do not hold the lock.
+func (l *Logger) lockedFilename() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.filename()
+}
+
+// defaultFilename returns the log file name used when none is configured.
+func defaultFilename() string {
 	name := filepath.Base(os.Args[0]) + "-nanojack.log"
 	return filepath.Join(os.TempDir(), name)
 }
 
-// cleanup deletes old log files, keeping at most l.MaxBackups files.
+// cleanup deletes the old log files that the retention settings have
+// expired.
 func (l *Logger) cleanup() error {
-	if l.MaxBackups == 0 {
-		return nil
-	}
-
-	files, err := l.oldLogFiles()
+	decisions, err := l.retention()
 	if err != nil {
 		return err
 	}
+	l.traceRetention(decisions)
 
-	var deletes []logInfo
-
-	if l.MaxBackups > 0 && l.MaxBackups < len(files) {
-		deletes = files[l.MaxBackups:]
-		files = files[:l.MaxBackups]
-	}
-
+	deletes := l.approved(expiredFiles(decisions))
 	if len(deletes) == 0 {
 		return nil
 	}
 
-	go deleteAll(l.dir(), deletes)
+	for _, f := range deletes {
+		path := f.path
+		l.forgetBackup(path)
+		l.expect(opRemove, path)
+		l.emit(Event{Type: EventRemove, Filename: l.filename(), Path: path, DryRun: l.DryRun})
+		l.debug("debug", "removing old log file", "path", path, "dryrun", l.DryRun)
+	}
+	if l.DryRun {
+		return nil
+	}
+	// removals from a FileSystem other than the disk are too cheap to be
+	// worth a goroutine, and are then done by the time Write returns
+	if l.cleanupResult != nil || !realFS(l.fs()) {
+		for _, f := range deletes {
+			l.remove(f.path)
+		}
+		return nil
+	}
+	go l.deleteAll(deletes, l.filename())
 
 	return nil
 }
 
-func linesInFile(path string) (int64, error) {
-	content, err := ioutil.ReadFile(path)
+// cleanupSequential deletes the sequential backups that the retention
+// settings have expired. The count is normally kept by backupSequential
+// already, so that only the age and size limits are left to apply.
+func (l *Logger) cleanupSequential() error {
+	decisions, err := l.retention()
 	if err != nil {
-		return 0, err
+		return err
 	}
-	lines := strings.FieldsFunc(string(content), func(c rune) bool { return c == '\n' })
-	return int64(len(lines)), nil
-}
+	l.traceRetention(decisions)
 
-func deleteAll(dir string, files []logInfo) {
-	// remove files on a separate goroutine
-	for _, f := range files {
-		// what am I going to do, log this?
-		_ = os.Remove(filepath.Join(dir, f.Name()))
+	for _, f := range expiredFiles(decisions) {
+		path := f.path
+		if !l.approveDelete(path) {
+			continue
+		}
+		l.forgetBackup(path)
+		l.expect(opRemove, path)
+		l.emit(Event{Type: EventRemove, Filename: l.filename(), Path: path, DryRun: l.DryRun})
+		l.debug("debug", "removing old log file", "path", path, "dryrun", l.DryRun)
+		if !l.DryRun {
+			l.remove(path)
+		}
 	}
+	return nil
 }
 
-// oldLogFiles returns the list of backup log files stored in the same
-// directory as the current log file, sorted by ModTime
-func (l *Logger) oldLogFiles() ([]logInfo, error) {
-	files, err := ioutil.ReadDir(l.dir())
+// linesInFile counts the non-empty lines in the file at path, including a
+// final line with no trailing newline. The file is read in chunks rather than
+// all at once.
+func linesInFile(fsys FileSystem, path string) (int64, error) {
+	f, err := fsys.OpenFile(path, os.O_RDONLY, 0)
 	if err != nil {
-		return nil, fmt.Errorf("can't read log file directory: %s", err)
-	}
-
-	if l.Sequential {
-
+		return 0, err
 	}
+	defer f.Close()
 
-	logFiles := []logInfo{}
-
-	prefix, ext := l.prefixAndExt()
-
-	for _, f := range files {
-		if f.IsDir() {
-			continue
+	var lines int64
+	var buf [32 * 1024]byte
+	prev := byte('\n')
+	for {
+		n, err := f.Read(buf[:])
+		for _, c := range buf[:n] {
+			if c != '\n' && prev == '\n' {
+				lines++
+			}
+			prev = c
 		}
-		name := l.timeFromName(f.Name(), prefix, ext)
-		if name == "" {
-			continue
+		if err == io.EOF {
+			return lines, nil
 		}
-		t, err := time.Parse(backupTimeFormat, name)
-		if err == nil {
-			logFiles = append(logFiles, logInfo{t, f})
+		if err != nil {
+			return 0, err
 		}
-		// error parsing means that the suffix at the end was not generated
-		// by nanojack, and therefore it's not a backup file.
 	}
+}
 
-	sort.Sort(byFormatTime(logFiles))
-
-	return logFiles, nil
+func (l *Logger) deleteAll(files []logInfo, filename string) {
+	defer l.watchdog("cleanup", filename)()
+	// remove files on a separate goroutine
+	for _, f := range files {
+		path := f.path
+		if err := l.fs().Remove(path); err != nil {
+			l.debug("error", "can't remove old log file", "path", path, "error", err)
+			l.mu.Lock()
+			l.cleanupErr = err
+			l.mu.Unlock()
+		}
+	}
 }
 
-// timeFromName extracts the formatted time from the filename by stripping off
-// the filename's prefix and extension. This prevents someone's filename from
-// confusing time.parse.
-func (l *Logger) timeFromName(filename, prefix, ext string) string {
-	if !strings.HasPrefix(filename, prefix) {
-		return ""
+// oldLogFiles returns the list of backup log files of the current log file,
+// newest first.
+func (l *Logger) oldLogFiles() ([]logInfo, error) {
+	if l.Sequential {
+		return l.sequentialLogFiles()
 	}
-	filename = filename[len(prefix):]
+	return l.namedLogFiles()
+}
 
-	if !strings.HasSuffix(filename, ext) {
-		return ""
+// sequentialLogFiles returns the sequential backups, newest first, which is
+// in order of their numbers. Their timestamps are their modification times,
+// since their names hold none.
+func (l *Logger) sequentialLogFiles() ([]logInfo, error) {
+	nums, err := l.sequentialNumbers()
+	if err != nil {
+		return nil, err
+	}
+	var files []logInfo
+	for _, n := range nums {
+		path := l.sequentialBackup(n)
+		info, err := l.fs().Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, logInfo{info.ModTime(), path, info})
 	}
-	filename = filename[:len(filename)-len(ext)]
-	return filename
+	return files, nil
 }
 
 // intFromName extracts the sequence number from the filename by stripping off
@@ -532,19 +1538,21 @@ func (l *Logger) dir() string {
 	return filepath.Dir(l.filename())
 }
 
-// prefixAndExt returns the filename part and extension part from the Logger's
-// filename.
-func (l *Logger) prefixAndExt() (prefix, ext string) {
-	filename := filepath.Base(l.filename())
-	ext = filepath.Ext(filename)
-	prefix = filename[:len(filename)-len(ext)] + "-"
-	return prefix, ext
+// readDirNames returns the names of the entries in dir.
+func readDirNames(dir string) ([]string, error) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	return d.Readdirnames(-1)
 }
 
 // logInfo is a convenience struct to return the filename and its embedded
 // timestamp.
 type logInfo struct {
 	timestamp time.Time
+	path      string
 	os.FileInfo
 }
 
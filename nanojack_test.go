@@ -77,6 +77,57 @@ func TestAppendExisting(t *testing.T) {
 	existsWithLines(filename, 2, t)
 }
 
+func TestAppendExistingLongLines(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	// the file is far larger in bytes than MaxLines, but has room for
+	// another line
+	filename := logFile(dir)
+	data := []byte(strings.Repeat("x", 100) + "\n" + strings.Repeat("y", 100) + "\n")
+	require.NoError(t, ioutil.WriteFile(filename, data, 0644))
+
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 3,
+	}
+	defer l.Close()
+	b := []byte("boo!\n")
+	n, err := l.Write(b)
+	require.NoError(t, err)
+	require.Equal(t, len(b), n)
+
+	fileCount(dir, 1, t)
+	existsWithLines(filename, 3, t)
+
+	// the next line no longer fits
+	n, err = l.Write(b)
+	require.NoError(t, err)
+	require.Equal(t, len(b), n)
+	fileCount(dir, 2, t)
+	existsWithLines(filename, 1, t)
+}
+
+func TestLinesInFile(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	for content, want := range map[string]int64{
+		"":                 0,
+		"one\n":            1,
+		"one\ntwo":         2,
+		"one\n\n\ntwo\n":   2,
+		"\n\none\ntwo\n\n": 2,
+	} {
+		require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+		lines, err := linesInFile(osFS{}, filename)
+		require.NoError(t, err)
+		require.Equal(t, want, lines, "content %q", content)
+	}
+}
+
 func TestMakeLogDir(t *testing.T) {
 	currentTime = fakeTime
 	dir := time.Now().Format("TestMakeLogDir" + backupTimeFormat)
@@ -236,6 +287,40 @@ func TestUnlimitedSequentialRotate(t *testing.T) {
 	}
 }
 
+func TestSequentialCascadeStopsAtGap(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	for _, n := range []int{1, 2, 4} {
+		b := []byte(fmt.Sprintf("backup %d\n", n))
+		require.NoError(t, ioutil.WriteFile(sequentialName(filename, n), b, 0600))
+	}
+	require.NoError(t, ioutil.WriteFile(filename, []byte("current\n"), 0600))
+
+	l := &Logger{
+		Filename:   filename,
+		MaxBackups: 1000,
+		Sequential: true,
+	}
+	defer l.Close()
+
+	require.NoError(t, l.Rotate())
+
+	// 1 and 2 move up to fill the gap at 3, and 4 is left alone
+	for n, want := range map[int]string{
+		1: "current\n",
+		2: "backup 1\n",
+		3: "backup 2\n",
+		4: "backup 4\n",
+	} {
+		b, err := ioutil.ReadFile(sequentialName(filename, n))
+		require.NoError(t, err)
+		require.Equal(t, want, string(b))
+	}
+	fileCount(dir, 5, t)
+}
+
 func TestFirstWriteRotate(t *testing.T) {
 	currentTime = fakeTime
 	dir := makeTempDir(t)
@@ -463,13 +548,50 @@ func TestOldLogFiles(t *testing.T) {
 	require.Equal(t, t1, files[1].timestamp)
 }
 
+func TestOldLogFilesSequential(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	data := []byte("data\n")
+	require.NoError(t, ioutil.WriteFile(filename, data, 0644))
+	for _, n := range []int{10, 2, 1} {
+		require.NoError(t, ioutil.WriteFile(sequentialName(filename, n), data, 0644))
+	}
+	// neither a directory nor a name that isn't a backup is listed
+	require.NoError(t, os.Mkdir(sequentialName(filename, 3), 0755))
+	require.NoError(t, ioutil.WriteFile(filename+".x", data, 0644))
+
+	// the numbers order the backups, whatever their modification times
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(sequentialName(filename, 1), old, old))
+
+	l := &Logger{Filename: filename, Sequential: true}
+	files, err := l.oldLogFiles()
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	require.Equal(t, []string{
+		sequentialName(filename, 1),
+		sequentialName(filename, 2),
+		sequentialName(filename, 10),
+	}, paths)
+	require.True(t, files[0].timestamp.Equal(old))
+}
+
 func TestTimeFromName(t *testing.T) {
-	l := &Logger{Filename: "/var/log/myfoo/foo.log"}
-	prefix, ext := l.prefixAndExt()
-	require.Equal(t, "2014-05-04T14-44-33.555", l.timeFromName("foo-2014-05-04T14-44-33.555.log", prefix, ext))
-	require.Equal(t, "", l.timeFromName("foo-2014-05-04T14-44-33.555", prefix, ext))
-	require.Equal(t, "", l.timeFromName("2014-05-04T14-44-33.555.log", prefix, ext))
-	require.Equal(t, "", l.timeFromName("foo.log", prefix, ext))
+	dir := filepath.Join("var", "log", "myfoo")
+	filename := filepath.Join(dir, "foo.log")
+	n := TimestampNamer{}
+	ts, ok := n.ParseBackup(filename, filepath.Join(dir, "foo-2014-05-04T14-44-33.555000000.log"))
+	require.True(t, ok)
+	require.Equal(t, time.Date(2014, 5, 4, 14, 44, 33, 555000000, time.UTC), ts)
+	for _, name := range []string{"foo-2014-05-04T14-44-33.555000000", "2014-05-04T14-44-33.555000000.log", "foo.log"} {
+		_, ok := n.ParseBackup(filename, filepath.Join(dir, name))
+		require.False(t, ok, name)
+	}
 }
 
 func TestRotate(t *testing.T) {
@@ -535,6 +657,94 @@ func testRotate(t *testing.T, copyTruncate bool) func(t *testing.T) {
 	}
 }
 
+func TestRotateTo(t *testing.T) {
+	for _, m := range []RotationMechanism{MechanismRenameCreate, MechanismCopyTruncate, MechanismTruncate} {
+		t.Run(m.String(), func(t *testing.T) {
+			currentTime = fakeTime
+			dir := makeTempDir(t)
+			defer os.RemoveAll(dir)
+
+			filename := logFile(dir)
+			l := &Logger{
+				Filename:   filename,
+				MaxBackups: NoBackups,
+				Mechanism:  m,
+			}
+			defer l.Close()
+
+			_, err := l.Write([]byte("boo!\n"))
+			require.NoError(t, err)
+
+			odd := filepath.Join(dir, "archive", "foobar.log.old")
+			require.NoError(t, l.RotateTo(odd))
+			existsWithLines(odd, 1, t)
+			existsWithLines(filename, 0, t)
+
+			// the backup isn't recognized, so it isn't cleaned up
+			newFakeTime(time.Second)
+			_, err = l.RotateSync()
+			require.NoError(t, err)
+			existsWithLines(odd, 1, t)
+		})
+	}
+}
+
+func TestLatency(t *testing.T) {
+	t.Run("MoveCreate", testLatency(t, false))
+	t.Run("CopyTruncate", testLatency(t, true))
+}
+
+func testLatency(t *testing.T, copyTruncate bool) func(t *testing.T) {
+	return func(t *testing.T) {
+		var slept []time.Duration
+		sleep = func(d time.Duration) { slept = append(slept, d) }
+		defer func() { sleep = time.Sleep }()
+
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		l := &Logger{
+			Filename:      logFile(dir),
+			MaxLines:      1,
+			CopyTruncate:  copyTruncate,
+			WriteLatency:  time.Millisecond,
+			RotateLatency: time.Second,
+		}
+		defer l.Close()
+
+		b := []byte("boo!\n")
+		_, err := l.Write(b)
+		require.NoError(t, err)
+		require.Equal(t, []time.Duration{time.Millisecond}, slept)
+
+		newFakeTime(time.Second)
+
+		_, err = l.Write(b)
+		require.NoError(t, err)
+		require.Equal(t, []time.Duration{time.Millisecond, time.Second, time.Millisecond}, slept)
+		existsWithLines(backupFile(dir), 1, t)
+	}
+}
+
+func TestLatencyJitter(t *testing.T) {
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleep = time.Sleep }()
+
+	l := &Logger{LatencyJitter: time.Millisecond}
+	l.delay(0)
+	require.Empty(t, slept)
+
+	for i := 0; i < 100; i++ {
+		l.delay(time.Second)
+	}
+	require.Len(t, slept, 100)
+	for _, d := range slept {
+		require.True(t, d >= time.Second && d < time.Second+time.Millisecond, d.String())
+	}
+}
+
 func TestJson(t *testing.T) {
 	data := []byte(`
 {
@@ -586,7 +796,7 @@ func makeTempDir(t testing.TB) string {
 func existsWithLines(path string, expected int64, t testing.TB) {
 	_, err := os.Stat(path)
 	require.NoError(t, err)
-	act, err := linesInFile(path)
+	act, err := linesInFile(osFS{}, path)
 	require.NoError(t, err)
 	require.Equal(t, expected, act)
 }
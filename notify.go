@@ -0,0 +1,23 @@
+package nanojack
+
+import (
+	"os"
+)
+
+// notify sends NotifySignal to NotifyPID, if both are set, for a rotation
+// that produced the named backup file, and reports the outcome in an
+// EventNotify.
+func (l *Logger) notify(backup string) {
+	if l.NotifyPID <= 0 || l.NotifySignal == nil {
+		return
+	}
+
+	p, err := os.FindProcess(l.NotifyPID)
+	if err == nil {
+		err = p.Signal(l.NotifySignal)
+	}
+	if err != nil {
+		l.debug("error", "notify failed", "pid", l.NotifyPID, "signal", l.NotifySignal, "error", err)
+	}
+	l.emit(Event{Type: EventNotify, Filename: l.filename(), Path: backup, Err: err})
+}
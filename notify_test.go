@@ -0,0 +1,52 @@
+// +build !windows,!js
+
+package nanojack
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	defer signal.Stop(sigs)
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:     logFile(dir),
+		MaxLines:     1,
+		NotifyPID:    os.Getpid(),
+		NotifySignal: syscall.SIGUSR1,
+		Events:       events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	_, err = l.Write([]byte("two\n"))
+	require.NoError(t, err)
+
+	select {
+	case sig := <-sigs:
+		require.Equal(t, syscall.SIGUSR1, sig)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no signal received")
+	}
+
+	e := nextEvent(t, events)
+	require.Equal(t, EventRotate, e.Type)
+	e = nextEvent(t, events)
+	require.Equal(t, EventNotify, e.Type)
+	require.NoError(t, e.Err)
+	require.Equal(t, backupFile(dir), e.Path)
+}
@@ -0,0 +1,31 @@
+package nanojack
+
+// Observer watches the data written by a Logger. Its methods are called with
+// the Logger's lock held, so they must not call the Logger's methods.
+type Observer interface {
+	// Written is called after each write to the active log file filename
+	// with the data that was written, which must not be modified or
+	// retained. With BufferSize set, the data may not have reached the file
+	// yet.
+	Written(filename string, p []byte)
+
+	// Rotated is called after each rotation of filename, with the path of
+	// the new backup, which is empty if no backup was made. Data written
+	// after the call goes to the new active file.
+	Rotated(filename, backup string)
+}
+
+// observeWrite tells the Logger's Observers about the write of p.
+func (l *Logger) observeWrite(p []byte) {
+	for _, o := range l.Observers {
+		o.Written(l.filename(), p)
+	}
+}
+
+// observeRotate tells the Logger's Observers about a rotation that produced
+// the backup at path.
+func (l *Logger) observeRotate(backup string) {
+	for _, o := range l.Observers {
+		o.Rotated(l.filename(), backup)
+	}
+}
@@ -0,0 +1,61 @@
+package nanojack
+
+import (
+	"crypto/sha256"
+	"hash"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// hashObserver keeps a running hash of each generation of the log file.
+type hashObserver struct {
+	h      hash.Hash
+	hashes map[string][]byte
+}
+
+func (o *hashObserver) Written(_ string, p []byte) {
+	o.h.Write(p)
+}
+
+func (o *hashObserver) Rotated(_, backup string) {
+	if backup != "" {
+		o.hashes[backup] = o.h.Sum(nil)
+	}
+	o.h.Reset()
+}
+
+func TestObservers(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	o := &hashObserver{h: sha256.New(), hashes: map[string][]byte{}}
+	l := &Logger{
+		Filename:  logFile(dir),
+		MaxLines:  2,
+		Observers: []Observer{o},
+	}
+	defer l.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		newFakeTime(time.Second)
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	// the third write backs up the first two lines
+	require.Len(t, o.hashes, 1)
+	b, err := ioutil.ReadFile(backupFile(dir))
+	require.NoError(t, err)
+	want := sha256.Sum256(b)
+	require.Equal(t, want[:], o.hashes[backupFile(dir)])
+
+	b, err = ioutil.ReadFile(logFile(dir))
+	require.NoError(t, err)
+	want = sha256.Sum256(b)
+	require.Equal(t, want[:], o.h.Sum(nil))
+}
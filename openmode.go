@@ -0,0 +1,74 @@
+package nanojack
+
+import (
+	"io"
+	"os"
+)
+
+// prepareFile applies the configured open mode, lock and preallocation to a
+// newly opened active log file.
+func (l *Logger) prepareFile() error {
+	if l.SyncWrites || l.DirectIO || l.Append {
+		if err := l.reopen(); err != nil {
+			return err
+		}
+	}
+	if l.LockActive {
+		f, err := osFile(l.file, "LockActive")
+		if err == nil {
+			err = lockFile(f, false)
+		}
+		if err != nil {
+			// leave the file closed, so that the next write tries again
+			_ = l.close()
+			return err
+		}
+	}
+	return l.preallocate()
+}
+
+// reopen replaces the handle of the active log file with one opened with
+// the configured flags, positioned at the end of the file.
+func (l *Logger) reopen() error {
+	flags := os.O_WRONLY
+	if l.SyncWrites {
+		flags |= os.O_SYNC
+	}
+	if l.Append && !l.DirectIO {
+		flags |= os.O_APPEND
+	}
+	if l.DirectIO {
+		if directFlag == 0 {
+			return ErrDirectIOUnsupported
+		}
+		if !realFS(l.fs()) {
+			return needsRealFS("DirectIO")
+		}
+		flags |= directFlag
+	}
+
+	f, err := l.fs().OpenFile(l.filename(), flags, 0)
+	if err != nil {
+		return err
+	}
+	l.file.Close()
+	l.file = f
+
+	if l.DirectIO {
+		return l.loadTail()
+	}
+	_, err = f.Seek(l.size, io.SeekStart)
+	return err
+}
+
+// preallocate allocates Preallocate bytes of disk space for the active file.
+func (l *Logger) preallocate() error {
+	if l.Preallocate <= 0 {
+		return nil
+	}
+	f, err := osFile(l.file, "Preallocate")
+	if err != nil {
+		return err
+	}
+	return preallocate(f, l.Preallocate)
+}
@@ -0,0 +1,39 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppend(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Append:   true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	// another writer appends through a descriptor of its own
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("other\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = l.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "first\nother\nsecond\n", string(content))
+}
@@ -0,0 +1,152 @@
+package nanojack
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Options holds the settings of a Logger that may be changed while it is in
+// use with SetOptions.
+type Options struct {
+	Filename   string            `json:"filename" yaml:"filename"`
+	MaxLines   int               `json:"maxlines" yaml:"maxlines"`
+	MaxBackups int               `json:"maxbackups" yaml:"maxbackups"`
+	Mechanism  RotationMechanism `json:"mechanism" yaml:"mechanism"`
+	Sequential bool              `json:"sequential" yaml:"sequential"`
+}
+
+// Options returns the Logger's current Options.
+func (l *Logger) Options() Options {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Options{
+		Filename:   l.Filename,
+		MaxLines:   l.MaxLines,
+		MaxBackups: l.MaxBackups,
+		Mechanism:  l.mechanism(),
+		Sequential: l.Sequential,
+	}
+}
+
+// SetOptions changes the Logger's settings between writes, so that a
+// long-running Logger can change its behavior without being recreated. The
+// new settings apply from the next write. If the line count is already over
+// a lowered MaxLines, the next write rotates.
+//
+// Changing Filename closes the current log file, releasing any lock, PID
+// file and watcher that belong to it. The new file is opened, or rotated if it is
+// already full, on the next write. Old backups of the previous file are left
+// as they are.
+func (l *Logger) SetOptions(o Options) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if o.Filename != l.Filename {
+		if err := l.releaseFile(); err != nil {
+			return err
+		}
+	}
+
+	l.Filename = o.Filename
+	l.MaxLines = o.MaxLines
+	l.MaxBackups = o.MaxBackups
+	l.Mechanism = o.Mechanism
+	l.CopyTruncate = false
+	l.Sequential = o.Sequential
+	return nil
+}
+
+// SetFilename switches the Logger to writing to filename straight away, as a
+// producer that migrates its log location would. The current log file is
+// closed, releasing any lock, PID file and watcher that belong to it, and
+// the file at filename is opened, or rotated if it is already full. If adopt
+// is set, the current log file is first moved to become the newest backup
+// of filename, named by the naming policy; otherwise it is left where it is.
+// An EventFilename is emitted for the switch.
+func (l *Logger) SetFilename(filename string, adopt bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	old := l.filename()
+	if err := l.releaseFile(); err != nil {
+		return err
+	}
+	l.Filename = filename
+	if filename == old {
+		adopt = false
+	}
+
+	if err := l.acquire(); err != nil {
+		return err
+	}
+	defer l.release()
+
+	if adopt && fileExists(l.fs(), old) {
+		if err := l.fs().MkdirAll(l.dir(), 0744); err != nil {
+			return fmt.Errorf("can't make directories for new logfile: %s", err)
+		}
+		name, err := l.adopt(old)
+		if err != nil {
+			l.emit(Event{Type: EventFilename, Filename: old, Path: l.filename(), Err: err})
+			return err
+		}
+		l.debug("debug", "adopted log file", "path", old, "backup", name)
+	}
+
+	if err := l.writePIDFile(); err != nil {
+		return err
+	}
+	err := l.openExistingOrNew()
+	l.emit(Event{Type: EventFilename, Filename: old, Path: l.filename(), Err: err})
+	return err
+}
+
+// adopt moves the file at path to be the newest backup of the log file, and
+// returns its new name.
+func (l *Logger) adopt(path string) (string, error) {
+	var name string
+	if l.Sequential {
+		nums, err := l.sequentialNumbers()
+		if err != nil {
+			return "", err
+		}
+		present := make(map[int]bool, len(nums))
+		for _, n := range nums {
+			present[n] = true
+		}
+		if err := l.cascade(l.filename(), present); err != nil {
+			return "", err
+		}
+		name = l.sequentialBackup(1)
+	} else {
+		name = l.timestampedBackupName()
+		if err := l.fs().MkdirAll(filepath.Dir(name), 0744); err != nil {
+			return "", fmt.Errorf("can't make directories for backup: %s", err)
+		}
+	}
+	if err := l.fs().Rename(path, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// releaseFile closes the current log file, and releases the lock, PID file
+// and watcher that belong to it. The Logger is switched back from any
+// FallbackFilename.
+func (l *Logger) releaseFile() error {
+	if err := l.stopWatcher(); err != nil {
+		return err
+	}
+	if err := l.close(); err != nil {
+		return err
+	}
+	if err := l.unlock(); err != nil {
+		return err
+	}
+	if err := l.removePIDFile(); err != nil {
+		return err
+	}
+	l.setFile(nil, 0, 0)
+	l.failedOver = false
+	return nil
+}
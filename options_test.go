@@ -0,0 +1,100 @@
+package nanojack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOptions(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxLines:     10,
+		CopyTruncate: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	for i := 0; i < 3; i++ {
+		_, err := l.Write(b)
+		require.NoError(t, err)
+	}
+
+	o := l.Options()
+	require.Equal(t, MechanismCopyTruncate, o.Mechanism)
+
+	// lowering MaxLines below the current count rotates on the next write
+	o.MaxLines = 2
+	o.Mechanism = MechanismRenameCreate
+	require.NoError(t, l.SetOptions(o))
+	newFakeTime(time.Second)
+	_, err := l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(backupFile(dir), 3, t)
+	existsWithLines(filename, 1, t)
+
+	// a new filename is opened on the next write
+	other := filepath.Join(dir, "other.log")
+	o.Filename = other
+	o.Sequential = true
+	require.NoError(t, l.SetOptions(o))
+	require.Equal(t, o, l.Options())
+	for i := 0; i < 3; i++ {
+		_, err = l.Write(b)
+		require.NoError(t, err)
+	}
+	existsWithLines(filename, 1, t)
+	existsWithLines(sequentialName(other, 1), 2, t)
+	existsWithLines(other, 1, t)
+	fileCount(dir, 4, t)
+}
+
+func TestSetFilename(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename: filename,
+		Events:   events,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+
+	// the new file is created straight away, and the old one left alone
+	other := filepath.Join(dir, "other", "other.log")
+	require.NoError(t, l.SetFilename(other, false))
+	existsWithLines(other, 0, t)
+	existsWithLines(filename, 1, t)
+	e := <-events
+	require.Equal(t, EventFilename, e.Type)
+	require.Equal(t, filename, e.Filename)
+	require.Equal(t, other, e.Path)
+
+	// an adopted file becomes the newest backup of the new one
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	newFakeTime(time.Second)
+	require.NoError(t, l.SetFilename(filename, true))
+	existsWithLines(timestampedName(filename, fakeTime()), 1, t)
+	existsWithLines(filename, 1, t)
+	notExist(other, t)
+	<-events
+
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(filename, 2, t)
+}
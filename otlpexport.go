@@ -0,0 +1,157 @@
+package nanojack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultOTLPBatchSize is the number of lines sent per request when
+// OTLPExporter.BatchSize is not set.
+const defaultOTLPBatchSize = 1000
+
+// OTLPExporter is an Archiver that reads each backup and sends its lines as
+// OpenTelemetry logs to an OTLP/HTTP endpoint, using the JSON encoding. It
+// gives a record of exactly what nanojack wrote, to diff against what a
+// collector under test exported. Gzip compressed backups are decompressed.
+//
+// Each line becomes a LogRecord whose body is the line without its newline,
+// with the log.file.name and log.file.path attributes set as the
+// OpenTelemetry Collector's filelog receiver sets them.
+type OTLPExporter struct {
+	// Endpoint is the URL that logs are posted to, such as
+	// http://localhost:4318/v1/logs.
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+
+	// Headers are added to every request, for example for authentication.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+
+	// Resource holds the attributes of the resource that the logs are
+	// reported as coming from.
+	Resource map[string]string `json:"resource" yaml:"resource"`
+
+	// BatchSize is the maximum number of lines sent in one request. It
+	// defaults to 1000.
+	BatchSize int `json:"batchsize" yaml:"batchsize"`
+
+	// Client is the HTTP client used to send requests. It defaults to
+	// http.DefaultClient.
+	Client *http.Client `json:"-" yaml:"-"`
+
+	// Clock, if set, is used in place of the system clock for the observed
+	// time of each record.
+	Clock Clock `json:"-" yaml:"-"`
+}
+
+// Archive implements Archiver.
+func (o *OTLPExporter) Archive(path string) error {
+	return o.archiveFile(osFS{}, path)
+}
+
+// archiveFile exports the lines of the backup at path on fsys.
+func (o *OTLPExporter) archiveFile(fsys FileSystem, path string) error {
+	f, err := fsys.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	size := o.BatchSize
+	if size <= 0 {
+		size = defaultOTLPBatchSize
+	}
+	attrs := []otlpKeyValue{
+		{"log.file.name", otlpString(filepath.Base(path))},
+		{"log.file.path", otlpString(path)},
+	}
+	lr := bufio.NewReader(r)
+	var batch []otlpLogRecord
+	for {
+		line, err := lr.ReadString('\n')
+		if len(line) > 0 {
+			now := strconv.FormatInt(clockNow(o.Clock).UnixNano(), 10)
+			batch = append(batch, otlpLogRecord{
+				ObservedTimeUnixNano: now,
+				Body:                 otlpString(strings.TrimSuffix(line, "\n")),
+				Attributes:           attrs,
+			})
+		}
+		if len(batch) == size || (err != nil && len(batch) > 0) {
+			if perr := o.post(batch); perr != nil {
+				return perr
+			}
+			batch = batch[:0]
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// post sends records to the endpoint in a single request.
+func (o *OTLPExporter) post(records []otlpLogRecord) error {
+	keys := make([]string, 0, len(o.Resource))
+	for k := range o.Resource {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	resource := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		resource = append(resource, otlpKeyValue{k, otlpString(o.Resource[k])})
+	}
+
+	body, err := json.Marshal(otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  otlpResource{Attributes: resource},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: "nanojack"}, LogRecords: records}},
+	}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, o.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range o.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("can't export logs to %s: %s: %s", o.Endpoint, resp.Status, msg)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package nanojack
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// otlpReceiver collects the bodies of log records posted to it.
+type otlpReceiver struct {
+	mu       sync.Mutex
+	requests int
+	bodies   []string
+	files    []string
+}
+
+func (r *otlpReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []struct{ Key string }
+			}
+			ScopeLogs []struct {
+				LogRecords []struct {
+					Body       struct{ StringValue string }
+					Attributes []struct {
+						Key   string
+						Value struct{ StringValue string }
+					}
+				}
+			}
+		}
+	}
+	if req.Header.Get("Authorization") != "Bearer token" || json.NewDecoder(req.Body).Decode(&body) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	for _, rl := range body.ResourceLogs {
+		for _, sl := range rl.ScopeLogs {
+			for _, rec := range sl.LogRecords {
+				r.bodies = append(r.bodies, rec.Body.StringValue)
+				r.files = append(r.files, rec.Attributes[0].Value.StringValue)
+			}
+		}
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	recv := &otlpReceiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	path := filepath.Join(dir, "backup.log")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = f.WriteString("one\ntwo\nthree\nfour\nfive")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	o := &OTLPExporter{
+		Endpoint:  srv.URL + "/v1/logs",
+		Headers:   map[string]string{"Authorization": "Bearer token"},
+		Resource:  map[string]string{"service.name": "nanojack"},
+		BatchSize: 2,
+	}
+	require.NoError(t, o.Archive(path))
+
+	recv.mu.Lock()
+	defer recv.mu.Unlock()
+	require.Equal(t, 3, recv.requests)
+	require.Equal(t, []string{"one", "two", "three", "four", "five"}, recv.bodies)
+	require.Equal(t, "backup.log", recv.files[0])
+}
+
+func TestOTLPExporterGzip(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	recv := &otlpReceiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	path := filepath.Join(dir, "backup.log.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte("one\ntwo\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	o := &OTLPExporter{Endpoint: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	require.NoError(t, o.Archive(path))
+
+	recv.mu.Lock()
+	defer recv.mu.Unlock()
+	require.Equal(t, []string{"one", "two"}, recv.bodies)
+}
+
+func TestOTLPExporterError(t *testing.T) {
+	recv := &otlpReceiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.log")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = f.WriteString("one\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// no authorization header
+	o := &OTLPExporter{Endpoint: srv.URL}
+	require.Error(t, o.Archive(path))
+}
@@ -0,0 +1,168 @@
+package nanojack
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// otlpSeverities are the severities of an OTLPLog, with the most common ones
+// repeated to weight them.
+var otlpSeverities = []struct {
+	number int
+	text   string
+}{
+	{1, "TRACE"}, {5, "DEBUG"}, {5, "DEBUG"},
+	{9, "INFO"}, {9, "INFO"}, {9, "INFO"}, {9, "INFO"}, {9, "INFO"},
+	{13, "WARN"}, {13, "WARN"}, {17, "ERROR"}, {21, "FATAL"},
+}
+
+// OTLPLog is a Generator of OpenTelemetry logs in the OTLP/JSON encoding.
+// Each line is a complete export request holding a single LogRecord, with
+// the resource and scope it came from, a severity, a body, attributes and a
+// trace context, as read by the OpenTelemetry Collector's otlpjsonfile
+// receiver. Everything but the timestamps is drawn from a random source
+// seeded with Seed.
+type OTLPLog struct {
+	// Resource holds the attributes of the resource that produced the logs.
+	// It defaults to a service.name of "nanojack".
+	Resource map[string]string `json:"resource" yaml:"resource"`
+
+	// Scope is the name of the instrumentation scope of the logs. It
+	// defaults to "nanojack".
+	Scope string `json:"scope" yaml:"scope"`
+
+	// Seed seeds the random source that the records are drawn from.
+	Seed int64 `json:"seed" yaml:"seed"`
+
+	// Clock is the source of the time of each record. It defaults to the
+	// system clock.
+	Clock Clock `json:"-" yaml:"-"`
+
+	rng *rand.Rand
+	buf []byte
+}
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano,omitempty"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber,omitempty"`
+	SeverityText         string         `json:"severityText,omitempty"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+	Flags                int            `json:"flags,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func otlpString(s string) otlpAnyValue {
+	return otlpAnyValue{StringValue: &s}
+}
+
+func otlpInt(n int64) otlpAnyValue {
+	s := strconv.FormatInt(n, 10)
+	return otlpAnyValue{IntValue: &s}
+}
+
+func otlpBool(b bool) otlpAnyValue {
+	return otlpAnyValue{BoolValue: &b}
+}
+
+// Line implements Generator.
+func (o *OTLPLog) Line() []byte {
+	if o.rng == nil {
+		o.rng = rand.New(rand.NewSource(o.Seed))
+	}
+
+	resource := o.Resource
+	if resource == nil {
+		resource = map[string]string{"service.name": "nanojack"}
+	}
+	keys := make([]string, 0, len(resource))
+	for k := range resource {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpKeyValue{k, otlpString(resource[k])})
+	}
+	scope := o.Scope
+	if scope == "" {
+		scope = "nanojack"
+	}
+
+	now := strconv.FormatInt(clockNow(o.Clock).UnixNano(), 10)
+	severity := otlpSeverities[o.rng.Intn(len(otlpSeverities))]
+	body := make([]string, 3+o.rng.Intn(5))
+	for i := range body {
+		body[i] = pick(o.rng, words)
+	}
+	var trace [16]byte
+	var span [8]byte
+	o.rng.Read(trace[:])
+	o.rng.Read(span[:])
+
+	record := otlpLogRecord{
+		TimeUnixNano:         now,
+		ObservedTimeUnixNano: now,
+		SeverityNumber:       severity.number,
+		SeverityText:         severity.text,
+		Body:                 otlpString(strings.Join(body, " ")),
+		Attributes: []otlpKeyValue{
+			{"http.method", otlpString(pick(o.rng, accessMethods))},
+			{"http.status_code", otlpInt(int64(accessStatuses[o.rng.Intn(len(accessStatuses))]))},
+			{"net.peer.ip", otlpString(string(appendIPv4(nil, o.rng)))},
+			{"cache.hit", otlpBool(o.rng.Intn(2) == 1)},
+		},
+		TraceID: hex.EncodeToString(trace[:]),
+		SpanID:  hex.EncodeToString(span[:]),
+		Flags:   1,
+	}
+	req := otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  otlpResource{Attributes: attrs},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: scope}, LogRecords: []otlpLogRecord{record}}},
+	}}}
+
+	// The request is made only of strings, numbers and bools, so it always
+	// encodes.
+	b, _ := json.Marshal(req)
+	o.buf = append(o.buf[:0], b...)
+	return o.buf
+}
@@ -0,0 +1,76 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPLog(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1603206245, 0))
+	o := &OTLPLog{
+		Resource: map[string]string{"service.name": "checkout", "host.name": "web-1"},
+		Scope:    "test",
+		Seed:     5,
+		Clock:    clock,
+	}
+
+	var req struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value struct{ StringValue string }
+				}
+			}
+			ScopeLogs []struct {
+				Scope      struct{ Name string }
+				LogRecords []struct {
+					TimeUnixNano   string
+					SeverityNumber int
+					SeverityText   string
+					Body           struct{ StringValue string }
+					Attributes     []struct{ Key string }
+					TraceID        string
+					SpanID         string
+				}
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(o.Line(), &req))
+	require.Len(t, req.ResourceLogs, 1)
+	rl := req.ResourceLogs[0]
+	require.Len(t, rl.Resource.Attributes, 2)
+	require.Equal(t, "host.name", rl.Resource.Attributes[0].Key)
+	require.Equal(t, "web-1", rl.Resource.Attributes[0].Value.StringValue)
+	require.Equal(t, "service.name", rl.Resource.Attributes[1].Key)
+	require.Equal(t, "checkout", rl.Resource.Attributes[1].Value.StringValue)
+
+	require.Len(t, rl.ScopeLogs, 1)
+	require.Equal(t, "test", rl.ScopeLogs[0].Scope.Name)
+	require.Len(t, rl.ScopeLogs[0].LogRecords, 1)
+	rec := rl.ScopeLogs[0].LogRecords[0]
+	require.Equal(t, "1603206245000000000", rec.TimeUnixNano)
+	require.NotZero(t, rec.SeverityNumber)
+	require.NotEmpty(t, rec.SeverityText)
+	require.NotEmpty(t, rec.Body.StringValue)
+	require.Len(t, rec.Attributes, 4)
+	require.Len(t, rec.TraceID, 32)
+	require.Len(t, rec.SpanID, 16)
+}
+
+func TestOTLPLogDeterministic(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1603206245, 0))
+	lines := func(seed int64) []string {
+		o := &OTLPLog{Seed: seed, Clock: clock}
+		var out []string
+		for i := 0; i < 10; i++ {
+			out = append(out, string(o.Line()))
+		}
+		return out
+	}
+	require.Equal(t, lines(1), lines(1))
+	require.NotEqual(t, lines(1), lines(2))
+}
@@ -0,0 +1,47 @@
+package nanojack
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// OutOfOrderClock is a Clock that now and then reports a time earlier than
+// the time it wraps, so that a Generator using it for the timestamps it
+// embeds in lines emits some lines out of order. It is meant for testing
+// pipelines that sort or reject out-of-order records. Every decision is drawn
+// from a random source seeded with Seed.
+type OutOfOrderClock struct {
+	// Clock is the clock whose time is reported. It defaults to the system
+	// clock.
+	Clock Clock `json:"-" yaml:"-"`
+
+	// Frequency is the chance, in the range [0, 1], that a call to Now
+	// reports a time in the past.
+	Frequency float64 `json:"frequency" yaml:"frequency"`
+
+	// MaxBackward is the furthest into the past that a time is moved. The
+	// distance is chosen at random in the range (0, MaxBackward].
+	MaxBackward time.Duration `json:"maxbackward" yaml:"maxbackward"`
+
+	// Seed seeds the random source that drives all decisions.
+	Seed int64 `json:"seed" yaml:"seed"`
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// Now implements Clock.
+func (c *OutOfOrderClock) Now() time.Time {
+	now := clockNow(c.Clock)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rng == nil {
+		c.rng = rand.New(rand.NewSource(c.Seed))
+	}
+	if c.rng.Float64() >= c.Frequency || c.MaxBackward <= 0 {
+		return now
+	}
+	return now.Add(-1 - time.Duration(c.rng.Int63n(int64(c.MaxBackward))))
+}
@@ -0,0 +1,37 @@
+package nanojack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutOfOrderClock(t *testing.T) {
+	start := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+	base := NewFakeClock(start)
+	c := &OutOfOrderClock{Clock: base, Frequency: 0.25, MaxBackward: time.Minute, Seed: 3}
+
+	backward := 0
+	for i := 0; i < 400; i++ {
+		base.Advance(time.Second)
+		now := c.Now()
+		require.False(t, now.After(base.Now()))
+		require.True(t, now.After(base.Now().Add(-time.Minute-1)))
+		if now.Before(base.Now()) {
+			backward++
+		}
+	}
+	require.True(t, backward > 50 && backward < 150, backward)
+}
+
+func TestOutOfOrderTemplate(t *testing.T) {
+	base := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC))
+	tmpl, err := NewTemplate("{ts}", 1)
+	require.NoError(t, err)
+	tmpl.Clock = &OutOfOrderClock{Clock: base, Frequency: 1, MaxBackward: time.Hour, Seed: 1}
+
+	ts, err := time.Parse(time.RFC3339Nano, string(tmpl.Line()))
+	require.NoError(t, err)
+	require.True(t, ts.Before(base.Now()))
+}
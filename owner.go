@@ -0,0 +1,57 @@
+package nanojack
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"strconv"
+)
+
+// ErrOwnerUnsupported is returned when Owner or Group is set on a platform
+// that does not support changing file ownership.
+var ErrOwnerUnsupported = errors.New("nanojack: file ownership is not supported on this platform")
+
+// applyOwner gives the configured Owner and Group ownership of the file at
+// path. Nothing happens if neither is set.
+func (l *Logger) applyOwner(path string) error {
+	if l.Owner == "" && l.Group == "" {
+		return nil
+	}
+	if !realFS(l.fs()) {
+		return needsRealFS("Owner and Group")
+	}
+	uid, gid, err := lookupOwner(l.Owner, l.Group)
+	if err != nil {
+		return err
+	}
+	return setOwner(path, uid, gid)
+}
+
+// lookupOwner resolves owner and group, which may be names or numeric IDs, to
+// a uid and gid. Either is -1, meaning unchanged, if not given.
+func lookupOwner(owner, group string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	if owner != "" {
+		if uid, err = strconv.Atoi(owner); err != nil {
+			u, err := user.Lookup(owner)
+			if err != nil {
+				return 0, 0, fmt.Errorf("can't find owner %q: %s", owner, err)
+			}
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return 0, 0, fmt.Errorf("can't use uid %q of owner %q", u.Uid, owner)
+			}
+		}
+	}
+	if group != "" {
+		if gid, err = strconv.Atoi(group); err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return 0, 0, fmt.Errorf("can't find group %q: %s", group, err)
+			}
+			if gid, err = strconv.Atoi(g.Gid); err != nil {
+				return 0, 0, fmt.Errorf("can't use gid %q of group %q", g.Gid, group)
+			}
+		}
+	}
+	return uid, gid, nil
+}
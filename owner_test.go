@@ -0,0 +1,67 @@
+// +build !windows
+
+package nanojack
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwner(t *testing.T) {
+	owners := map[string][2]int{}
+	os_Chown = func(name string, uid, gid int) error {
+		owners[name] = [2]int{uid, gid}
+		return nil
+	}
+	defer func() { os_Chown = os.Chown }()
+
+	u, err := user.Current()
+	require.NoError(t, err)
+	g, err := user.LookupGroupId(u.Gid)
+	require.NoError(t, err)
+	gid, err := strconv.Atoi(g.Gid)
+	require.NoError(t, err)
+
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 1,
+		Owner:    "4321",
+		Group:    g.Name,
+	}
+	defer l.Close()
+
+	_, err = l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.Equal(t, [2]int{4321, gid}, owners[filename])
+
+	delete(owners, filename)
+	newFakeTime(time.Second)
+	_, err = l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.Equal(t, [2]int{4321, gid}, owners[filename])
+	require.Equal(t, [2]int{4321, gid}, owners[backupFile(dir)])
+}
+
+func TestOwnerUnknown(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Owner:    "no-such-user-nanojack",
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.Error(t, err)
+}
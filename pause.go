@@ -0,0 +1,44 @@
+package nanojack
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPaused is returned by Write while the Logger is paused, if FailWhenPaused
+// is set.
+var ErrPaused = errors.New("nanojack: logger is paused")
+
+// Pause stops the Logger from writing until Resume is called. Once Pause
+// returns, no write is in progress and none will start: writers block until
+// the Logger is resumed, or fail with ErrPaused if FailWhenPaused is set.
+func (l *Logger) Pause() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.paused = true
+}
+
+// Resume allows a paused Logger to write again, and wakes any blocked writers.
+func (l *Logger) Resume() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.paused = false
+	if l.resumed != nil {
+		l.resumed.Broadcast()
+	}
+}
+
+// waitResumed blocks while the Logger is paused. It must be called with the
+// Logger's lock held, which it releases while waiting.
+func (l *Logger) waitResumed() error {
+	for l.paused {
+		if l.FailWhenPaused {
+			return ErrPaused
+		}
+		if l.resumed == nil {
+			l.resumed = sync.NewCond(&l.mu)
+		}
+		l.resumed.Wait()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseBlocks(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	require.NoError(t, err)
+
+	l.Pause()
+
+	done := make(chan error)
+	go func() {
+		_, err := l.Write(b)
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		require.FailNow(t, "write did not block while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	existsWithLines(filename, 1, t)
+
+	l.Resume()
+	require.NoError(t, <-done)
+	existsWithLines(filename, 2, t)
+}
+
+func TestPauseFails(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		FailWhenPaused: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	l.Pause()
+	n, err := l.Write(b)
+	require.Equal(t, ErrPaused, err)
+	require.Equal(t, 0, n)
+	notExist(filename, t)
+
+	l.Resume()
+	_, err = l.Write(b)
+	require.NoError(t, err)
+	existsWithLines(filename, 1, t)
+}
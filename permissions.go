@@ -0,0 +1,39 @@
+package nanojack
+
+import (
+	"os"
+)
+
+// mode returns the permission bits for newly created log files.
+func (l *Logger) mode() os.FileMode {
+	if l.Mode == 0 {
+		return 0644
+	}
+	return l.Mode.Perm()
+}
+
+// activeMode returns the permission bits of the active log file, which are
+// carried over to the files created when it is rotated. It falls back to the
+// configured mode if the file can't be examined.
+func (l *Logger) activeMode() os.FileMode {
+	if !l.StrictPermissions {
+		return 0
+	}
+	info, err := l.fs().Stat(l.filename())
+	if err != nil {
+		return l.mode()
+	}
+	return info.Mode().Perm()
+}
+
+// applyMode sets the permissions of the file at path to exactly mode, if
+// StrictPermissions is set.
+func (l *Logger) applyMode(path string, mode os.FileMode) error {
+	if !l.StrictPermissions {
+		return nil
+	}
+	if !realFS(l.fs()) {
+		return needsRealFS("StrictPermissions")
+	}
+	return os.Chmod(path, mode)
+}
@@ -0,0 +1,68 @@
+// +build !windows
+
+package nanojack
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictPermissions(t *testing.T) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:          filename,
+		MaxLines:          1,
+		Mode:              0664,
+		StrictPermissions: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	requireMode(t, filename, 0664)
+
+	for _, copyTruncate := range []bool{false, true} {
+		l.CopyTruncate = copyTruncate
+		newFakeTime(time.Second)
+		_, err = l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+		requireMode(t, filename, 0664)
+		requireMode(t, backupFile(dir), 0664)
+	}
+}
+
+func TestUmaskPermissions(t *testing.T) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		Mode:     0664,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	requireMode(t, filename, 0600)
+}
+
+func requireMode(t testing.TB, path string, mode os.FileMode) {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, mode, info.Mode().Perm(), path)
+}
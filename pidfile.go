@@ -0,0 +1,84 @@
+package nanojack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrRunning is returned when PIDFile is set and the PID file of the log
+// file names another process that is still running.
+var ErrRunning = errors.New("nanojack: log file is in use by another running process")
+
+// pidFilename returns the path of the Logger's PID file.
+func (l *Logger) pidFilename() string {
+	return l.filename() + ".pid"
+}
+
+// writePIDFile creates the PID file, if PIDFile is set and it has not been
+// created yet. A PID file left behind by a process that is no longer running
+// is replaced.
+func (l *Logger) writePIDFile() error {
+	if !l.PIDFile || l.pidFile != "" {
+		return nil
+	}
+	if err := l.fs().MkdirAll(l.dir(), 0744); err != nil {
+		return err
+	}
+
+	name := l.pidFilename()
+	pid := os.Getpid()
+	for tries := 0; ; tries++ {
+		f, err := l.fs().OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = fmt.Fprintln(f, pid)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				l.fs().Remove(name)
+				return err
+			}
+			l.pidFile = name
+			return nil
+		}
+		if !os.IsExist(err) || tries > 0 {
+			return err
+		}
+
+		other, err := readPIDFile(l.fs(), name)
+		if err == nil && other != pid && processAlive(other) {
+			l.debug("error", "log file is in use", "pidfile", name, "pid", other)
+			return ErrRunning
+		}
+		l.debug("debug", "replacing stale PID file", "pidfile", name, "pid", other)
+		if err := l.fs().Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+}
+
+// removePIDFile removes the PID file created by writePIDFile, if it still
+// holds this process's PID.
+func (l *Logger) removePIDFile() error {
+	if l.pidFile == "" {
+		return nil
+	}
+	name := l.pidFile
+	l.pidFile = ""
+	if pid, err := readPIDFile(l.fs(), name); err != nil || pid != os.Getpid() {
+		return nil
+	}
+	return l.fs().Remove(name)
+}
+
+// readPIDFile returns the process ID held in the PID file at name on fsys.
+func readPIDFile(fsys FileSystem, name string) (int, error) {
+	b, err := readFile(fsys, name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
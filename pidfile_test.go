@@ -0,0 +1,71 @@
+package nanojack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPIDFile(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, PIDFile: true}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	pid, err := readPIDFile(osFS{}, filename + ".pid")
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), pid)
+
+	require.NoError(t, l.Close())
+	notExist(filename+".pid", t)
+}
+
+func TestPIDFileRunning(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	// the parent process is running, and isn't this one
+	filename := logFile(dir)
+	other := []byte(fmt.Sprintln(os.Getppid()))
+	require.NoError(t, ioutil.WriteFile(filename+".pid", other, 0644))
+
+	l := &Logger{Filename: filename, PIDFile: true}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	require.Equal(t, ErrRunning, err)
+	notExist(filename, t)
+
+	// the PID file of another process is left alone
+	require.NoError(t, l.Close())
+	exists(filename+".pid", t)
+}
+
+func TestPIDFileStale(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	filename := logFile(dir)
+	stale := []byte(fmt.Sprintln(cmd.Process.Pid))
+	require.NoError(t, ioutil.WriteFile(filename+".pid", stale, 0644))
+
+	l := &Logger{Filename: filename, PIDFile: true}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	pid, err := readPIDFile(osFS{}, filename + ".pid")
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), pid)
+}
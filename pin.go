@@ -0,0 +1,41 @@
+package nanojack
+
+import (
+	"path/filepath"
+)
+
+// PinBackup protects the backup at path from being deleted by cleanup, so
+// that it survives however many rotations follow. A pinned backup does not
+// count towards MaxBackups. A pinned sequential backup is still renumbered
+// as newer backups are made, and stays pinned under its new name. The path
+// should be given in the same form as the Logger's Filename.
+func (l *Logger) PinBackup(path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.pinned == nil {
+		l.pinned = make(map[string]bool)
+	}
+	l.pinned[filepath.Clean(path)] = true
+}
+
+// UnpinBackup removes the protection given by PinBackup. The backup is
+// subject to cleanup again from the next rotation on.
+func (l *Logger) UnpinBackup(path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.pinned, filepath.Clean(path))
+}
+
+// isPinned reports whether the backup at path is pinned.
+func (l *Logger) isPinned(path string) bool {
+	return l.pinned[filepath.Clean(path)]
+}
+
+// movePin carries any pin on the backup at from over to its new name.
+func (l *Logger) movePin(from, to string) {
+	if !l.isPinned(from) {
+		return
+	}
+	delete(l.pinned, filepath.Clean(from))
+	l.pinned[filepath.Clean(to)] = true
+}
@@ -0,0 +1,74 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinBackup(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	pinned := backupFile(dir)
+	l.PinBackup(pinned)
+
+	for i := 0; i < 3; i++ {
+		newFakeTime(time.Second)
+		result, err := l.RotateSync()
+		require.NoError(t, err)
+		require.NotContains(t, result.Removed, pinned)
+	}
+	exists(pinned, t)
+	// the pinned backup doesn't count towards MaxBackups
+	fileCount(dir, 3, t)
+
+	l.UnpinBackup(pinned)
+	newFakeTime(time.Second)
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Contains(t, result.Removed, pinned)
+}
+
+func TestPinBackupSequential(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxBackups: 2,
+		Sequential: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("pinned\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+	l.PinBackup(sequentialName(filename, 1))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Rotate())
+	}
+
+	// the pinned backup was renumbered past MaxBackups rather than removed
+	b, err := ioutil.ReadFile(sequentialName(filename, 3))
+	require.NoError(t, err)
+	require.Equal(t, "pinned\n", string(b))
+	require.True(t, l.isPinned(sequentialName(filename, 3)))
+	fileCount(dir, 4, t)
+}
@@ -0,0 +1,52 @@
+package nanojack
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// postRotate runs PostRotateCmd, if any, for a rotation that produced the
+// named backup file, and reports the outcome in an EventPostRotate. The
+// command runs in the background, and Close waits for it to finish.
+func (l *Logger) postRotate(backup string) {
+	if len(l.PostRotateCmd) == 0 {
+		return
+	}
+
+	timeout := l.PostRotateTimeout
+	if timeout <= 0 {
+		timeout = defaultPostRotateTimeout
+	}
+	filename := l.filename()
+	args := l.PostRotateCmd
+
+	l.postRotating.Add(1)
+	go func() {
+		defer l.postRotating.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(),
+			"NANOJACK_FILENAME="+filename,
+			"NANOJACK_BACKUP="+backup,
+		)
+		out, err := cmd.CombinedOutput()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		e := Event{
+			Type:     EventPostRotate,
+			Filename: filename,
+			Path:     backup,
+			Output:   string(out),
+			Err:      err,
+		}
+		if cmd.ProcessState != nil {
+			e.ExitCode = cmd.ProcessState.ExitCode()
+		}
+		l.emit(e)
+	}()
+}
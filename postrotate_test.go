@@ -0,0 +1,106 @@
+// +build !windows
+
+package nanojack
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRotateCmd(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:      filename,
+		PostRotateCmd: []string{"sh", "-c", `cat "$NANOJACK_BACKUP"; echo "$NANOJACK_FILENAME"; exit 3`},
+		Events:        events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	// Close waits for the command to finish
+	require.NoError(t, l.Close())
+
+	require.Len(t, events, 2)
+	require.Equal(t, EventRotate, (<-events).Type)
+	e := <-events
+	require.Equal(t, EventPostRotate, e.Type)
+	require.Equal(t, backupFile(dir), e.Path)
+	require.Equal(t, "boo!\n"+filename+"\n", e.Output)
+	require.Equal(t, 3, e.ExitCode)
+	require.Error(t, e.Err)
+}
+
+func TestPostRotateTimeout(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:          logFile(dir),
+		PostRotateCmd:     []string{"sleep", "10"},
+		PostRotateTimeout: 10 * time.Millisecond,
+		Events:            events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	newFakeTime(time.Second)
+	start := time.Now()
+	require.NoError(t, l.Rotate())
+	require.True(t, time.Since(start) < 5*time.Second)
+
+	require.Equal(t, EventRotate, (<-events).Type)
+	e := nextEvent(t, events)
+	require.Equal(t, EventPostRotate, e.Type)
+	require.Equal(t, context.DeadlineExceeded, e.Err)
+}
+
+func TestPostRotateBackground(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	release := filepath.Join(dir, "release")
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:      logFile(dir),
+		PostRotateCmd: []string{"sh", "-c", `while [ ! -e "$0" ]; do sleep 0.01; done`, release},
+		Events:        events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	// the command does not hold up the rotation, or writes after it
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	_, err = l.Write([]byte("foo!\n"))
+	require.NoError(t, err)
+	require.Equal(t, EventRotate, (<-events).Type)
+	require.Empty(t, events)
+
+	require.NoError(t, ioutil.WriteFile(release, nil, 0644))
+	e := nextEvent(t, events)
+	require.Equal(t, EventPostRotate, e.Type)
+	require.NoError(t, e.Err)
+}
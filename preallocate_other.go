@@ -0,0 +1,12 @@
+// +build !linux
+
+package nanojack
+
+import (
+	"os"
+)
+
+// preallocate is not supported on this platform, and does nothing.
+func preallocate(_ *os.File, _ int64) error {
+	return nil
+}
@@ -0,0 +1,41 @@
+// +build linux
+
+package nanojack
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreallocate(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:    filename,
+		MaxLines:    1,
+		Preallocate: 1 << 20,
+	}
+	defer l.Close()
+
+	for i := 0; i < 2; i++ {
+		newFakeTime(time.Second)
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+
+		info, err := os.Stat(filename)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), info.Size())
+		stat := info.Sys().(*syscall.Stat_t)
+		if stat.Blocks*512 < 1<<20 {
+			t.Skip("file system does not support preallocation")
+		}
+	}
+	existsWithLines(filename, 1, t)
+}
@@ -0,0 +1,13 @@
+// +build !windows
+
+package nanojack
+
+import (
+	"syscall"
+)
+
+// processAlive reports whether a process with the given ID is running.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,46 @@
+package nanojack
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// words is the vocabulary used by generators that need random words.
+var words = []string{
+	"alpha", "bravo", "cache", "delta", "error", "fetch", "gateway", "handler",
+	"index", "job", "kernel", "lookup", "mount", "network", "order", "parse",
+	"query", "request", "session", "timeout", "update", "volume", "worker",
+	"yield", "zone",
+}
+
+// pick returns a random element of choices.
+func pick(rng *rand.Rand, choices []string) string {
+	return choices[rng.Intn(len(choices))]
+}
+
+// appendUUID appends a random version 4 UUID to b.
+func appendUUID(b []byte, rng *rand.Rand) []byte {
+	const hex = "0123456789abcdef"
+	var u [16]byte
+	rng.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	for i, c := range u {
+		if i == 4 || i == 6 || i == 8 || i == 10 {
+			b = append(b, '-')
+		}
+		b = append(b, hex[c>>4], hex[c&0x0f])
+	}
+	return b
+}
+
+// appendIPv4 appends a random IPv4 address to b.
+func appendIPv4(b []byte, rng *rand.Rand) []byte {
+	for i := 0; i < 4; i++ {
+		if i > 0 {
+			b = append(b, '.')
+		}
+		b = strconv.AppendInt(b, int64(rng.Intn(256)), 10)
+	}
+	return b
+}
@@ -0,0 +1,74 @@
+package nanojack
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimit paces writes to a sustained rate using a token bucket. The bucket
+// starts full, holds up to Burst tokens, and refills at Limit tokens per
+// second. Each write takes one token, or one token per byte if Bytes is set,
+// and waits until the bucket has paid off any deficit it leaves behind.
+type RateLimit struct {
+	// Limit is the sustained rate, in lines per second or in bytes per
+	// second if Bytes is set. A zero Limit disables rate limiting.
+	Limit float64 `json:"limit" yaml:"limit"`
+
+	// Burst is the number of lines, or bytes if Bytes is set, that may be
+	// written at once after a period of inactivity. It defaults to one
+	// second's worth at Limit.
+	Burst float64 `json:"burst" yaml:"burst"`
+
+	// Bytes selects a limit in bytes per second rather than lines per second.
+	Bytes bool `json:"bytes" yaml:"bytes"`
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// wait blocks, using sleep, until a write of n bytes made at time now is
+// allowed by the limit.
+func (r *RateLimit) wait(n int, now time.Time, sleep func(time.Duration)) {
+	r.mu.Lock()
+	if r.Limit <= 0 {
+		r.mu.Unlock()
+		return
+	}
+	burst := r.Burst
+	if burst <= 0 {
+		burst = r.Limit
+	}
+	if r.last.IsZero() {
+		r.tokens = burst
+	} else if elapsed := now.Sub(r.last); elapsed > 0 {
+		r.tokens += elapsed.Seconds() * r.Limit
+	}
+	if r.tokens > burst {
+		r.tokens = burst
+	}
+	r.last = now
+
+	cost := 1.0
+	if r.Bytes {
+		cost = float64(n)
+	}
+	r.tokens -= cost
+	var d time.Duration
+	if r.tokens < 0 {
+		d = time.Duration(-r.tokens / r.Limit * float64(time.Second))
+	}
+	r.mu.Unlock()
+
+	if d > 0 {
+		sleep(d)
+	}
+}
+
+// SetLimit changes the sustained rate of a RateLimit that may be in use. A
+// zero limit disables rate limiting.
+func (r *RateLimit) SetLimit(limit float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Limit = limit
+}
@@ -0,0 +1,77 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSleep records sleeps and advances the fake clock instead of sleeping.
+func fakeSleep(slept *[]time.Duration) func(time.Duration) {
+	return func(d time.Duration) {
+		*slept = append(*slept, d)
+		newFakeTime(d)
+	}
+}
+
+func TestRateLimitLines(t *testing.T) {
+	var slept []time.Duration
+	sleep = fakeSleep(&slept)
+	defer func() { sleep = time.Sleep }()
+
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:  logFile(dir),
+		MaxLines:  100,
+		RateLimit: &RateLimit{Limit: 10, Burst: 3},
+	}
+	defer l.Close()
+
+	start := fakeTime()
+	for i := 0; i < 13; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+
+	// the burst is written immediately, and the remaining 10 lines take a
+	// second at 10 lines per second.
+	require.Len(t, slept, 10)
+	require.Equal(t, time.Second, fakeTime().Sub(start).Round(time.Millisecond))
+	existsWithLines(logFile(dir), 13, t)
+
+	// after an idle period the bucket is full again, but no fuller
+	newFakeTime(time.Minute)
+	slept = nil
+	for i := 0; i < 4; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	require.Len(t, slept, 1)
+}
+
+func TestRateLimitBytes(t *testing.T) {
+	var slept []time.Duration
+	sleep = fakeSleep(&slept)
+	defer func() { sleep = time.Sleep }()
+
+	currentTime = fakeTime
+	r := &RateLimit{Limit: 100, Bytes: true}
+
+	// the default burst is one second's worth
+	r.wait(100, fakeTime(), sleep)
+	require.Empty(t, slept)
+
+	r.wait(50, fakeTime(), sleep)
+	require.Equal(t, []time.Duration{500 * time.Millisecond}, slept)
+
+	// a zero limit never waits
+	slept = nil
+	r = &RateLimit{}
+	r.wait(1000, fakeTime(), sleep)
+	require.Empty(t, slept)
+}
@@ -0,0 +1,47 @@
+package nanojack
+
+// RotationReason says why a rotation happened. It is given as the Reason of
+// the rotation's EventRotate, and of the BackupInfo of the backup it made.
+type RotationReason string
+
+const (
+	// ReasonMaxLines is a rotation made because the active log file held
+	// MaxLines lines.
+	ReasonMaxLines RotationReason = "max-lines"
+
+	// ReasonInterval is a rotation made because RotateInterval had passed.
+	ReasonInterval RotationReason = "interval"
+
+	// ReasonManual is a rotation requested through Rotate, RotateTo or
+	// RotateSync.
+	ReasonManual RotationReason = "manual"
+
+	// ReasonExternal is a rotation requested from outside the process, by a
+	// signal installed with HandleSignals or through the control socket.
+	ReasonExternal RotationReason = "external"
+
+	// ReasonTrigger is a rotation made because a line matched one of the
+	// Triggers.
+	ReasonTrigger RotationReason = "trigger"
+
+	// ReasonChaos is a rotation made at random by Chaos.
+	ReasonChaos RotationReason = "chaos"
+
+	// ReasonExisting is a rotation of a log file that was found on disk when
+	// the Logger opened it, and could not be continued, because it was full
+	// or because it is compressed with Gzip.
+	ReasonExisting RotationReason = "existing"
+)
+
+// requestRotate rotates the log file for reason, as Rotate does for
+// ReasonManual.
+func (l *Logger) requestRotate(reason RotationReason) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.acquire(); err != nil {
+		return err
+	}
+	defer l.release()
+	l.debug("debug", "rotating", "reason", string(reason))
+	return l.rotate(reason)
+}
@@ -0,0 +1,44 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotationReason(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	events := make(chan Event, 10)
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   1,
+		Sequential: true,
+		Events:     events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	_, err = l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+
+	var reasons []string
+	for len(events) > 0 {
+		if e := <-events; e.Type == EventRotate {
+			reasons = append(reasons, e.Reason)
+		}
+	}
+	require.Equal(t, []string{"max-lines", "manual"}, reasons)
+
+	// the reason follows a sequential backup as it is renumbered
+	backups, err := l.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	require.Equal(t, ReasonManual, backups[0].Reason)
+	require.Equal(t, ReasonMaxLines, backups[1].Reason)
+}
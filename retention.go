@@ -0,0 +1,113 @@
+package nanojack
+
+// Retention reasons, recorded in the journal for each backup that cleanup
+// considers.
+const (
+	retainPinned = "pinned"
+	retainKept   = "kept"
+	expireCount  = "count"
+	expireAge    = "age"
+	expireSize   = "size"
+)
+
+// retainDecision is the outcome of the retention settings for one backup.
+type retainDecision struct {
+	logInfo
+	reason string
+}
+
+// expired reports whether the backup is to be deleted.
+func (d retainDecision) expired() bool {
+	return d.reason != retainPinned && d.reason != retainKept
+}
+
+// retention evaluates MaxBackups, MaxBackupAge and MaxTotalSize together
+// against the backups, newest first, and returns a decision for each. A
+// pinned backup is always kept and counts against none of the limits. Of the
+// rest, a backup after the first MaxBackups is deleted for its count, then
+// one older than MaxBackupAge for its age, then one that would take the
+// total size of the newer backups past MaxTotalSize for its size. A backup
+// deleted for its count or age does not use up any of MaxTotalSize.
+func (l *Logger) retention() ([]retainDecision, error) {
+	if l.MaxBackups == 0 && l.MaxBackupAge == 0 && l.MaxTotalSize == 0 {
+		return nil, nil
+	}
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := l.MaxBackups
+	if keep < 0 {
+		keep = 0
+	}
+	cutoff := l.now().Add(-l.MaxBackupAge)
+
+	var n int
+	var size int64
+	decisions := make([]retainDecision, 0, len(files))
+	for _, f := range files {
+		d := retainDecision{logInfo: f, reason: retainKept}
+		if l.isPinned(f.path) {
+			d.reason = retainPinned
+			decisions = append(decisions, d)
+			continue
+		}
+		n++
+		switch {
+		case l.MaxBackups != 0 && n > keep:
+			d.reason = expireCount
+		case l.MaxBackupAge > 0 && f.timestamp.Before(cutoff):
+			d.reason = expireAge
+		default:
+			size += f.Size()
+			if l.MaxTotalSize > 0 && size > l.MaxTotalSize {
+				d.reason = expireSize
+			}
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, nil
+}
+
+// expired returns the backups that the retention settings would have cleanup
+// delete.
+func (l *Logger) expired() ([]logInfo, error) {
+	decisions, err := l.retention()
+	if err != nil {
+		return nil, err
+	}
+	return expiredFiles(decisions), nil
+}
+
+// expiredFiles returns the backups of decisions that are to be deleted.
+func expiredFiles(decisions []retainDecision) []logInfo {
+	var files []logInfo
+	for _, d := range decisions {
+		if d.expired() {
+			files = append(files, d.logInfo)
+		}
+	}
+	return files
+}
+
+// traceRetention records each decision in the JournalFile, so that it can be
+// seen why a backup was deleted or kept. The trace only goes to the journal,
+// not to Events.
+func (l *Logger) traceRetention(decisions []retainDecision) {
+	if l.JournalFile == "" {
+		return
+	}
+	now := l.now()
+	for _, d := range decisions {
+		l.record(Event{
+			Type:     EventRetain,
+			Time:     now,
+			Filename: l.filename(),
+			Path:     d.path,
+			Reason:   d.reason,
+			DryRun:   l.DryRun,
+		})
+	}
+}
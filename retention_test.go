@@ -0,0 +1,77 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetention(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		MaxBackups:   4,
+		MaxBackupAge: 210 * time.Second,
+		MaxTotalSize: 12,
+	}
+	defer l.Close()
+
+	// six backups of 5 bytes, a minute apart, the newest of them pinned
+	var backups []string
+	for i := 0; i < 6; i++ {
+		newFakeTime(time.Minute)
+		path := backupFile(dir)
+		require.NoError(t, ioutil.WriteFile(path, []byte("boo!\n"), 0644))
+		backups = append([]string{path}, backups...)
+	}
+	l.PinBackup(backups[0])
+
+	decisions, err := l.retention()
+	require.NoError(t, err)
+	var reasons []string
+	for _, d := range decisions {
+		reasons = append(reasons, d.reason)
+	}
+	// the pinned backup doesn't count against the limits, so the 1 and 2
+	// minute old backups fit in the size, the 3 minute old one doesn't, the
+	// 4 minute old one is past the age, and the 5 minute old one the count
+	require.Equal(t, []string{"pinned", "kept", "kept", "size", "age", "count"}, reasons)
+
+	expired, err := l.Expired()
+	require.NoError(t, err)
+	require.Equal(t, backups[3:], expired)
+}
+
+func TestRetentionSequentialSize(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		Sequential:   true,
+		MaxTotalSize: 10,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+	_, err = l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Rotate())
+	_, err = l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	result, err := l.RotateSync()
+	require.NoError(t, err)
+	require.Equal(t, []string{sequentialName(filename, 3)}, result.Removed)
+	fileCount(dir, 3, t)
+}
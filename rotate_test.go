@@ -4,8 +4,6 @@ package nanojack_test
 
 import (
 	"log"
-	"os"
-	"os/signal"
 	"syscall"
 
 	"github.com/observiq/nanojack"
@@ -15,13 +13,5 @@ import (
 func ExampleLogger_Rotate() {
 	l := &nanojack.Logger{}
 	log.SetOutput(l)
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGHUP)
-
-	go func() {
-		for {
-			<-c
-			l.Rotate()
-		}
-	}()
+	l.HandleSignals(syscall.SIGHUP)
 }
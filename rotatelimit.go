@@ -0,0 +1,65 @@
+package nanojack
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRotationLimit is returned when a rotation would exceed
+// MaxRotationsPerMinute and RotationLimit is RotationLimitError.
+var ErrRotationLimit = errors.New("nanojack: too many rotations per minute")
+
+// RotationLimitPolicy selects what a Logger does when a rotation would
+// exceed MaxRotationsPerMinute.
+type RotationLimitPolicy int
+
+const (
+	// RotationLimitBlock delays the rotation, and the write that caused it,
+	// until it is within the limit. This is the default.
+	RotationLimitBlock RotationLimitPolicy = iota
+
+	// RotationLimitOverflow skips the rotation, so that lines keep being
+	// written to the active log file beyond MaxLines until a rotation is
+	// allowed again.
+	RotationLimitOverflow
+
+	// RotationLimitError skips the rotation, and fails the write that caused
+	// it with ErrRotationLimit.
+	RotationLimitError
+)
+
+// limitRotation applies MaxRotationsPerMinute to a rotation of the active
+// log file that is about to happen, recording it if it goes ahead. It
+// reports whether the rotation may go ahead, and if not, the error to fail
+// with.
+func (l *Logger) limitRotation() (bool, error) {
+	if l.MaxRotationsPerMinute <= 0 || l.file == nil {
+		return true, nil
+	}
+
+	for {
+		now := l.now()
+		// forget the rotations that are more than a minute old
+		i := 0
+		for i < len(l.recentRotations) && now.Sub(l.recentRotations[i]) >= time.Minute {
+			i++
+		}
+		l.recentRotations = l.recentRotations[i:]
+		if len(l.recentRotations) < l.MaxRotationsPerMinute {
+			l.recentRotations = append(l.recentRotations, now)
+			return true, nil
+		}
+
+		switch l.RotationLimit {
+		case RotationLimitOverflow:
+			l.debug("debug", "skipping rotation", "reason", "rotation limit")
+			return false, nil
+		case RotationLimitError:
+			l.debug("error", "rotation limit exceeded", "limit", l.MaxRotationsPerMinute)
+			return false, ErrRotationLimit
+		}
+		wait := time.Minute - now.Sub(l.recentRotations[0])
+		l.debug("debug", "delaying rotation", "reason", "rotation limit", "wait", wait)
+		l.sleep(wait)
+	}
+}
@@ -0,0 +1,76 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotationLimit(t *testing.T) {
+	t.Run("Block", func(t *testing.T) {
+		clock, l, dir := newLimitedLogger(t, RotationLimitBlock)
+		defer os.RemoveAll(dir)
+		defer l.Close()
+
+		start := clock.Now()
+		writeLimited(t, clock, l, 4)
+		// the third rotation waited until the first was a minute old
+		require.True(t, clock.Now().Sub(start) >= time.Minute)
+		existsWithLines(logFile(dir), 1, t)
+		fileCount(dir, 4, t)
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		clock, l, dir := newLimitedLogger(t, RotationLimitOverflow)
+		defer os.RemoveAll(dir)
+		defer l.Close()
+
+		writeLimited(t, clock, l, 4)
+		existsWithLines(logFile(dir), 2, t)
+		fileCount(dir, 3, t)
+
+		// rotations are allowed again once the minute has passed
+		clock.Advance(time.Minute)
+		writeLimited(t, clock, l, 1)
+		existsWithLines(logFile(dir), 1, t)
+		fileCount(dir, 4, t)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		clock, l, dir := newLimitedLogger(t, RotationLimitError)
+		defer os.RemoveAll(dir)
+		defer l.Close()
+
+		writeLimited(t, clock, l, 3)
+		clock.Advance(time.Second)
+		_, err := l.Write([]byte("boo!\n"))
+		require.Equal(t, ErrRotationLimit, err)
+		existsWithLines(logFile(dir), 1, t)
+	})
+}
+
+// newLimitedLogger returns a Logger that rotates after every line, but at
+// most twice a minute.
+func newLimitedLogger(t *testing.T, policy RotationLimitPolicy) (*FakeClock, *Logger, string) {
+	dir := makeTempDir(t)
+	clock := NewFakeClock(fakeTime())
+	l := &Logger{
+		Filename:              logFile(dir),
+		MaxLines:              1,
+		MaxRotationsPerMinute: 2,
+		RotationLimit:         policy,
+		Clock:                 clock,
+	}
+	return clock, l, dir
+}
+
+// writeLimited writes n lines to l a second apart.
+func writeLimited(t *testing.T, clock *FakeClock, l *Logger, n int) {
+	for i := 0; i < n; i++ {
+		clock.Advance(time.Second)
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,300 @@
+package nanojack
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Upload is an Archiver that uploads each backup to an Amazon S3 bucket,
+// and optionally has the Logger delete the local copy, emulating producers
+// that rotate their logs and ship them away.
+//
+// Requests are signed with AWS Signature Version 4. Credentials are taken
+// from the environment variables AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+// and AWS_SESSION_TOKEN, or failing that from the shared credentials file
+// named by AWS_SHARED_CREDENTIALS_FILE, which defaults to ~/.aws/credentials,
+// using the profile named by AWS_PROFILE, which defaults to "default".
+// Requests are signed with the system clock even when the Logger has a
+// Clock, since S3 rejects requests whose time is too far from its own.
+type S3Upload struct {
+	// Bucket is the name of the bucket to upload to.
+	Bucket string `json:"bucket" yaml:"bucket"`
+
+	// Prefix is prepended to the base name of each backup to form its key.
+	// Sequential backups are renamed as they age, so their uploads overwrite
+	// one another unless the backups are deleted locally.
+	Prefix string `json:"prefix" yaml:"prefix"`
+
+	// Region is the region of the bucket. It defaults to the AWS_REGION or
+	// AWS_DEFAULT_REGION environment variable, or else us-east-1.
+	Region string `json:"region" yaml:"region"`
+
+	// Endpoint, if set, is the base URL of an S3-compatible service to use
+	// instead of AWS, such as a local MinIO. Buckets are addressed by path
+	// rather than by host name.
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+
+	// DeleteLocal removes each backup once it has been uploaded. It only has
+	// an effect when the S3Upload is set as a Logger's S3 field.
+	DeleteLocal bool `json:"deletelocal" yaml:"deletelocal"`
+
+	// Client is the HTTP client used for uploads. It defaults to
+	// http.DefaultClient.
+	Client *http.Client `json:"-" yaml:"-"`
+}
+
+// s3Credentials are the credentials used to sign S3 requests.
+type s3Credentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// Archive implements Archiver by uploading the backup at path.
+func (s *S3Upload) Archive(path string) error {
+	return s.archiveFile(osFS{}, path)
+}
+
+// archiveFile uploads the backup at path on fsys.
+func (s *S3Upload) archiveFile(fsys FileSystem, path string) error {
+	f, err := fsys.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// requests are always signed with the real time, since S3 rejects
+	// requests whose time is too far from its own
+	return s.put(f, filepath.Base(path), time.Now())
+}
+
+// put uploads the contents of f under the key formed from name.
+func (s *S3Upload) put(f io.ReadSeeker, name string, now time.Time) error {
+	creds, err := s3EnvCredentials()
+	if err != nil {
+		return err
+	}
+	region := s.region()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := s.Prefix + name
+	var u string
+	if s.Endpoint != "" {
+		u = strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + s3Escape(key)
+	} else {
+		u = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, region, s3Escape(key))
+	}
+	req, err := http.NewRequest(http.MethodPut, u, ioutil.NopCloser(f))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	s3Sign(req, hex.EncodeToString(h.Sum(nil)), creds, region, now)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("can't upload %s to bucket %s: %s: %s", key, s.Bucket, resp.Status, body)
+	}
+	return nil
+}
+
+// region returns the region of the bucket.
+func (s *S3Upload) region() string {
+	for _, r := range []string{s.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")} {
+		if r != "" {
+			return r
+		}
+	}
+	return "us-east-1"
+}
+
+// s3EnvCredentials finds credentials in the environment or the shared
+// credentials file.
+func s3EnvCredentials() (s3Credentials, error) {
+	creds := s3Credentials{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.accessKey != "" && creds.secretKey != "" {
+		return creds, nil
+	}
+
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return creds, err
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return creds, fmt.Errorf("can't find AWS credentials: %s", err)
+	}
+	defer f.Close()
+
+	creds = s3Credentials{}
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if section != profile || len(kv) != 2 {
+			continue
+		}
+		v := strings.TrimSpace(kv[1])
+		switch strings.TrimSpace(kv[0]) {
+		case "aws_access_key_id":
+			creds.accessKey = v
+		case "aws_secret_access_key":
+			creds.secretKey = v
+		case "aws_session_token":
+			creds.sessionToken = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return creds, err
+	}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return creds, errors.New("can't find AWS credentials for profile " + profile)
+	}
+	return creds, nil
+}
+
+// s3Escape escapes a key for use in a URL path, as S3 expects.
+func s3Escape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Sign signs req with AWS Signature Version 4 for the S3 service. Every
+// header already set on req is signed, along with the host, date, payload
+// hash and session token headers that s3Sign adds.
+func s3Sign(req *http.Request, payloadHash string, creds s3Credentials, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := []byte("AWS4" + creds.secretKey)
+	for _, part := range []string{day, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKey, scope, signed, signature))
+}
+
+// s3CanonicalQuery returns the query parameters in canonical form.
+func s3CanonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, s3EscapeQuery(k)+"="+s3EscapeQuery(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapeQuery escapes a query parameter name or value, as S3 expects.
+func s3EscapeQuery(s string) string {
+	return strings.Replace(s3Escape(s), "/", "%2F", -1)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,77 @@
+package nanojack
+
+import (
+	"io"
+	"time"
+)
+
+// Segment is one part of a Load's Schedule: a period during which lines are
+// written at a steady rate.
+type Segment struct {
+	// Duration is the length of the segment.
+	Duration time.Duration `json:"duration" yaml:"duration"`
+
+	// Rate is the number of lines written per second during the segment. A
+	// zero Rate makes the segment idle.
+	Rate float64 `json:"rate" yaml:"rate"`
+}
+
+// runSchedule writes up to n lines to w following the Schedule, or lines
+// until the Schedule ends if n is not positive. The lines of each segment are
+// written at fixed offsets from its start, so that the same Schedule always
+// produces the same traffic, however long the writes take.
+func (ld *Load) runSchedule(w io.Writer, n int) error {
+	start := clockNow(ld.Clock)
+	var buf []byte
+	written := 0
+	for _, seg := range ld.Schedule {
+		end := start.Add(seg.Duration)
+		for k := 0; seg.Rate > 0 && (n <= 0 || written < n); k++ {
+			at := start.Add(time.Duration(float64(k) * float64(time.Second) / seg.Rate))
+			if !at.Before(end) {
+				break
+			}
+			ld.sleepUntil(at)
+			paused, stopped := ld.hold()
+			if stopped {
+				return nil
+			}
+			if paused > 0 || clockNow(ld.Clock).Before(at) {
+				// the line is due again after the pause
+				start, end = start.Add(paused), end.Add(paused)
+				k--
+				continue
+			}
+			buf = ld.line(buf)
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+			written++
+		}
+		if n > 0 && written >= n {
+			return nil
+		}
+		// an idle segment, or the rest of one, is paused and stopped too
+		for {
+			ld.sleepUntil(end)
+			paused, stopped := ld.hold()
+			if stopped {
+				return nil
+			}
+			end = end.Add(paused)
+			if !clockNow(ld.Clock).Before(end) {
+				break
+			}
+		}
+		start = end
+	}
+	return nil
+}
+
+// sleepUntil sleeps on the Clock until t, if t has not yet passed, or until
+// the Load is paused or stopped.
+func (ld *Load) sleepUntil(t time.Time) {
+	if d := t.Sub(clockNow(ld.Clock)); d > 0 {
+		ld.sleep(d)
+	}
+}
@@ -0,0 +1,10 @@
+// +build !js
+
+package nanojack
+
+import (
+	"syscall"
+)
+
+// sighup is the signal handled by HandleSignals by default.
+var sighup = syscall.SIGHUP
@@ -0,0 +1,9 @@
+package nanojack
+
+import (
+	"syscall"
+)
+
+// sighup is the signal handled by HandleSignals by default. js/wasm has no
+// SIGHUP, nor delivers any signal, so its number stands in for it.
+var sighup = syscall.Signal(1)
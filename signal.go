@@ -0,0 +1,69 @@
+package nanojack
+
+import (
+	"os"
+	"os/signal"
+)
+
+// signalHandler rotates a Logger whenever one of a set of signals arrives.
+type signalHandler struct {
+	c    chan os.Signal
+	done chan struct{}
+}
+
+// HandleSignals installs a handler that rotates the log file whenever the
+// process receives one of the given signals, or SIGHUP if none are given. The
+// outcome of each rotation is reported in an EventSignal. Calling
+// HandleSignals again replaces the previous handler, and Close removes it.
+func (l *Logger) HandleSignals(sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{sighup}
+	}
+	h := &signalHandler{
+		c:    make(chan os.Signal, 1),
+		done: make(chan struct{}),
+	}
+
+	l.mu.Lock()
+	l.stopSignals()
+	l.signals = h
+	l.mu.Unlock()
+
+	signal.Notify(h.c, sigs...)
+	go l.handleSignals(h)
+}
+
+func (l *Logger) handleSignals(h *signalHandler) {
+	for {
+		select {
+		case <-h.c:
+		case <-h.done:
+			return
+		}
+
+		l.mu.Lock()
+		if l.signals != h {
+			// the handler was removed while waiting for the lock
+			l.mu.Unlock()
+			return
+		}
+		err := l.acquire()
+		if err == nil {
+			l.debug("debug", "rotating", "reason", "signal")
+			err = l.rotate(ReasonExternal)
+			l.release()
+		}
+		l.emit(Event{Type: EventSignal, Filename: l.filename(), Err: err})
+		l.mu.Unlock()
+	}
+}
+
+// stopSignals removes the signal handler, if any.
+func (l *Logger) stopSignals() {
+	if l.signals == nil {
+		return
+	}
+	signal.Stop(l.signals.c)
+	close(l.signals.done)
+	l.signals = nil
+}
@@ -0,0 +1,42 @@
+// +build !windows,!js
+
+package nanojack
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSignals(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename: logFile(dir),
+		Events:   events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	newFakeTime(time.Second)
+	l.HandleSignals(syscall.SIGUSR1)
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	require.Equal(t, EventRotate, nextEvent(t, events).Type)
+	e := nextEvent(t, events)
+	require.Equal(t, EventSignal, e.Type)
+	require.NoError(t, e.Err)
+	existsWithLines(backupFile(dir), 1, t)
+	existsWithLines(logFile(dir), 0, t)
+
+	require.NoError(t, l.Close())
+	require.Nil(t, l.signals)
+}
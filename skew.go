@@ -0,0 +1,63 @@
+package nanojack
+
+import (
+	"sync"
+	"time"
+)
+
+// ClockJump is an abrupt step in the time reported by a SkewedClock, such as
+// the correction made when NTP resynchronizes a badly drifted clock.
+type ClockJump struct {
+	// After is how long after the SkewedClock is first read the jump occurs.
+	After time.Duration `json:"after" yaml:"after"`
+
+	// By is the size of the jump, which is backwards if negative.
+	By time.Duration `json:"by" yaml:"by"`
+}
+
+// SkewedClock is a Clock whose time is skewed relative to the time it wraps,
+// so that a Generator using it for the timestamps it embeds in lines
+// disagrees with the Logger's own clock, which names the backups. It
+// reproduces the clock offsets, drift and NTP jumps that parsers see in the
+// wild.
+type SkewedClock struct {
+	// Clock is the clock whose time is skewed. It defaults to the system
+	// clock.
+	Clock Clock `json:"-" yaml:"-"`
+
+	// Offset is a constant added to every time.
+	Offset time.Duration `json:"offset" yaml:"offset"`
+
+	// Drift is the rate at which the skewed time gains on the wrapped time,
+	// as a fraction of the time elapsed since the SkewedClock was first
+	// read. A Drift of 0.001 gains a millisecond every second, and a
+	// negative Drift loses time.
+	Drift float64 `json:"drift" yaml:"drift"`
+
+	// Jumps are abrupt steps in the skewed time. Each one applies from its
+	// scheduled time onwards.
+	Jumps []ClockJump `json:"jumps" yaml:"jumps"`
+
+	mu    sync.Mutex
+	start time.Time
+}
+
+// Now implements Clock.
+func (c *SkewedClock) Now() time.Time {
+	now := clockNow(c.Clock)
+
+	c.mu.Lock()
+	if c.start.IsZero() {
+		c.start = now
+	}
+	elapsed := now.Sub(c.start)
+	c.mu.Unlock()
+
+	skew := c.Offset + time.Duration(float64(elapsed)*c.Drift)
+	for _, j := range c.Jumps {
+		if elapsed >= j.After {
+			skew += j.By
+		}
+	}
+	return now.Add(skew)
+}
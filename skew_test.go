@@ -0,0 +1,54 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkewedClock(t *testing.T) {
+	start := time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC)
+	base := NewFakeClock(start)
+	c := &SkewedClock{
+		Clock:  base,
+		Offset: 2 * time.Second,
+		Drift:  0.01,
+		Jumps:  []ClockJump{{After: time.Minute, By: -time.Hour}},
+	}
+
+	require.Equal(t, start.Add(2*time.Second), c.Now())
+
+	base.Advance(10 * time.Second)
+	require.Equal(t, start.Add(10*time.Second+2*time.Second+100*time.Millisecond), c.Now())
+
+	base.Advance(50 * time.Second)
+	require.Equal(t, start.Add(time.Minute+2*time.Second+600*time.Millisecond-time.Hour), c.Now())
+}
+
+func TestSkewedTimestamps(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	base := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC))
+	tmpl, err := NewTemplate("{ts} hello", 1)
+	require.NoError(t, err)
+	tmpl.Clock = &SkewedClock{Clock: base, Offset: -24 * time.Hour}
+
+	l := &Logger{Filename: logFile(dir), MaxLines: 1, Clock: base}
+	defer l.Close()
+	require.NoError(t, Generate(l, tmpl, 2))
+
+	// the line in the backup was stamped a day before the backup was named
+	files, err := l.oldLogFiles()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.True(t, strings.Contains(files[0].Name(), "2020-10-20T15-04-05"), files[0].Name())
+	b, err := ioutil.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Equal(t, "2020-10-19T15:04:05Z hello\n", string(b))
+}
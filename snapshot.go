@@ -0,0 +1,118 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// manifestName is the name of the manifest written into a snapshot.
+const manifestName = "manifest.json"
+
+// Snapshot copies the active log file and all of its backups into dir, along
+// with a manifest.json holding the Logger's Stats, so that the exact state of
+// the files can be kept when a test fails. The Logger's lock is held
+// throughout, so no write or rotation happens part way through, but data
+// still held in a write buffer is not included. The copies keep the base
+// names and modification times of the originals. The snapshot is written to
+// the real file system whatever the Logger's FileSystem.
+//
+// The snapshot is assembled in a temporary directory beside dir and then
+// renamed to dir, so dir, which must not already exist, either appears
+// complete or not at all.
+func (l *Logger) Snapshot(dir string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := os_Stat(dir); err == nil {
+		return fmt.Errorf("can't snapshot to %s: directory already exists", dir)
+	}
+	stats, err := l.stats()
+	if err != nil {
+		return err
+	}
+	parent := filepath.Dir(dir)
+	if err := os.MkdirAll(parent, 0744); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempDir(parent, "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	err = eachFamilyFile(&stats, func(path string) error {
+		return copyFile(l.fs(), path, filepath.Join(tmp, filepath.Base(path)))
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(tmp, manifestName))
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(stats); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+// eachFamilyFile calls f with the path of each file described by stats: the
+// active log file, if it exists, followed by the backups, newest first. A
+// backup for which f fails because it no longer exists, having been removed
+// by a cleanup in the background since it was listed, is dropped from stats.
+func eachFamilyFile(stats *Stats, f func(path string) error) error {
+	if !stats.Active.ModTime.IsZero() {
+		if err := f(stats.Active.Path); err != nil {
+			return err
+		}
+	}
+	var kept []FileStat
+	for _, b := range stats.Backups {
+		err := f(b.Path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		kept = append(kept, b)
+	}
+	stats.Backups = kept
+	return nil
+}
+
+// copyFile copies the file at from on fsys to the path to on the real file
+// system, keeping its permissions and modification time.
+func copyFile(fsys FileSystem, from, to string) error {
+	src, err := fsys.OpenFile(from, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := copyFiles(dst, src, 0); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(to, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,114 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxLines: 2, Sequential: true}
+	defer l.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	snap := filepath.Join(dir, "snapshots", "failure")
+	require.NoError(t, l.Snapshot(snap))
+
+	names := []string{manifestName}
+	for _, path := range []string{filename, filename + ".1", filename + ".2"} {
+		want, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		got, err := ioutil.ReadFile(filepath.Join(snap, filepath.Base(path)))
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		orig, err := os.Stat(path)
+		require.NoError(t, err)
+		cp, err := os.Stat(filepath.Join(snap, filepath.Base(path)))
+		require.NoError(t, err)
+		require.True(t, orig.ModTime().Equal(cp.ModTime()))
+		names = append(names, filepath.Base(path))
+	}
+
+	infos, err := ioutil.ReadDir(snap)
+	require.NoError(t, err)
+	require.Len(t, infos, len(names))
+	// the temporary directory is gone
+	infos, err = ioutil.ReadDir(filepath.Dir(snap))
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+
+	b, err := ioutil.ReadFile(filepath.Join(snap, manifestName))
+	require.NoError(t, err)
+	var stats Stats
+	require.NoError(t, json.Unmarshal(b, &stats))
+	require.Equal(t, int64(1), stats.Lines)
+	require.Len(t, stats.Backups, 2)
+
+	// a snapshot never overwrites an existing one
+	require.NoError(t, os.Remove(filepath.Join(snap, manifestName)))
+	require.Error(t, l.Snapshot(snap))
+	infos, err = ioutil.ReadDir(filepath.Dir(snap))
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+}
+
+func TestSnapshotDuringCleanup(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	logs := filepath.Join(dir, "logs")
+	clock := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC))
+	l := &Logger{Filename: filepath.Join(logs, "foo.log"), MaxLines: 1, MaxBackups: 1, Clock: clock}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	// old backups are removed in the background while snapshots are taken
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			clock.Advance(time.Second)
+			if _, err := l.Write([]byte("boo!\n")); err != nil {
+				return
+			}
+		}
+	}()
+	for i, running := 0, true; running; i++ {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		snap := filepath.Join(dir, "snapshots", fmt.Sprint(i))
+		require.NoError(t, l.Snapshot(snap))
+
+		// the manifest lists only the backups that were copied
+		b, err := ioutil.ReadFile(filepath.Join(snap, manifestName))
+		require.NoError(t, err)
+		var stats Stats
+		require.NoError(t, json.Unmarshal(b, &stats))
+		for _, backup := range stats.Backups {
+			_, err := os.Stat(filepath.Join(snap, filepath.Base(backup.Path)))
+			require.NoError(t, err)
+		}
+		infos, err := ioutil.ReadDir(snap)
+		require.NoError(t, err)
+		require.Len(t, infos, 2+len(stats.Backups))
+	}
+}
@@ -0,0 +1,129 @@
+package nanojack
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// StackTraceStyle is the language whose exceptions a StackTrace imitates.
+type StackTraceStyle int
+
+const (
+	// JavaStackTrace produces exceptions as printed by Throwable.printStackTrace.
+	JavaStackTrace StackTraceStyle = iota
+
+	// GoStackTrace produces panics as printed by the Go runtime.
+	GoStackTrace
+)
+
+// StackTrace is a Generator of ordinary log lines interspersed with
+// exceptions that span many physical lines, for testing how readers
+// recombine multiline entries. Every physical line is returned by its own
+// call to Line, so a Logger counts each of them and may rotate in the middle
+// of an exception. Everything but the timestamps is drawn from a random
+// source seeded with Seed.
+type StackTrace struct {
+	// Style is the language whose exceptions are imitated.
+	Style StackTraceStyle `json:"style" yaml:"style"`
+
+	// Frequency is the chance, in the range [0, 1], that an entry is an
+	// exception rather than a single line.
+	Frequency float64 `json:"frequency" yaml:"frequency"`
+
+	// Depth is the maximum number of stack frames in an exception. The
+	// number of frames is chosen at random between 1 and Depth. It defaults
+	// to 10.
+	Depth int `json:"depth" yaml:"depth"`
+
+	// Seed seeds the random source that the entries are drawn from.
+	Seed int64 `json:"seed" yaml:"seed"`
+
+	// Clock is the source of the time of each entry. It defaults to the
+	// system clock.
+	Clock Clock `json:"-" yaml:"-"`
+
+	rng     *rand.Rand
+	pending []string
+	buf     []byte
+}
+
+var (
+	javaExceptions = []string{
+		"java.lang.IllegalStateException", "java.lang.NullPointerException",
+		"java.io.IOException", "java.util.concurrent.TimeoutException",
+	}
+	goPanics = []string{
+		"runtime error: invalid memory address or nil pointer dereference",
+		"runtime error: index out of range [3] with length 2",
+		"assignment to entry in nil map",
+	}
+	logLevels = []string{"DEBUG", "INFO", "INFO", "INFO", "WARN"}
+)
+
+// Line implements Generator.
+func (s *StackTrace) Line() []byte {
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewSource(s.Seed))
+	}
+	if len(s.pending) == 0 {
+		if s.rng.Float64() < s.Frequency {
+			s.pending = s.exception()
+		} else {
+			s.pending = []string{s.header(pick(s.rng, logLevels)) + s.sentence()}
+		}
+	}
+	s.buf = append(s.buf[:0], s.pending[0]...)
+	s.pending = s.pending[1:]
+	return s.buf
+}
+
+// header returns the timestamp and level that begin an entry.
+func (s *StackTrace) header(level string) string {
+	return clockNow(s.Clock).UTC().Format(time.RFC3339Nano) + " " + level + " "
+}
+
+// sentence returns a few random words.
+func (s *StackTrace) sentence() string {
+	w := make([]string, 2+s.rng.Intn(4))
+	for i := range w {
+		w[i] = pick(s.rng, words)
+	}
+	return strings.Join(w, " ")
+}
+
+// exception returns the physical lines of a single exception.
+func (s *StackTrace) exception() []string {
+	depth := s.Depth
+	if depth <= 0 {
+		depth = 10
+	}
+	frames := 1 + s.rng.Intn(depth)
+
+	if s.Style == GoStackTrace {
+		lines := []string{
+			s.header("ERROR") + "panic: " + pick(s.rng, goPanics),
+			fmt.Sprintf("goroutine %d [running]:", 1+s.rng.Intn(100)),
+		}
+		for i := 0; i < frames; i++ {
+			pkg, fn := pick(s.rng, words), pick(s.rng, words)
+			lines = append(lines,
+				fmt.Sprintf("example.com/app/%s.%s(...)", pkg, fn),
+				fmt.Sprintf("\t/src/app/%s/%s.go:%d +0x%x", pkg, fn, 1+s.rng.Intn(500), s.rng.Intn(0x200)))
+		}
+		return lines
+	}
+
+	lines := []string{
+		s.header("ERROR") + s.sentence(),
+		pick(s.rng, javaExceptions) + ": " + s.sentence(),
+	}
+	for i := 0; i < frames; i++ {
+		class := pick(s.rng, words)
+		class = strings.ToUpper(class[:1]) + class[1:]
+		lines = append(lines, fmt.Sprintf("\tat com.example.%s.%s.%s(%s.java:%d)",
+			pick(s.rng, words), class, pick(s.rng, words), class, 1+s.rng.Intn(500)))
+	}
+	return lines
+}
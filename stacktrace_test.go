@@ -0,0 +1,64 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackTraceJava(t *testing.T) {
+	clock := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC))
+	s := &StackTrace{Style: JavaStackTrace, Frequency: 1, Depth: 3, Seed: 1, Clock: clock}
+
+	require.Regexp(t, `^2020-10-20T15:04:05Z ERROR [a-z ]+$`, string(s.Line()))
+	require.Regexp(t, `^java\.[a-z.]+\.[A-Za-z]+: [a-z ]+$`, string(s.Line()))
+	frame := regexp.MustCompile(`^\tat com\.example\.[a-z]+\.[A-Z][a-z]+\.[a-z]+\([A-Z][a-z]+\.java:\d+\)$`)
+	frames := 0
+	for {
+		line := string(s.Line())
+		if !frame.MatchString(line) {
+			require.Contains(t, line, " ERROR ")
+			break
+		}
+		frames++
+	}
+	require.True(t, frames >= 1 && frames <= 3, frames)
+}
+
+func TestStackTraceGo(t *testing.T) {
+	s := &StackTrace{Style: GoStackTrace, Frequency: 1, Depth: 1, Seed: 1}
+
+	require.Contains(t, string(s.Line()), " ERROR panic: ")
+	require.Regexp(t, `^goroutine \d+ \[running\]:$`, string(s.Line()))
+	require.Regexp(t, `^example\.com/app/[a-z]+\.[a-z]+\(\.\.\.\)$`, string(s.Line()))
+	require.Regexp(t, `^\t/src/app/[a-z]+/[a-z]+\.go:\d+ \+0x[0-9a-f]+$`, string(s.Line()))
+	require.Contains(t, string(s.Line()), " ERROR panic: ")
+}
+
+func TestStackTraceFrequency(t *testing.T) {
+	s := &StackTrace{Seed: 1}
+	for i := 0; i < 100; i++ {
+		require.NotContains(t, string(s.Line()), "ERROR")
+	}
+}
+
+func TestStackTraceAcrossRotation(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir), MaxLines: 4, Sequential: true}
+	defer l.Close()
+
+	// with this seed the first exception is longer than a file, so the
+	// second file begins in the middle of its frames
+	require.NoError(t, Generate(l, &StackTrace{Frequency: 1, Depth: 10, Seed: 2}, 12))
+
+	b, err := ioutil.ReadFile(logFile(dir) + ".1")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(b), "\tat "), string(b))
+}
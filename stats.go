@@ -0,0 +1,170 @@
+package nanojack
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// fingerprintSize is the number of leading bytes of a file recorded as its
+// fingerprint. It matches the default used by common log collectors.
+const fingerprintSize = 1000
+
+// FileID uniquely identifies a file on a machine. On Unix it holds the
+// device and inode numbers, and on Windows the volume serial number and file
+// index.
+type FileID struct {
+	Device uint64 `json:"device"`
+	Inode  uint64 `json:"inode"`
+}
+
+// FileStat describes one file of a Logger's log family.
+type FileStat struct {
+	// Path is the path of the file.
+	Path string `json:"path"`
+
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+
+	// ModTime is the modification time of the file.
+	ModTime time.Time `json:"modtime"`
+
+	// ID identifies the file independently of its name.
+	ID FileID `json:"id"`
+
+	// Fingerprint holds the first bytes of the file, up to 1000 bytes, as
+	// used by readers that identify files by their content.
+	Fingerprint []byte `json:"fingerprint"`
+
+	// FirstLine and LastLine give the range of lines in the file, numbered
+	// as for BackupInfo. They are omitted if the file is empty or was not
+	// written by this Logger.
+	FirstLine int64 `json:"firstline,omitempty"`
+	LastLine  int64 `json:"lastline,omitempty"`
+}
+
+// Stats describes the state of a Logger and its files.
+type Stats struct {
+	// Lines is the number of lines in the active log file.
+	Lines int64 `json:"lines"`
+
+	// Rotations is the number of rotations performed by the Logger.
+	Rotations int64 `json:"rotations"`
+
+	// TotalLines is the number of lines the Logger has written across all
+	// its files, as counted against MaxTotalLines.
+	TotalLines int64 `json:"totallines"`
+
+	// Active describes the active log file. It is the zero FileStat, apart
+	// from Path, if the file does not exist.
+	Active FileStat `json:"active"`
+
+	// Backups describes the backup files, newest first.
+	Backups []FileStat `json:"backups"`
+}
+
+// Stats returns the current state of the Logger and the files it manages.
+func (l *Logger) Stats() (Stats, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats()
+}
+
+// WriteManifest writes the Logger's Stats to w as JSON.
+func (l *Logger) WriteManifest(w io.Writer) error {
+	stats, err := l.Stats()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
+}
+
+func (l *Logger) stats() (Stats, error) {
+	s := Stats{
+		Lines:      l.lines,
+		Rotations:  l.rotations,
+		TotalLines: l.totalLines,
+		Active:     FileStat{Path: l.filename()},
+	}
+
+	active, err := statFile(l.fs(), l.filename())
+	if err == nil {
+		s.Active = active
+		if l.file != nil && l.lines > 0 {
+			s.Active.FirstLine, s.Active.LastLine = l.linesBefore+1, l.linesBefore+l.lines
+		}
+	} else if !os.IsNotExist(err) {
+		return s, err
+	}
+
+	backups, err := l.backupFiles()
+	if err != nil {
+		return s, err
+	}
+	for _, path := range backups {
+		b, err := statFile(l.fs(), path)
+		if os.IsNotExist(err) {
+			// removed by a cleanup in the background since it was listed
+			continue
+		} else if err != nil {
+			return s, err
+		}
+		meta := l.metaFor(path)
+		b.FirstLine, b.LastLine = meta.firstLine, meta.lastLine
+		s.Backups = append(s.Backups, b)
+	}
+	return s, nil
+}
+
+// statFile describes the file at path on fsys. Files that are not on the
+// real file system have no FileID.
+func statFile(fsys FileSystem, path string) (FileStat, error) {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return FileStat{}, err
+	}
+	var id FileID
+	if realFS(fsys) {
+		if id, err = fileID(path, info); err != nil {
+			return FileStat{}, err
+		}
+	}
+	fp, err := fingerprint(fsys, path)
+	if err != nil {
+		return FileStat{}, err
+	}
+	return FileStat{
+		Path:        path,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		ID:          id,
+		Fingerprint: fp,
+	}, nil
+}
+
+// fingerprint returns the leading bytes of the file at path.
+func fingerprint(fsys FileSystem, path string) ([]byte, error) {
+	f, err := fsys.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(io.LimitReader(f, fingerprintSize))
+}
+
+// backupFiles returns the paths of the Logger's backup files, newest first.
+func (l *Logger) backupFiles() ([]string, error) {
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	return paths, nil
+}
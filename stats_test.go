@@ -0,0 +1,164 @@
+package nanojack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 2,
+	}
+	defer l.Close()
+
+	s, err := l.Stats()
+	require.NoError(t, err)
+	require.Equal(t, filename, s.Active.Path)
+	require.Equal(t, FileStat{Path: filename}, s.Active)
+	require.Empty(t, s.Backups)
+
+	for i := 0; i < 3; i++ {
+		_, err = l.Write([]byte(fmt.Sprintf("line %d\n", i)))
+		require.NoError(t, err)
+	}
+	first := backupFile(dir)
+
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	second := backupFile(dir)
+
+	s, err = l.Stats()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), s.Lines)
+	require.Equal(t, int64(2), s.Rotations)
+	require.Equal(t, int64(0), s.Active.Size)
+	require.Empty(t, s.Active.Fingerprint)
+
+	require.Len(t, s.Backups, 2)
+	require.Equal(t, second, s.Backups[0].Path)
+	require.Equal(t, []byte("line 2\n"), s.Backups[0].Fingerprint)
+	require.Equal(t, first, s.Backups[1].Path)
+	require.Equal(t, []byte("line 0\nline 1\n"), s.Backups[1].Fingerprint)
+	require.Equal(t, int64(14), s.Backups[1].Size)
+
+	// the line ranges of the backups meet, with none missing
+	require.Equal(t, int64(1), s.Backups[1].FirstLine)
+	require.Equal(t, int64(2), s.Backups[1].LastLine)
+	require.Equal(t, int64(3), s.Backups[0].FirstLine)
+	require.Equal(t, int64(3), s.Backups[0].LastLine)
+	require.Zero(t, s.Active.FirstLine)
+
+	ids := map[FileID]bool{s.Active.ID: true}
+	for _, b := range s.Backups {
+		require.NotZero(t, b.ID.Inode)
+		ids[b.ID] = true
+	}
+	require.Len(t, ids, 3)
+}
+
+func TestStatsSequential(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxLines:   1,
+		Sequential: true,
+	}
+	defer l.Close()
+
+	for i := 0; i < 12; i++ {
+		_, err := l.Write([]byte(fmt.Sprintf("line %d\n", i)))
+		require.NoError(t, err)
+	}
+
+	s, err := l.Stats()
+	require.NoError(t, err)
+	require.Len(t, s.Backups, 11)
+	for i, b := range s.Backups {
+		require.Equal(t, fmt.Sprintf("%s.%d", filename, i+1), b.Path)
+		require.Equal(t, []byte(fmt.Sprintf("line %d\n", 10-i)), b.Fingerprint)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, l.WriteManifest(buf))
+
+	var s Stats
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &s))
+	require.Equal(t, int64(1), s.Lines)
+	require.Equal(t, logFile(dir), s.Active.Path)
+	require.Equal(t, []byte("boo!\n"), s.Active.Fingerprint)
+	require.NotZero(t, s.Active.ID.Inode)
+}
+
+func TestStatsDuringCleanup(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clock := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 0, time.UTC))
+	l := &Logger{Filename: logFile(dir), MaxLines: 1, MaxBackups: 1, Clock: clock}
+	defer l.Close()
+
+	// old backups are removed in the background while Stats lists them
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			clock.Advance(time.Second)
+			if _, err := l.Write([]byte("boo!\n")); err != nil {
+				return
+			}
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		_, err := l.Stats()
+		require.NoError(t, err)
+	}
+
+	// a backup that vanishes between its listing and its stat is left out
+	var backups []string
+	require.Eventually(t, func() bool {
+		backups, _ = l.backupFiles()
+		return len(backups) == 1
+	}, time.Second, time.Millisecond)
+	os_Stat = func(name string) (os.FileInfo, error) {
+		if name == backups[0] {
+			os.Remove(name)
+		}
+		return os.Stat(name)
+	}
+	defer func() { os_Stat = os.Stat }()
+	s, err := l.Stats()
+	require.NoError(t, err)
+	require.Empty(t, s.Backups)
+}
@@ -0,0 +1,48 @@
+package nanojack
+
+// fileSync exists so it can be mocked out by tests.
+var fileSync = File.Sync
+
+// Sync writes out any buffered data and commits the active log file to
+// disk.
+func (l *Logger) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sync()
+}
+
+// sync writes out any buffered data and commits the active log file to
+// disk.
+func (l *Logger) sync() error {
+	if l.file == nil {
+		return nil
+	}
+	l.unsynced = 0
+	l.lastSync = l.now()
+	if err := l.flush(); err != nil {
+		return err
+	}
+	return fileSync(l.file)
+}
+
+// syncPolicy syncs the active log file after a line has been written, if
+// SyncEveryLines or SyncEveryDuration call for it.
+func (l *Logger) syncPolicy() error {
+	if l.SyncEveryLines <= 0 && l.SyncEveryDuration <= 0 {
+		return nil
+	}
+	l.unsynced++
+	if l.SyncEveryLines > 0 && l.unsynced >= l.SyncEveryLines {
+		return l.sync()
+	}
+	if l.SyncEveryDuration > 0 {
+		now := l.now()
+		if l.lastSync.IsZero() {
+			// the interval starts with the first write
+			l.lastSync = now
+		} else if now.Sub(l.lastSync) >= l.SyncEveryDuration {
+			return l.sync()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countSyncs replaces fileSync with a counter, and returns a function that
+// restores it.
+func countSyncs(n *int) func() {
+	fileSync = func(f File) error {
+		*n++
+		return f.Sync()
+	}
+	return func() { fileSync = File.Sync }
+}
+
+func TestSyncEveryLines(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var syncs int
+	defer countSyncs(&syncs)()
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		MaxLines:       100,
+		SyncEveryLines: 3,
+	}
+	defer l.Close()
+
+	for i := 0; i < 7; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, syncs)
+
+	require.NoError(t, l.Sync())
+	require.Equal(t, 3, syncs)
+
+	// Sync restarts the count
+	for i := 0; i < 2; i++ {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, syncs)
+}
+
+func TestSyncEveryDuration(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var syncs int
+	defer countSyncs(&syncs)()
+
+	l := &Logger{
+		Filename:          logFile(dir),
+		MaxLines:          100,
+		SyncEveryDuration: time.Minute,
+	}
+	defer l.Close()
+
+	write := func() {
+		_, err := l.Write([]byte("boo!\n"))
+		require.NoError(t, err)
+	}
+	write()
+	newFakeTime(30 * time.Second)
+	write()
+	require.Equal(t, 0, syncs)
+
+	newFakeTime(30 * time.Second)
+	write()
+	require.Equal(t, 1, syncs)
+	write()
+	require.Equal(t, 1, syncs)
+}
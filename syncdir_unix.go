@@ -0,0 +1,18 @@
+// +build !windows
+
+package nanojack
+
+import (
+	"os"
+)
+
+// syncDir syncs the directory at path to disk, so that the files created in
+// it are durable.
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return fileSync(d)
+}
@@ -0,0 +1,7 @@
+package nanojack
+
+// syncDir does nothing on Windows, where directories can't be synced and
+// the file system commits new entries with the files themselves.
+func syncDir(path string) error {
+	return nil
+}
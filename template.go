@@ -0,0 +1,137 @@
+package nanojack
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Template is a Generator that fills in a line of text from a template. The
+// template is copied verbatim, except for tokens in braces, which are
+// replaced on every line:
+//
+//	{uuid}        a random version 4 UUID
+//	{ipv4}        a random IPv4 address
+//	{word}        a random lowercase word
+//	{int:lo-hi}   a random integer in the range [lo, hi]
+//	{ts}          the current time in RFC 3339 format with nanoseconds
+//
+// A literal brace is written by doubling it, as in "{{" or "}}".
+type Template struct {
+	// Clock is the source of the time used by {ts}. It defaults to the
+	// system clock.
+	Clock Clock
+
+	rng   *rand.Rand
+	parts []templatePart
+	buf   []byte
+}
+
+// templatePart is a literal piece of a template, or a token if fill is set.
+type templatePart struct {
+	literal string
+	fill    func(t *Template, b []byte) []byte
+}
+
+// NewTemplate parses text into a Template whose random tokens are drawn from
+// a source seeded with seed.
+func NewTemplate(text string, seed int64) (*Template, error) {
+	t := &Template{rng: rand.New(rand.NewSource(seed))}
+	var literal strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c == '}' {
+			if i+1 < len(text) && text[i+1] == '}' {
+				i++
+			}
+			literal.WriteByte(c)
+			continue
+		}
+		if c != '{' {
+			literal.WriteByte(c)
+			continue
+		}
+		if i+1 < len(text) && text[i+1] == '{' {
+			literal.WriteByte(c)
+			i++
+			continue
+		}
+		end := strings.IndexByte(text[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated template token at offset %d", i)
+		}
+		fill, err := parseToken(text[i+1 : i+end])
+		if err != nil {
+			return nil, err
+		}
+		if literal.Len() > 0 {
+			t.parts = append(t.parts, templatePart{literal: literal.String()})
+			literal.Reset()
+		}
+		t.parts = append(t.parts, templatePart{fill: fill})
+		i += end
+	}
+	if literal.Len() > 0 {
+		t.parts = append(t.parts, templatePart{literal: literal.String()})
+	}
+	return t, nil
+}
+
+// parseToken returns the function that fills in the token named by tok.
+func parseToken(tok string) (func(t *Template, b []byte) []byte, error) {
+	switch tok {
+	case "uuid":
+		return (*Template).uuid, nil
+	case "ipv4":
+		return (*Template).ipv4, nil
+	case "word":
+		return (*Template).word, nil
+	case "ts":
+		return (*Template).ts, nil
+	}
+	if strings.HasPrefix(tok, "int:") {
+		bounds := strings.SplitN(tok[len("int:"):], "-", 2)
+		if len(bounds) == 2 {
+			lo, err1 := strconv.ParseInt(bounds[0], 10, 64)
+			hi, err2 := strconv.ParseInt(bounds[1], 10, 64)
+			if err1 == nil && err2 == nil && lo <= hi {
+				return func(t *Template, b []byte) []byte {
+					return strconv.AppendInt(b, lo+t.rng.Int63n(hi-lo+1), 10)
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid integer range in template token %q", tok)
+	}
+	return nil, fmt.Errorf("unknown template token %q", tok)
+}
+
+// Line implements Generator.
+func (t *Template) Line() []byte {
+	t.buf = t.buf[:0]
+	for _, p := range t.parts {
+		if p.fill != nil {
+			t.buf = p.fill(t, t.buf)
+		} else {
+			t.buf = append(t.buf, p.literal...)
+		}
+	}
+	return t.buf
+}
+
+func (t *Template) uuid(b []byte) []byte {
+	return appendUUID(b, t.rng)
+}
+
+func (t *Template) ipv4(b []byte) []byte {
+	return appendIPv4(b, t.rng)
+}
+
+func (t *Template) word(b []byte) []byte {
+	return append(b, pick(t.rng, words)...)
+}
+
+func (t *Template) ts(b []byte) []byte {
+	return clockNow(t.Clock).AppendFormat(b, time.RFC3339Nano)
+}
@@ -0,0 +1,73 @@
+package nanojack
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateTokens(t *testing.T) {
+	clock := NewFakeClock(time.Date(2020, 10, 20, 15, 4, 5, 6, time.UTC))
+	tmpl, err := NewTemplate("{ts} {ipv4} {{id={uuid}}} {word} took {int:1-100}ms", 42)
+	require.NoError(t, err)
+	tmpl.Clock = clock
+
+	re := regexp.MustCompile(`^2020-10-20T15:04:05.000000006Z ` +
+		`(\d+)\.(\d+)\.(\d+)\.(\d+) ` +
+		`\{id=[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\} ` +
+		`[a-z]+ took (\d+)ms$`)
+	for i := 0; i < 100; i++ {
+		line := string(tmpl.Line())
+		m := re.FindStringSubmatch(line)
+		require.NotNil(t, m, line)
+		for _, octet := range m[1:5] {
+			n, err := strconv.Atoi(octet)
+			require.NoError(t, err)
+			require.True(t, n >= 0 && n <= 255, line)
+		}
+		n, err := strconv.Atoi(m[5])
+		require.NoError(t, err)
+		require.True(t, n >= 1 && n <= 100, line)
+	}
+}
+
+func TestTemplateDeterministic(t *testing.T) {
+	lines := func(seed int64) []string {
+		tmpl, err := NewTemplate("{uuid} {ipv4} {word} {int:0-9}", seed)
+		require.NoError(t, err)
+		var out []string
+		for i := 0; i < 10; i++ {
+			out = append(out, string(tmpl.Line()))
+		}
+		return out
+	}
+	require.Equal(t, lines(1), lines(1))
+	require.NotEqual(t, lines(1), lines(2))
+}
+
+func TestTemplateInvalid(t *testing.T) {
+	for _, text := range []string{"{nope}", "{int:5-1}", "{int:a-b}", "{int:3}", "open {uuid"} {
+		_, err := NewTemplate(text, 0)
+		require.Error(t, err, text)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	tmpl, err := NewTemplate("request from {ipv4}", 7)
+	require.NoError(t, err)
+
+	l := &Logger{Filename: logFile(dir), MaxLines: 5, Sequential: true}
+	defer l.Close()
+
+	require.NoError(t, Generate(l, tmpl, 12))
+	existsWithLines(logFile(dir), 2, t)
+	existsWithLines(logFile(dir)+".1", 5, t)
+	existsWithLines(logFile(dir)+".2", 5, t)
+}
@@ -0,0 +1,34 @@
+package nanojack
+
+import (
+	"errors"
+)
+
+// ErrWriteTimeout is returned by Write when it takes longer than WriteTimeout.
+var ErrWriteTimeout = errors.New("nanojack: write timed out")
+
+type writeResult struct {
+	n   int
+	err error
+}
+
+// writeWithTimeout writes p, giving up after WriteTimeout.
+func (l *Logger) writeWithTimeout(p []byte) (int, error) {
+	// the write may outlive this call, after which the caller is free to
+	// reuse p.
+	buf := append([]byte(nil), p...)
+	result := make(chan writeResult, 1)
+	go func() {
+		n, err := l.write(buf)
+		result <- writeResult{n, err}
+	}()
+
+	timeout, timer := l.after(l.WriteTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-result:
+		return r.n, r.err
+	case <-timeout:
+		return 0, ErrWriteTimeout
+	}
+}
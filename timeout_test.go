@@ -0,0 +1,40 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTimeout(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		WriteTimeout: 10 * time.Millisecond,
+		WriteLatency: 200 * time.Millisecond,
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	start := time.Now()
+	n, err := l.Write(b)
+	require.Equal(t, ErrWriteTimeout, err)
+	require.Equal(t, 0, n)
+	require.True(t, time.Since(start) < 200*time.Millisecond)
+
+	// the timed out write still completes
+	require.NoError(t, l.Close())
+	existsWithLines(filename, 1, t)
+
+	l.WriteLatency = 0
+	n, err = l.Write(b)
+	require.NoError(t, err)
+	require.Equal(t, len(b), n)
+	existsWithLines(filename, 2, t)
+}
@@ -0,0 +1,41 @@
+package nanojack
+
+// Transformer rewrites lines before they are written by a Logger.
+type Transformer interface {
+	// Transform returns the form of p to write instead of p, or nil to drop
+	// it. It must not modify p, but may return it unchanged.
+	Transform(p []byte) []byte
+}
+
+// TransformerFunc adapts a function to the Transformer interface.
+type TransformerFunc func(p []byte) []byte
+
+// Transform calls f(p).
+func (f TransformerFunc) Transform(p []byte) []byte {
+	return f(p)
+}
+
+// transform applies the Logger's Transformers to p in order.
+func (l *Logger) transform(p []byte) []byte {
+	for _, t := range l.Transformers {
+		if p = t.Transform(p); p == nil {
+			return nil
+		}
+	}
+	return p
+}
+
+// transformedCount converts n, the number of bytes of line that were written,
+// into the number of bytes of p to report to the caller, where line is the
+// transformed form of p. A partial write of a line that changed length is
+// reported as no bytes written.
+func transformedCount(p, line []byte, n int) int {
+	switch {
+	case n == len(line):
+		return len(p)
+	case len(line) == len(p):
+		return n
+	default:
+		return 0
+	}
+}
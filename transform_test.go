@@ -0,0 +1,60 @@
+package nanojack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformers(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+		MaxLines: 100,
+		Transformers: []Transformer{
+			// drop debug lines
+			TransformerFunc(func(p []byte) []byte {
+				if bytes.HasPrefix(p, []byte("DEBUG")) {
+					return nil
+				}
+				return p
+			}),
+			// redact passwords
+			TransformerFunc(func(p []byte) []byte {
+				return bytes.Replace(p, []byte("hunter2"), []byte("*******"), -1)
+			}),
+			// annotate with a trace ID
+			TransformerFunc(func(p []byte) []byte {
+				return append([]byte("trace=abc "), p...)
+			}),
+		},
+	}
+	defer l.Close()
+
+	for _, line := range []string{"login password=hunter2\n", "DEBUG noise\n", "done\n"} {
+		n, err := l.Write([]byte(line))
+		require.NoError(t, err)
+		require.Equal(t, len(line), n)
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "trace=abc login password=*******\ntrace=abc done\n", string(b))
+
+	stats, err := l.Stats()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stats.Lines)
+}
+
+func TestTransformedCount(t *testing.T) {
+	p := []byte("abcd")
+	require.Equal(t, 4, transformedCount(p, []byte("abcdef"), 6))
+	require.Equal(t, 0, transformedCount(p, []byte("abcdef"), 3))
+	require.Equal(t, 2, transformedCount(p, []byte("ABCD"), 2))
+}
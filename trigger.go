@@ -0,0 +1,63 @@
+package nanojack
+
+import (
+	"time"
+)
+
+// TriggerState describes the active log file and the write about to be made
+// to it, for a Trigger to decide whether to rotate.
+type TriggerState struct {
+	// Filename is the active log file.
+	Filename string
+
+	// Lines and Size are the number of lines and bytes in the active file.
+	Lines int64
+	Size  int64
+
+	// Opened is when the active file was started, and Now is the current
+	// time, both according to the Logger's Clock.
+	Opened time.Time
+	Now    time.Time
+
+	// Line is the data about to be written. It must not be modified or
+	// retained.
+	Line []byte
+}
+
+// Trigger is a custom condition for rotating a Logger's active file.
+type Trigger interface {
+	// ShouldRotate reports whether the active file should be rotated before
+	// the pending write. It is called with the Logger's lock held, so it
+	// must not call the Logger's methods.
+	ShouldRotate(s TriggerState) bool
+}
+
+// TriggerFunc adapts a function to the Trigger interface.
+type TriggerFunc func(s TriggerState) bool
+
+// ShouldRotate calls f(s).
+func (f TriggerFunc) ShouldRotate(s TriggerState) bool {
+	return f(s)
+}
+
+// triggered reports whether any of the Logger's Triggers is met by the write
+// of p.
+func (l *Logger) triggered(p []byte) bool {
+	if len(l.Triggers) == 0 {
+		return false
+	}
+	s := TriggerState{
+		Filename: l.filename(),
+		Lines:    l.lines,
+		Size:     l.size,
+		Opened:   l.opened,
+		Now:      l.now(),
+		Line:     p,
+	}
+	for _, t := range l.Triggers {
+		if t.ShouldRotate(s) {
+			return true
+		}
+	}
+	return false
+}
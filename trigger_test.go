@@ -0,0 +1,60 @@
+package nanojack
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// afterMarker rotates after a line containing its marker has been written.
+type afterMarker struct {
+	marker []byte
+	seen   bool
+}
+
+func (a *afterMarker) ShouldRotate(s TriggerState) bool {
+	rotate := a.seen
+	a.seen = bytes.Contains(s.Line, a.marker)
+	return rotate
+}
+
+func TestTriggers(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var states []TriggerState
+	l := &Logger{
+		Filename: filename,
+		Triggers: []Trigger{
+			TriggerFunc(func(s TriggerState) bool {
+				states = append(states, s)
+				return false
+			}),
+			&afterMarker{marker: []byte("END")},
+		},
+	}
+	defer l.Close()
+
+	for _, line := range []string{"one\n", "two END\n"} {
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	fileCount(dir, 1, t)
+
+	newFakeTime(time.Second)
+	_, err := l.Write([]byte("three\n"))
+	require.NoError(t, err)
+	existsWithLines(backupFile(dir), 2, t)
+	existsWithLines(filename, 1, t)
+
+	require.Len(t, states, 3)
+	require.Equal(t, int64(1), states[1].Lines)
+	require.Equal(t, int64(len("one\n")), states[1].Size)
+	require.Equal(t, "two END\n", string(states[1].Line))
+	require.Equal(t, filename, states[1].Filename)
+}
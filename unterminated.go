@@ -0,0 +1,59 @@
+package nanojack
+
+// newline is the line terminator held back by Unterminated.
+var newline = []byte{'\n'}
+
+// writeLine writes line to the active log file in the configured Encoding,
+// through the buffer if there is one, preceded by a byte order mark if BOM is
+// set and the file is new. If Unterminated is set, the newline held back from
+// the previous line is written first, and the newline ending this line is
+// held back in turn. The newline is included in the count of bytes written
+// either way.
+func (l *Logger) writeLine(line []byte) (int, error) {
+	if err := l.terminate(); err != nil {
+		return 0, err
+	}
+	if l.BOM && l.lines == 0 && l.size == 0 {
+		if _, err := l.writeOut(l.Encoding.bom()); err != nil {
+			return 0, err
+		}
+	}
+
+	hold := l.Unterminated && len(line) > 0 && line[len(line)-1] == '\n'
+	if hold {
+		line = line[:len(line)-1]
+	}
+	encoded := l.encode(line)
+	n, err := l.writeOut(encoded)
+	n = transformedCount(line, encoded, n)
+	if err != nil || !hold {
+		return n, err
+	}
+	l.held = true
+	return n + 1, nil
+}
+
+// terminate writes the newline held back from the previous line, if any.
+func (l *Logger) terminate() error {
+	if !l.held {
+		return nil
+	}
+	if _, err := l.writeOut(l.encode(newline)); err != nil {
+		return err
+	}
+	l.held = false
+	return nil
+}
+
+// writeOut writes p to the batch being collected by WriteLines, the buffer if
+// there is one, or else to the active log file.
+func (l *Logger) writeOut(p []byte) (int, error) {
+	if l.batching {
+		l.batch = append(l.batch, p...)
+		return len(p), nil
+	}
+	if l.BufferSize > 0 {
+		return l.writeBuffered(p)
+	}
+	return l.writeFile(p)
+}
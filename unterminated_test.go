@@ -0,0 +1,69 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnterminated(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, MaxLines: 2, Sequential: true, Unterminated: true}
+	defer l.Close()
+
+	contents := func(path string) string {
+		b, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	n, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, "one", contents(filename))
+
+	n, err = l.Write([]byte("two\n"))
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, "one\ntwo", contents(filename))
+
+	// the held newline is written before the file is rotated
+	_, err = l.Write([]byte("three\n"))
+	require.NoError(t, err)
+	require.Equal(t, "one\ntwo\n", contents(filename+".1"))
+	require.Equal(t, "three", contents(filename))
+
+	// a line that is already unterminated is written as is
+	_, err = l.Write([]byte("partial"))
+	require.NoError(t, err)
+	require.Equal(t, "three\npartial", contents(filename))
+
+	require.NoError(t, l.Close())
+	require.Equal(t, "three\npartial", contents(filename))
+}
+
+func TestUnterminatedClose(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{Filename: filename, BufferSize: 1024, Unterminated: true}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	require.NoError(t, err)
+	require.NoError(t, l.Flush())
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "one", string(b))
+
+	require.NoError(t, l.Close())
+	b, err = ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(b))
+}
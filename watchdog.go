@@ -0,0 +1,16 @@
+package nanojack
+
+// watchdog starts timing the operation op on the log file filename, and
+// returns a function to call when it completes. If the operation takes longer
+// than StallThreshold, an EventStall is emitted.
+func (l *Logger) watchdog(op, filename string) (stop func()) {
+	if l.StallThreshold <= 0 {
+		return func() {}
+	}
+	threshold := l.StallThreshold
+	t := l.afterFunc(threshold, func() {
+		l.debug("error", "operation stalled", "op", op, "threshold", threshold)
+		l.emit(Event{Type: EventStall, Filename: filename, Op: op})
+	})
+	return func() { t.Stop() }
+}
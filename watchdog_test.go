@@ -0,0 +1,44 @@
+package nanojack
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStallThreshold(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename:       logFile(dir),
+		RotateLatency:  100 * time.Millisecond,
+		StallThreshold: 10 * time.Millisecond,
+		Events:         events,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!\n"))
+	require.NoError(t, err)
+
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+
+	// the stall is reported before the slow rotation completes
+	e := nextEvent(t, events)
+	require.Equal(t, EventStall, e.Type)
+	require.Equal(t, "rotate", e.Op)
+	require.Equal(t, EventRotate, nextEvent(t, events).Type)
+
+	// a quick rotation is not reported
+	l.RotateLatency = 0
+	newFakeTime(time.Second)
+	require.NoError(t, l.Rotate())
+	require.Equal(t, EventRotate, nextEvent(t, events).Type)
+	time.Sleep(20 * time.Millisecond)
+	require.Empty(t, events)
+}
@@ -0,0 +1,85 @@
+package nanojack
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// fsOp is a file system operation performed by the Logger, which the watcher
+// should not report as external interference.
+type fsOp int
+
+const (
+	opCreate fsOp = iota
+	opRename
+	opRemove
+)
+
+// watcher observes the Logger's directory and emits events for changes that
+// the Logger did not make itself.
+type watcher struct {
+	close func() error
+	done  chan struct{}
+
+	// filename is the log file being watched, named in the watcher's events.
+	filename string
+
+	mu       sync.Mutex
+	expected map[fsOp]map[string]int
+}
+
+// newWatcher returns a watcher of the log file filename, closed by close,
+// that expects nothing.
+func newWatcher(filename string, close func() error) *watcher {
+	return &watcher{
+		close:    close,
+		done:     make(chan struct{}),
+		filename: filename,
+		expected: map[fsOp]map[string]int{
+			opCreate: {},
+			opRename: {},
+			opRemove: {},
+		},
+	}
+}
+
+// stopWatcher stops watching the Logger's directory, and waits until no more
+// events will be emitted by the watcher.
+func (l *Logger) stopWatcher() error {
+	if l.watcher == nil {
+		return nil
+	}
+	err := l.watcher.close()
+	<-l.watcher.done
+	l.watcher = nil
+	return err
+}
+
+// expect records that the Logger is about to perform op on each of paths, so
+// that the watcher does not report it as external interference.
+func (l *Logger) expect(op fsOp, paths ...string) {
+	if l.watcher == nil {
+		return
+	}
+	l.watcher.mu.Lock()
+	defer l.watcher.mu.Unlock()
+	for _, p := range paths {
+		l.watcher.expected[op][filepath.Clean(p)]++
+	}
+}
+
+// consume reports whether op on path was expected, and forgets the
+// expectation if so.
+func (w *watcher) consume(op fsOp, path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	path = filepath.Clean(path)
+	if w.expected[op][path] == 0 {
+		return false
+	}
+	w.expected[op][path]--
+	if w.expected[op][path] == 0 {
+		delete(w.expected[op], path)
+	}
+	return true
+}
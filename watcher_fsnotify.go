@@ -0,0 +1,65 @@
+// +build !js
+
+package nanojack
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// startWatcher begins watching the Logger's directory.
+func (l *Logger) startWatcher() error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := fw.Add(l.dir()); err != nil {
+		fw.Close()
+		return err
+	}
+	w := newWatcher(l.filename(), fw.Close)
+	l.watcher = w
+	go w.run(l, fw)
+	return nil
+}
+
+func (w *watcher) run(l *Logger, fw *fsnotify.Watcher) {
+	defer close(w.done)
+	for {
+		select {
+		case e, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+			w.handle(l, e)
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+			l.emit(Event{Type: EventWatchError, Filename: w.filename, Err: err})
+		}
+	}
+}
+
+func (w *watcher) handle(l *Logger, e fsnotify.Event) {
+	if l.JournalFile != "" && filepath.Clean(e.Name) == filepath.Clean(l.JournalFile) {
+		// the journal's own changes are not interference
+		return
+	}
+	ops := []struct {
+		fs  fsnotify.Op
+		op  fsOp
+		typ EventType
+	}{
+		{fsnotify.Create, opCreate, EventWatchCreate},
+		{fsnotify.Rename, opRename, EventWatchRename},
+		{fsnotify.Remove, opRemove, EventWatchRemove},
+	}
+	for _, o := range ops {
+		if e.Op&o.fs == 0 || w.consume(o.op, e.Name) {
+			continue
+		}
+		l.emit(Event{Type: o.typ, Filename: w.filename, Path: e.Name})
+	}
+}
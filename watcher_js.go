@@ -0,0 +1,10 @@
+package nanojack
+
+import (
+	"errors"
+)
+
+// startWatcher fails, since there is no way to watch a directory on js/wasm.
+func (l *Logger) startWatcher() error {
+	return errors.New("nanojack: watching is not supported on js/wasm")
+}
@@ -0,0 +1,75 @@
+package nanojack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	t.Run("MoveCreate", testWatch(t, false))
+	t.Run("CopyTruncate", testWatch(t, true))
+}
+
+func testWatch(t *testing.T, copyTruncate bool) func(t *testing.T) {
+	return func(t *testing.T) {
+		currentTime = fakeTime
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		events := make(chan Event, 100)
+		l := &Logger{
+			Filename:     logFile(dir),
+			MaxLines:     1,
+			MaxBackups:   1,
+			CopyTruncate: copyTruncate,
+			Watch:        true,
+			Events:       events,
+		}
+		defer l.Close()
+
+		// the logger's own creates, renames, and removes are not reported
+		b := []byte("boo!\n")
+		for i := 0; i < 4; i++ {
+			newFakeTime(time.Second)
+			_, err := l.Write(b)
+			require.NoError(t, err)
+		}
+
+		for len(events) > 0 {
+			e := <-events
+			require.Contains(t, []EventType{EventRotate, EventRemove}, e.Type)
+		}
+
+		other := filepath.Join(dir, "other.log")
+		require.NoError(t, ioutil.WriteFile(other, b, 0644))
+		e := nextEvent(t, events)
+		require.Equal(t, EventWatchCreate, e.Type)
+		require.Equal(t, other, e.Path)
+
+		require.NoError(t, os.Remove(other))
+		e = nextEvent(t, events)
+		require.Equal(t, EventWatchRemove, e.Type)
+		require.Equal(t, other, e.Path)
+
+		require.NoError(t, l.Close())
+		require.NoError(t, ioutil.WriteFile(other, b, 0644))
+		<-time.After(10 * time.Millisecond)
+		require.Empty(t, events)
+	}
+}
+
+// nextEvent waits for the next event on the channel.
+func nextEvent(t testing.TB, events <-chan Event) Event {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for event")
+		return Event{}
+	}
+}
@@ -0,0 +1,127 @@
+package nanojack
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Webhook posts a JSON description of each rotation to a URL, so that an
+// external test orchestrator can follow a Logger's rotations as they happen.
+// Requests are made in the background, so they never delay writes, and each
+// one is reported in an EventWebhook.
+type Webhook struct {
+	// URL is the address the rotations are posted to.
+	URL string `json:"url" yaml:"url"`
+
+	// Headers are added to every request, for example for authentication.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+
+	// Client is the HTTP client used to send requests. It defaults to
+	// http.DefaultClient.
+	Client *http.Client `json:"-" yaml:"-"`
+}
+
+// WebhookPayload is the JSON body posted by a Webhook for each rotation.
+type WebhookPayload struct {
+	// Filename is the active log file that was rotated.
+	Filename string `json:"filename"`
+
+	// Backup is the backup the rotated file became.
+	Backup string `json:"backup"`
+
+	// Time is the time of the rotation.
+	Time time.Time `json:"time"`
+
+	// Rotation is the number of rotations the Logger has made, including
+	// this one.
+	Rotation int64 `json:"rotation"`
+
+	// Lines is the number of lines in the rotated file.
+	Lines int64 `json:"lines"`
+
+	// Size is the size of the backup in bytes.
+	Size int64 `json:"size"`
+
+	// SHA256 and MD5 are hex encoded checksums of the backup.
+	SHA256 string `json:"sha256"`
+	MD5    string `json:"md5"`
+}
+
+// webhook posts the rotation of a file with the given number of lines into
+// the backup at path to the Webhook, if there is one. The backup is opened
+// immediately, so that its checksums are not affected by it being renamed in
+// the meantime.
+func (l *Logger) webhook(backup string, lines int64) {
+	if l.Webhook == nil || backup == "" {
+		return
+	}
+	payload := WebhookPayload{
+		Filename: l.filename(),
+		Backup:   backup,
+		Time:     l.now(),
+		Rotation: l.rotations,
+		Lines:    lines,
+	}
+	f, err := l.fs().OpenFile(backup, os.O_RDONLY, 0)
+	if err != nil {
+		l.emit(Event{Type: EventWebhook, Filename: payload.Filename, Path: backup, Err: err})
+		return
+	}
+	go func() {
+		defer f.Close()
+		err := l.Webhook.post(f, payload)
+		if err != nil {
+			l.debug("error", "webhook failed", "backup", backup, "error", err)
+		}
+		l.emit(Event{Type: EventWebhook, Filename: payload.Filename, Path: backup, Err: err})
+	}()
+}
+
+// post fills in the size and checksums of the backup f and posts payload.
+func (w *Webhook) post(f io.Reader, payload WebhookPayload) error {
+	sha, md := sha256.New(), md5.New()
+	size, err := io.Copy(io.MultiWriter(sha, md), f)
+	if err != nil {
+		return err
+	}
+	payload.Size = size
+	payload.SHA256 = hex.EncodeToString(sha.Sum(nil))
+	payload.MD5 = hex.EncodeToString(md.Sum(nil))
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("can't post rotation to %s: %s: %s", w.URL, resp.Status, msg)
+	}
+	return nil
+}
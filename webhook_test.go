@@ -0,0 +1,87 @@
+package nanojack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	payloads := make(chan WebhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		if r.Header.Get("X-Token") != "secret" || json.NewDecoder(r.Body).Decode(&p) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		payloads <- p
+	}))
+	defer srv.Close()
+
+	events := make(chan Event, 10)
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxLines: 2,
+		Webhook:  &Webhook{URL: srv.URL, Headers: map[string]string{"X-Token": "secret"}},
+		Events:   events,
+	}
+	defer l.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	var e Event
+	for e.Type != EventWebhook {
+		e = nextEvent(t, events)
+	}
+	require.NoError(t, e.Err)
+
+	p := <-payloads
+	sum := sha256.Sum256([]byte("one\ntwo\n"))
+	require.Equal(t, logFile(dir), p.Filename)
+	require.Equal(t, backupFile(dir), p.Backup)
+	require.True(t, fakeTime().Equal(p.Time))
+	require.Equal(t, int64(1), p.Rotation)
+	require.Equal(t, int64(2), p.Lines)
+	require.Equal(t, int64(8), p.Size)
+	require.Equal(t, hex.EncodeToString(sum[:]), p.SHA256)
+	require.Equal(t, "2094b601daac3d68f5aed51d3c20f7cd", p.MD5)
+}
+
+func TestWebhookError(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	events := make(chan Event, 10)
+	l := &Logger{Filename: logFile(dir), MaxLines: 1, Webhook: &Webhook{URL: srv.URL}, Events: events}
+	defer l.Close()
+
+	for _, line := range []string{"one\n", "two\n"} {
+		_, err := l.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	var e Event
+	for e.Type != EventWebhook {
+		e = nextEvent(t, events)
+	}
+	require.Error(t, e.Err)
+	require.Contains(t, e.Err.Error(), "503")
+}